@@ -0,0 +1,602 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"log/slog"
+	"os"
+	"os/signal"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/romangod6/kb-crawler/internal/crawler"
+	"github.com/romangod6/kb-crawler/internal/models"
+	"github.com/romangod6/kb-crawler/internal/queue"
+	"github.com/romangod6/kb-crawler/internal/scheduler"
+	"github.com/romangod6/kb-crawler/internal/storage"
+	"github.com/romangod6/kb-crawler/internal/utils"
+	"github.com/romangod6/kb-crawler/internal/webhook"
+)
+
+// crawlCommand crawls a crawler config in this process: once with --once, or on the config's
+// schedule until the process is signalled. It prints each run, or with --dry-run the report of
+// what the run would change. Crawls of stored configs run as jobs of the job queue, so a
+// config that already has a queued or running job is not crawled. Configs read from a file are
+// not stored and are crawled directly.
+func crawlCommand(a *app, args []string) error {
+	fs := a.flagSet()
+	ref := fs.String("config", "", "crawler config ID, or a JSON crawler config file")
+	once := fs.Bool("once", false, "run one crawl and exit instead of crawling on the config's schedule")
+	dryRun := fs.Bool("dry-run", false, "fetch and extract pages without storing anything and print what would change")
+	replay := fs.Bool("replay", false, "serve a dry run's pages from the page cache where it has them")
+	if rest, err := parseFlags(fs, args); err != nil {
+		return err
+	} else if len(rest) > 0 {
+		return fmt.Errorf("unexpected arguments %q", rest)
+	}
+	if *replay && !*dryRun {
+		return errors.New("--replay is only allowed with --dry-run")
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	store, err := a.migratedStore()
+	if err != nil {
+		return err
+	}
+	config, err := a.crawlerConfig(ctx, *ref)
+	if err != nil {
+		return err
+	}
+	blobs, pages, err := a.assets()
+	if err != nil {
+		return err
+	}
+
+	// Changes are stored as webhook deliveries for the serving process to send
+	runner := &crawler.Runner{
+		Store:       store,
+		LogOptions:  a.logOptions,
+		AssetStore:  blobs,
+		AssetHosts:  a.cfg.Assets.Hosts,
+		Notifier:    webhook.NewDispatcher(store, a.cfg.Webhooks.MaxAttempts),
+		Distributed: a.cfg.DistributedOptions(),
+		PageCache:   pages,
+	}
+	opts := &models.RunOptions{DryRun: *dryRun, Replay: *replay}
+	jobs := queue.New(store, runner.Run, a.cfg.Crawler.MaxConcurrentCrawls)
+
+	schedule := config.CrawlInterval
+	if schedule == "" {
+		schedule = a.cfg.Crawler.CrawlInterval
+	}
+	for {
+		runID, runErr := crawlOnce(ctx, jobs, runner, config, opts)
+		if runID != uuid.Nil {
+			if err := printRun(ctx, store, runID, *dryRun); err != nil {
+				log.Printf("Failed to print run %s: %v", runID, err)
+			}
+		}
+		if *once || ctx.Err() != nil {
+			return runErr
+		}
+		if runErr != nil {
+			log.Printf("Crawl of %s failed: %v", config.Product, runErr)
+		}
+
+		next := scheduler.NextRun(schedule, time.Now())
+		if next == nil {
+			return fmt.Errorf("crawl interval %q has no next run; pass --once to crawl a single time", schedule)
+		}
+		log.Printf("Next crawl of %s at %s", config.Product, next.Format(time.RFC3339))
+		select {
+		case <-time.After(time.Until(*next)):
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+// crawlOnce runs one crawl of config and returns its run ID, or uuid.Nil when none was started.
+// Stored configs are crawled as a job of the queue.
+func crawlOnce(ctx context.Context, jobs *queue.Queue, runner *crawler.Runner, config *models.CrawlerConfig,
+	opts *models.RunOptions) (uuid.UUID, error) {
+	if config.ID == uuid.Nil {
+		runID := uuid.New()
+		return runID, runner.Run(ctx, config, runID, opts)
+	}
+
+	job, err := jobs.RunNow(ctx, config, models.JobTriggerCLI, opts)
+	switch {
+	case errors.Is(err, queue.ErrConfigBusy):
+		return uuid.Nil, fmt.Errorf("%w: %s job %s", err, job.Status, job.ID)
+	case job == nil || job.RunID == nil:
+		return uuid.Nil, err
+	}
+	return *job.RunID, err
+}
+
+// printRun prints a finished run, or the report of a dry run.
+func printRun(ctx context.Context, store storage.Store, runID uuid.UUID, dryRun bool) error {
+	ctx = context.WithoutCancel(ctx)
+	if dryRun {
+		report, err := store.GetRunReport(ctx, runID)
+		if err != nil || report == nil {
+			return err
+		}
+		return printJSON(report)
+	}
+
+	run, err := store.GetCrawlRun(ctx, runID)
+	if err != nil || run == nil {
+		return err
+	}
+	return printJSON(run)
+}
+
+// mapCommand maps a crawler config's category structure and prints its category paths. The
+// categories are not stored.
+func mapCommand(a *app, args []string) error {
+	fs := a.flagSet()
+	ref := fs.String("config", "", "crawler config ID, or a JSON crawler config file")
+	if rest, err := parseFlags(fs, args); err != nil {
+		return err
+	} else if len(rest) > 0 {
+		return fmt.Errorf("unexpected arguments %q", rest)
+	}
+
+	ctx := context.Background()
+	config, err := a.crawlerConfig(ctx, *ref)
+	if err != nil {
+		return err
+	}
+
+	crawlerConfig := crawler.ConfigFromModel(*config)
+	if crawlerConfig.MapURL == "" {
+		crawlerConfig.MapURL = crawler.DefaultMapURL(config.SitemapURL)
+	}
+	// A dry run keeps the mapped categories in memory, so no store is needed
+	crawlerConfig.DryRun = true
+	categories, err := crawler.NewCrawler(nil, crawlerConfig).MapCategoryStructure(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, path := range categories.Paths() {
+		fmt.Println(path)
+	}
+	return nil
+}
+
+// exportBatch is how many articles export reads from the store at once.
+const exportBatch = 500
+
+// exportCommand writes the stored articles as JSON lines or CSV.
+func exportCommand(a *app, args []string) error {
+	fs := a.flagSet()
+	configRef := fs.String("config", "", "only export the articles of this crawler config ID")
+	format := fs.String("format", "jsonl", "output format: jsonl or csv")
+	output := fs.String("output", "", "write to this file instead of stdout")
+	includeDeleted := fs.Bool("include-deleted", false, "include tombstoned articles")
+	if rest, err := parseFlags(fs, args); err != nil {
+		return err
+	} else if len(rest) > 0 {
+		return fmt.Errorf("unexpected arguments %q", rest)
+	}
+
+	var configID *uuid.UUID
+	if *configRef != "" {
+		id, err := uuid.Parse(*configRef)
+		if err != nil {
+			return fmt.Errorf("invalid crawler config ID %q", *configRef)
+		}
+		configID = &id
+	}
+	if *format != "jsonl" && *format != "csv" {
+		return fmt.Errorf("unknown format %q; use jsonl or csv", *format)
+	}
+
+	var write func(*models.Article) error
+	var flush func() error
+	var out io.Writer = os.Stdout
+	if *output != "" {
+		f, err := os.Create(*output)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		out = f
+	}
+	buffered := bufio.NewWriter(out)
+	switch *format {
+	case "jsonl", "":
+		enc := json.NewEncoder(buffered)
+		write = func(article *models.Article) error { return enc.Encode(article) }
+		flush = buffered.Flush
+	case "csv":
+		w := csv.NewWriter(buffered)
+		if err := w.Write(exportColumns); err != nil {
+			return err
+		}
+		write = func(article *models.Article) error { return w.Write(exportRecord(article)) }
+		flush = func() error {
+			w.Flush()
+			if err := w.Error(); err != nil {
+				return err
+			}
+			return buffered.Flush()
+		}
+	}
+
+	store, err := a.store()
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+	exported := 0
+	for offset := 0; ; offset += exportBatch {
+		articles, err := store.ListArticles(ctx, exportBatch, offset, *includeDeleted)
+		if err != nil {
+			return fmt.Errorf("failed to list articles: %w", err)
+		}
+		for _, article := range articles {
+			if configID != nil && (article.ConfigID == nil || *article.ConfigID != *configID) {
+				continue
+			}
+			if err := write(article); err != nil {
+				return err
+			}
+			exported++
+		}
+		if len(articles) < exportBatch {
+			break
+		}
+	}
+	if err := flush(); err != nil {
+		return err
+	}
+
+	log.Printf("Exported %d articles", exported)
+	return nil
+}
+
+// exportColumns are the CSV columns of an exported article.
+var exportColumns = []string{
+	"id", "url", "name", "category_id", "config_id", "author", "tags", "language",
+	"content_confidence", "in_sitemap", "created_at", "updated_at", "deleted_at", "body",
+}
+
+func exportRecord(article *models.Article) []string {
+	configID, confidence, deletedAt := "", "", ""
+	if article.ConfigID != nil {
+		configID = article.ConfigID.String()
+	}
+	if article.ContentConfidence != nil {
+		confidence = strconv.FormatFloat(*article.ContentConfidence, 'f', 2, 64)
+	}
+	if article.DeletedAt != nil {
+		deletedAt = article.DeletedAt.Format(time.RFC3339)
+	}
+	return []string{
+		article.ID.String(),
+		article.URL,
+		article.Name,
+		article.CategoryID.String(),
+		configID,
+		article.Author,
+		strings.Join(article.Tags, ";"),
+		article.Language,
+		confidence,
+		strconv.FormatBool(article.InSitemap),
+		article.CreatedAt.Format(time.RFC3339),
+		article.UpdatedAt.Format(time.RFC3339),
+		deletedAt,
+		article.Body,
+	}
+}
+
+// searchCommand runs a full-text search over the stored articles and prints the matches.
+func searchCommand(a *app, args []string) error {
+	fs := a.flagSet()
+	limit := fs.Int("limit", 20, "most articles to print")
+	asJSON := fs.Bool("json", false, "print the articles as JSON")
+	includeDeleted := fs.Bool("include-deleted", false, "include tombstoned articles")
+	rest, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(rest) == 0 {
+		fs.Usage()
+		return errors.New("a search query is required")
+	}
+
+	store, err := a.store()
+	if err != nil {
+		return err
+	}
+	articles, err := store.SearchArticles(context.Background(), strings.Join(rest, " "), *limit, 0, *includeDeleted)
+	if err != nil {
+		return fmt.Errorf("failed to search articles: %w", err)
+	}
+
+	if *asJSON {
+		if articles == nil {
+			articles = []*models.Article{}
+		}
+		return printJSON(articles)
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tNAME\tURL")
+	for _, article := range articles {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", article.ID, article.Name, article.URL)
+	}
+	return w.Flush()
+}
+
+// migrateCommand creates the database tables or upgrades them to the current schema.
+func migrateCommand(a *app, args []string) error {
+	fs := a.flagSet()
+	if rest, err := parseFlags(fs, args); err != nil {
+		return err
+	} else if len(rest) > 0 {
+		return fmt.Errorf("unexpected arguments %q", rest)
+	}
+
+	if _, err := a.migratedStore(); err != nil {
+		return err
+	}
+	fmt.Println("Database tables are up to date")
+	return nil
+}
+
+// validateCommand validates a crawler config file as the API validates configs, printing an
+// error per invalid field.
+func validateCommand(a *app, args []string) error {
+	fs := a.flagSet()
+	checkReachability := fs.Bool("check-reachability", false, "also fetch the sitemap and map URLs")
+	rest, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(rest) != 1 {
+		fs.Usage()
+		return errors.New("exactly one config file is required")
+	}
+
+	config, err := readConfigFile(rest[0])
+	if err != nil {
+		return err
+	}
+	errs := crawler.ValidateConfig(context.Background(), config, crawler.ValidateOptions{
+		CheckReachability: *checkReachability,
+		UserAgent:         config.UserAgent,
+	})
+	if errs == nil {
+		fmt.Printf("%s is valid\n", rest[0])
+		return nil
+	}
+
+	fields := make([]string, 0, len(errs))
+	for field := range errs {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	for _, field := range fields {
+		fmt.Printf("%s: %s\n", field, errs[field])
+	}
+	return fmt.Errorf("%s is not a valid crawler config", rest[0])
+}
+
+// crawlerConfig loads the crawler config named by --config: the ID of a stored config, or a
+// JSON file holding a config in the form the API takes. A config read from a file is
+// validated and is crawled on its own, not as a stored config: the config is not updated, and
+// its articles are neither tied to it nor tombstoned.
+func (a *app) crawlerConfig(ctx context.Context, ref string) (*models.CrawlerConfig, error) {
+	if ref == "" {
+		return nil, errors.New("--config is required")
+	}
+
+	if id, err := uuid.Parse(ref); err == nil {
+		store, err := a.store()
+		if err != nil {
+			return nil, err
+		}
+		config, err := store.GetCrawlerConfig(ctx, id)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch crawler config: %w", err)
+		}
+		if config == nil {
+			return nil, fmt.Errorf("crawler config %s not found", id)
+		}
+		return config, nil
+	}
+
+	config, err := readConfigFile(ref)
+	if err != nil {
+		return nil, err
+	}
+	if errs := crawler.ValidateConfig(ctx, config, crawler.ValidateOptions{}); errs != nil {
+		return nil, fmt.Errorf("invalid crawler config %s: %w", ref, errs)
+	}
+	config.ID = uuid.Nil
+	return config, nil
+}
+
+// readConfigFile reads a crawler config from a JSON file. Unknown fields are rejected, so a
+// misspelled setting is not silently ignored.
+func readConfigFile(path string) (*models.CrawlerConfig, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	dec := json.NewDecoder(f)
+	dec.DisallowUnknownFields()
+	var config models.CrawlerConfig
+	if err := dec.Decode(&config); err != nil {
+		return nil, fmt.Errorf("failed to read crawler config %s: %w", path, err)
+	}
+	return &config, nil
+}
+
+// runsCommand lists crawl runs or shows one.
+func runsCommand(a *app, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: kb-crawler %s", a.cmd.usage)
+	}
+	switch args[0] {
+	case "list":
+		return runsList(a, args[1:])
+	case "show":
+		return runsShow(a, args[1:])
+	default:
+		return fmt.Errorf("unknown runs command %q; usage: kb-crawler %s", args[0], a.cmd.usage)
+	}
+}
+
+// runsList prints the latest crawl runs, newest first.
+func runsList(a *app, args []string) error {
+	fs := a.flagSet()
+	configRef := fs.String("config", "", "only list the runs of this crawler config ID")
+	limit := fs.Int("limit", 20, "most runs to list")
+	if rest, err := parseFlags(fs, args); err != nil {
+		return err
+	} else if len(rest) > 0 {
+		return fmt.Errorf("unexpected arguments %q", rest)
+	}
+
+	var configID *uuid.UUID
+	if *configRef != "" {
+		id, err := uuid.Parse(*configRef)
+		if err != nil {
+			return fmt.Errorf("invalid crawler config ID %q", *configRef)
+		}
+		configID = &id
+	}
+
+	store, err := a.store()
+	if err != nil {
+		return err
+	}
+	runs, err := store.ListCrawlRuns(context.Background(), configID, *limit, 0)
+	if err != nil {
+		return fmt.Errorf("failed to list runs: %w", err)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tPRODUCT\tSTATUS\tSTARTED\tDURATION\tOPTIONS")
+	for _, run := range runs {
+		duration := ""
+		if run.FinishedAt != nil {
+			duration = run.FinishedAt.Sub(run.StartedAt).Round(time.Second).String()
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", run.ID, run.Product, run.Status,
+			run.StartedAt.Format("2006-01-02 15:04:05"), duration, describeRunOptions(run.Options))
+	}
+	return w.Flush()
+}
+
+// describeRunOptions summarizes the overrides of a run, such as "dry run, incremental".
+func describeRunOptions(opts *models.RunOptions) string {
+	if opts == nil {
+		return ""
+	}
+	var parts []string
+	if opts.DryRun {
+		parts = append(parts, "dry run")
+	}
+	if opts.Replay {
+		parts = append(parts, "replay")
+	}
+	if opts.Mode != "" && opts.Mode != models.RunModeFull {
+		parts = append(parts, opts.Mode)
+	}
+	if len(opts.URLs) > 0 {
+		parts = append(parts, fmt.Sprintf("%d urls", len(opts.URLs)))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// runsShow prints a run as JSON, or its log or dry run report.
+func runsShow(a *app, args []string) error {
+	fs := a.flagSet()
+	logs := fs.Bool("logs", false, "print the run's log instead")
+	report := fs.Bool("report", false, "print the dry run's report instead")
+	asHTML := fs.Bool("html", false, "print the report as an HTML page")
+	rest, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(rest) != 1 {
+		fs.Usage()
+		return errors.New("exactly one run ID is required")
+	}
+	id, err := uuid.Parse(rest[0])
+	if err != nil {
+		return fmt.Errorf("invalid run ID %q", rest[0])
+	}
+
+	store, err := a.store()
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+	run, err := store.GetCrawlRun(ctx, id)
+	if err != nil {
+		return fmt.Errorf("failed to fetch run: %w", err)
+	}
+	if run == nil {
+		return fmt.Errorf("run %s not found", id)
+	}
+
+	switch {
+	case *logs:
+		if run.LogPath == "" {
+			return errors.New("run has no log")
+		}
+		lines, err := utils.ReadLog(run.LogPath, slog.LevelDebug, 0)
+		if err != nil {
+			return fmt.Errorf("failed to read run log: %w", err)
+		}
+		for _, line := range lines {
+			fmt.Println(line)
+		}
+		return nil
+
+	case *report:
+		r, err := store.GetRunReport(ctx, id)
+		if err != nil {
+			return fmt.Errorf("failed to fetch dry run report: %w", err)
+		}
+		if r == nil {
+			return errors.New("run has no dry run report")
+		}
+		if *asHTML {
+			return crawler.WriteReportHTML(os.Stdout, r)
+		}
+		return printJSON(r)
+
+	default:
+		return printJSON(run)
+	}
+}
+
+// printJSON prints v to stdout as indented JSON.
+func printJSON(v interface{}) error {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
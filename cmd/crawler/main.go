@@ -2,53 +2,309 @@ package main
 
 import (
 	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
 	"log"
+	"log/slog"
 	"os"
 	"os/signal"
-	"sync"
+	"strings"
 	"syscall"
 	"time"
 
 	"github.com/romangod6/kb-crawler/config"
 	"github.com/romangod6/kb-crawler/internal/api"
+	"github.com/romangod6/kb-crawler/internal/blobstore"
 	"github.com/romangod6/kb-crawler/internal/crawler"
+	"github.com/romangod6/kb-crawler/internal/events"
+	"github.com/romangod6/kb-crawler/internal/leader"
 	"github.com/romangod6/kb-crawler/internal/models"
+	"github.com/romangod6/kb-crawler/internal/queue"
+	"github.com/romangod6/kb-crawler/internal/scheduler"
 	"github.com/romangod6/kb-crawler/internal/storage"
+	"github.com/romangod6/kb-crawler/internal/tracing"
+	"github.com/romangod6/kb-crawler/internal/urlnorm"
+	"github.com/romangod6/kb-crawler/internal/utils"
+	"github.com/romangod6/kb-crawler/internal/webhook"
+	"github.com/romangod6/kb-crawler/internal/worker"
 )
 
 func main() {
+	os.Exit(run(os.Args[1:]))
+}
+
+// command is a kb-crawler subcommand. Daemons log to stdout; every other command keeps stdout
+// for its output and logs to stderr.
+type command struct {
+	name    string
+	usage   string
+	summary string
+	daemon  bool
+	run     func(a *app, args []string) error
+}
+
+var commands = []*command{
+	{name: "serve", usage: "serve", summary: "Serve the API and run the scheduler and job queue (the default)", daemon: true, run: serve},
+	{name: "worker", usage: "worker", summary: "Fetch the pages of distributed crawls", daemon: true, run: runWorker},
+	{name: "crawl", usage: "crawl --config <id|file> [--once] [--dry-run [--replay]]", summary: "Crawl a crawler config on its schedule, or once", run: crawlCommand},
+	{name: "map", usage: "map --config <id|file>", summary: "Print the category paths a crawler config maps, without storing them", run: mapCommand},
+	{name: "export", usage: "export [--config <id>] [--format jsonl|csv] [--output <file>] [--include-deleted]", summary: "Export stored articles", run: exportCommand},
+	{name: "search", usage: "search [--limit <n>] [--json] [--include-deleted] <query>", summary: "Search stored articles", run: searchCommand},
+	{name: "migrate", usage: "migrate", summary: "Create or upgrade the database tables", run: migrateCommand},
+	{name: "validate", usage: "validate [--check-reachability] <config-file>", summary: "Validate a crawler config file", run: validateCommand},
+	{name: "runs", usage: "runs list [--config <id>] [--limit <n>] | runs show [--logs] [--report [--html]] <run-id>", summary: "List crawl runs or show one", run: runsCommand},
+}
+
+// run runs the subcommand named by the first argument, serve when there is none, and returns
+// the process exit code.
+func run(args []string) int {
+	name := "serve"
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		name, args = args[0], args[1:]
+	}
+	if name == "help" || (len(args) > 0 && name == "serve" && (args[0] == "-h" || args[0] == "--help")) {
+		printUsage(os.Stdout)
+		return 0
+	}
+
+	var cmd *command
+	for _, c := range commands {
+		if c.name == name {
+			cmd = c
+		}
+	}
+	if cmd == nil {
+		fmt.Fprintf(os.Stderr, "kb-crawler: unknown command %q\n\n", name)
+		printUsage(os.Stderr)
+		return 2
+	}
+
 	// Load configuration
 	cfg, err := config.LoadConfig()
 	if err != nil {
-		log.Fatalf("Failed to load config: %v", err)
+		log.Printf("Failed to load config: %v", err)
+		return 1
 	}
 
-	// Initialize storage
-	store, err := storage.NewPostgresStore(cfg.Database.URL)
+	// Log through slog in the configured format and level; crawl runs add their own log files
+	logOptions := cfg.LogOptions()
+	logOutput := os.Stderr
+	if cmd.daemon {
+		logOutput = os.Stdout
+	}
+	slog.SetDefault(slog.New(utils.NewHandler(logOutput, logOptions)))
+
+	// Export spans for crawl runs, page fetches, parsing, storage calls and API requests
+	shutdownTracing, err := tracing.Setup(cfg.TracingOptions())
 	if err != nil {
-		log.Fatalf("Failed to initialize storage: %v", err)
+		log.Printf("Failed to set up tracing: %v", err)
+		return 1
+	}
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := shutdownTracing(ctx); err != nil {
+			log.Printf("Failed to flush traces: %v", err)
+		}
+	}()
+
+	// Apply the URL normalization rules used for queueing and storage keys
+	urlnorm.Configure(cfg.URLRules())
+
+	// Register extraction profiles defined in the config file
+	for name, profile := range cfg.Profiles {
+		crawler.RegisterProfile(&crawler.Profile{
+			Name:             name,
+			ContentSelectors: profile.ContentSelectors,
+			StripSelectors:   profile.StripSelectors,
+		})
 	}
-	defer store.Close()
 
-	// Initialize database tables
+	a := &app{cmd: cmd, cfg: cfg, logOptions: logOptions}
+	defer a.close()
+
+	if err := cmd.run(a, args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		fmt.Fprintf(os.Stderr, "kb-crawler %s: %v\n", cmd.name, err)
+		return 1
+	}
+	return 0
+}
+
+func printUsage(w io.Writer) {
+	fmt.Fprintln(w, "Usage: kb-crawler <command> [flags] [arguments]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Commands:")
+	for _, c := range commands {
+		fmt.Fprintf(w, "  %-9s %s\n", c.name, c.summary)
+		fmt.Fprintf(w, "  %-9s   kb-crawler %s\n", "", c.usage)
+	}
+}
+
+// app holds what the commands share. The database, asset store and page cache are opened on
+// first use, so commands that don't need them run without them.
+type app struct {
+	cmd        *command
+	cfg        *config.Config
+	logOptions utils.LogOptions
+	db         storage.Store
+}
+
+// store opens the database. It does not create or upgrade the tables; migrate does.
+func (a *app) store() (storage.Store, error) {
+	if a.db != nil {
+		return a.db, nil
+	}
+	db, err := storage.NewPostgresStore(a.cfg.Database.URL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize storage: %w", err)
+	}
+	a.db = storage.NewTracedStore(db)
+	return a.db, nil
+}
+
+// migratedStore opens the database and creates or upgrades its tables, as commands that write
+// to it do on start.
+func (a *app) migratedStore() (storage.Store, error) {
+	store, err := a.store()
+	if err != nil {
+		return nil, err
+	}
 	if err := store.Initialize(); err != nil {
-		log.Fatalf("Failed to initialize database tables: %v", err)
+		return nil, fmt.Errorf("failed to initialize database tables: %w", err)
 	}
+	return store, nil
+}
+
+// assets opens the blob store for downloaded assets and, when a directory is configured, the
+// page cache that keeps fetched pages for dry runs to replay.
+func (a *app) assets() (*blobstore.Store, *crawler.PageCache, error) {
+	blobs, err := blobstore.New(a.cfg.Assets.Dir, a.cfg.Assets.MaxSize)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to initialize asset store: %w", err)
+	}
+
+	var pages *crawler.PageCache
+	if a.cfg.Crawler.PageCacheDir != "" {
+		pages, err = crawler.NewPageCache(a.cfg.Crawler.PageCacheDir)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to initialize page cache: %w", err)
+		}
+	}
+	return blobs, pages, nil
+}
+
+func (a *app) close() {
+	if a.db != nil {
+		a.db.Close()
+	}
+}
+
+// flagSet returns the flag set of the running command, which prints the command's usage on
+// errors.
+func (a *app) flagSet() *flag.FlagSet {
+	fs := flag.NewFlagSet(a.cmd.name, flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: kb-crawler %s\n", a.cmd.usage)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// parseFlags parses a command's flags, which may come before, between or after its
+// arguments, and returns the arguments.
+func parseFlags(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			return positional, nil
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+// serve runs the API server, the job queue, the webhook dispatcher and, while this instance
+// leads, the scheduler until the process is signalled.
+func serve(a *app, args []string) error {
+	fs := a.flagSet()
+	if rest, err := parseFlags(fs, args); err != nil {
+		return err
+	} else if len(rest) > 0 {
+		return fmt.Errorf("unexpected arguments %q", rest)
+	}
+	cfg := a.cfg
+
+	// Initialize storage and database tables
+	store, err := a.migratedStore()
+	if err != nil {
+		return err
+	}
+
+	// Initialize the blob store for downloaded assets and the page cache
+	blobs, pages, err := a.assets()
+	if err != nil {
+		return err
+	}
+
+	// Deliver article change notifications to registered webhooks
+	dispatcher := webhook.NewDispatcher(store, cfg.Webhooks.MaxAttempts)
+
+	// Live crawl progress, streamed to API clients
+	bus := events.NewBus()
+
+	// Every crawl goes through the job queue, which runs at most maxConcurrentCrawls at once
+	runner := &crawler.Runner{
+		Store:      store,
+		LogOptions: a.logOptions,
+		AssetStore: blobs,
+		AssetHosts: cfg.Assets.Hosts,
+		Notifier:   dispatcher,
+		Events:     bus,
+		// Queue each crawl's pages for worker processes when workers are enabled
+		Distributed: cfg.DistributedOptions(),
+		PageCache:   pages,
+	}
+	jobs := queue.New(store, runner.Run, cfg.Crawler.MaxConcurrentCrawls)
+
+	// Queue each crawler config on its own schedule
+	sched := scheduler.New(store, func(ctx context.Context, config *models.CrawlerConfig) error {
+		_, _, err := jobs.Submit(ctx, config, models.JobTriggerSchedule, models.JobPriorityScheduled, nil)
+		return err
+	}, cfg.SchedulerOptions())
+
+	// Replicas sharing the database elect one leader to run the scheduler; another takes over
+	// when it dies
+	elector := leader.New(store, "scheduler", utils.InstanceID())
 
 	// Initialize API server
-	server := api.NewServer(cfg.Server.Port, store)
+	server := api.NewServer(cfg.Server.Port, store, blobs, bus, sched, jobs, elector)
 
-	// Setup periodic crawling
+	// Setup periodic maintenance
 	ticker := time.NewTicker(cfg.GetCrawlDuration())
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
+	go dispatcher.Run(ctx)
+	go jobs.Run(ctx)
+	go elector.Run(ctx, sched.Run)
+
+	pruneLogs(a.logOptions)
+
 	go func() {
 		for {
 			select {
 			case <-ticker.C:
-				log.Println("Starting periodic crawl...")
-				runAllCrawls(ctx, store, cfg.Crawler.MaxConcurrentCrawls)
+				purgeDeletedArticles(ctx, store, cfg.Articles.PurgeDeletedAfterDays)
+				pruneLogs(a.logOptions)
 			case <-ctx.Done():
 				return
 			}
@@ -65,78 +321,66 @@ func main() {
 
 	// Wait for shutdown
 	waitForShutdown(cancel, server)
+	return nil
 }
 
-func runAllCrawls(ctx context.Context, store storage.Store, maxConcurrentCrawls int) {
-	// Fetch all crawler configs
-	crawlerConfigs, err := store.ListCrawlerConfigs(ctx)
-	if err != nil {
-		log.Printf("Failed to fetch crawler configs: %v", err)
-		return
+// runWorker claims and fetches pages of distributed crawls until the process is signalled.
+// Notifications of the worker's article changes are stored for the serving process to deliver.
+func runWorker(a *app, args []string) error {
+	fs := a.flagSet()
+	if rest, err := parseFlags(fs, args); err != nil {
+		return err
+	} else if len(rest) > 0 {
+		return fmt.Errorf("unexpected arguments %q", rest)
 	}
 
-	if len(crawlerConfigs) == 0 {
-		log.Println("No crawlers to run")
-		return
+	store, err := a.migratedStore()
+	if err != nil {
+		return err
 	}
+	blobs, pages, err := a.assets()
+	if err != nil {
+		return err
+	}
+	dispatcher := webhook.NewDispatcher(store, a.cfg.Webhooks.MaxAttempts)
 
-	now := time.Now()
-
-	// Create a semaphore channel to limit concurrency
-	semaphore := make(chan struct{}, maxConcurrentCrawls)
-	wg := sync.WaitGroup{}
-
-	for _, config := range crawlerConfigs {
-		// Skip if crawler is already running
-		if config.Status == "Running" {
-			log.Printf("Skipping crawler %s (%s) as it's already running", config.Product, config.ID)
-			continue
-		}
-
-		// Skip if it's not time to run yet
-		if config.NextRun != nil && now.Before(*config.NextRun) {
-			log.Printf("Skipping crawler %s (%s) as it's not scheduled yet", config.Product, config.ID)
-			continue
-		}
-
-		configCopy := *config // Dereference the pointer to create a value copy
-		wg.Add(1)
-
-		// Acquire a spot in the semaphore
-		semaphore <- struct{}{}
-
-		go func(cfg models.CrawlerConfig) {
-			defer wg.Done()
-			defer func() { <-semaphore }() // Release the spot in the semaphore
-
-			log.Printf("Starting crawl for %s...", cfg.SitemapURL)
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
-			// Create and run the crawler
-			c := crawler.NewCrawler(store, &crawler.CrawlerConfig{
-				SitemapURL:      cfg.SitemapURL,
-				UserAgent:       cfg.UserAgent,
-				MaxDepth:        cfg.MaxDepth,
-				AllowedDomains:  cfg.AllowedDomains,
-				DefaultCategory: cfg.DefaultCategory,
-			})
+	opts := a.cfg.WorkerOptions()
+	opts.PageCache = pages
+	worker.New(store, blobs, dispatcher, opts).Run(ctx)
+	return nil
+}
 
-			cs, err := c.MapCategoryStructure(ctx)
-			if err != nil {
-				log.Printf("Failed to map category structure for %s: %v", cfg.SitemapURL, err)
-				return
-			}
+// purgeDeletedArticles removes articles that have been tombstoned for longer than the
+// configured number of days. Zero days disables purging.
+func purgeDeletedArticles(ctx context.Context, store storage.Store, days int) {
+	if days <= 0 {
+		return
+	}
 
-			if err := c.Crawl(ctx, cs); err != nil {
-				log.Printf("Crawl failed for %s: %v", cfg.SitemapURL, err)
-			} else {
-				log.Printf("Crawl completed for %s", cfg.SitemapURL)
-			}
-		}(configCopy)
+	purged, err := store.PurgeDeletedArticles(ctx, time.Now().AddDate(0, 0, -days))
+	if err != nil {
+		log.Printf("Failed to purge deleted articles: %v", err)
+		return
 	}
+	if purged > 0 {
+		log.Printf("Purged %d articles deleted more than %d days ago", purged, days)
+	}
+}
 
-	wg.Wait() // Wait for all workers to finish
-	log.Println("All crawls completed")
+// pruneLogs applies the log retention limits to the crawl logs directory.
+func pruneLogs(opts utils.LogOptions) {
+	removed, err := utils.PruneLogs(opts.Dir, opts.MaxAge, opts.MaxTotalSize)
+	if err != nil {
+		log.Printf("Failed to prune logs: %v", err)
+	}
+	if removed > 0 {
+		log.Printf("Pruned %d log files", removed)
+	}
 }
+
 func waitForShutdown(cancel context.CancelFunc, server *api.Server) {
 	// Handle system signals for shutdown
 	sigChan := make(chan os.Signal, 1)
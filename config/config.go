@@ -3,6 +3,12 @@ package config
 import (
 	"time"
 
+	"github.com/romangod6/kb-crawler/internal/crawler"
+	"github.com/romangod6/kb-crawler/internal/scheduler"
+	"github.com/romangod6/kb-crawler/internal/tracing"
+	"github.com/romangod6/kb-crawler/internal/urlnorm"
+	"github.com/romangod6/kb-crawler/internal/utils"
+	"github.com/romangod6/kb-crawler/internal/worker"
 	"github.com/spf13/viper"
 )
 
@@ -21,7 +27,71 @@ type Config struct {
 		MaxDepth            int
 		DefaultCategory     string
 		AllowedDomains      []string
-		MaxConcurrentCrawls int // Add this
+		MaxConcurrentCrawls int    // Add this
+		PageCacheDir        string // keeps fetched pages for dry runs to replay; empty disables
+	}
+	// Scheduler controls when crawler configs run on their own schedules
+	Scheduler struct {
+		Tick            string // how often schedules are checked
+		Jitter          string // largest random delay added to a due time
+		MissedRunPolicy string // "once" or "skip" for runs missed during downtime
+		MissedRunGrace  string // how late a run may start before it counts as missed
+	}
+	// Workers configures distributed crawls, whose pages are fetched by worker processes
+	Workers struct {
+		Enabled      bool   // queue the pages of every crawl for workers instead of fetching them
+		BatchSize    int    // pages a worker claims at once
+		Lease        string // how long a claimed page stays with a worker without a heartbeat
+		MaxAttempts  int    // claims of a page before it counts as failed
+		PollInterval string // how often idle workers and coordinators check the database
+		IdleTimeout  string // how long a crawl waits for any worker activity before failing; "0" waits forever
+	}
+	// Assets configures where downloaded article assets are stored
+	Assets struct {
+		Dir     string
+		MaxSize int64    // bytes, zero for no limit
+		Hosts   []string // hosts assets may be downloaded from besides each config's allowed domains
+	}
+	// Articles controls how tombstoned articles are kept
+	Articles struct {
+		PurgeDeletedAfterDays int // zero keeps tombstones forever
+	}
+	// Logging configures crawl run logs
+	Logging struct {
+		Dir    string // run logs are written to <Dir>/<product>/
+		Format string // "text" or "json"
+		Level  string // "debug", "info", "warn" or "error"
+		// MaxSizeMB rotates a run's log file at this size; MaxAgeDays and MaxTotalSizeMB bound
+		// how much is kept. Zero disables each limit
+		MaxSizeMB      int
+		MaxAgeDays     int
+		MaxTotalSizeMB int
+	}
+	// Tracing configures where spans are exported
+	Tracing struct {
+		Exporter    string            // "none", "stdout", "file" or "otlp"
+		Endpoint    string            // OTLP/HTTP collector base URL
+		Headers     map[string]string // sent with OTLP requests
+		File        string            // JSON output for the file exporter
+		ServiceName string
+		SampleRatio float64 // share of new traces recorded, from 0 to 1
+	}
+	// Webhooks controls delivery of change notifications
+	Webhooks struct {
+		MaxAttempts int // attempts before a delivery is dead-lettered
+	}
+	// URLNormalization controls how URLs are normalized before crawling and storage
+	URLNormalization struct {
+		DropFragments bool
+		LowercaseHost bool
+		StripParams   []string
+		SortParams    bool
+		UseCanonical  bool
+	}
+	// Profiles adds or overrides extraction profiles, keyed by profile name
+	Profiles map[string]struct {
+		ContentSelectors []string
+		StripSelectors   []string
 	}
 }
 
@@ -38,6 +108,36 @@ func LoadConfig() (*Config, error) {
 	viper.SetDefault("crawler.maxdepth", 10)
 	viper.SetDefault("crawler.crawlinterval", "24h")
 	viper.SetDefault("crawler.defaultcategory", "Datto RMM")
+	viper.SetDefault("scheduler.tick", "30s")
+	viper.SetDefault("scheduler.jitter", "2m")
+	viper.SetDefault("scheduler.missedrunpolicy", "once")
+	viper.SetDefault("scheduler.missedrungrace", "10m")
+	viper.SetDefault("workers.enabled", false)
+	viper.SetDefault("workers.batchsize", 10)
+	viper.SetDefault("workers.lease", "2m")
+	viper.SetDefault("workers.maxattempts", 3)
+	viper.SetDefault("workers.pollinterval", "5s")
+	viper.SetDefault("workers.idletimeout", "10m")
+	viper.SetDefault("assets.dir", "assets")
+	viper.SetDefault("assets.maxsize", 100<<20)
+	viper.SetDefault("articles.purgedeletedafterdays", 0)
+	viper.SetDefault("webhooks.maxattempts", 8)
+	viper.SetDefault("tracing.exporter", "none")
+	viper.SetDefault("tracing.endpoint", "http://localhost:4318")
+	viper.SetDefault("tracing.file", "traces.jsonl")
+	viper.SetDefault("tracing.servicename", "kb-crawler")
+	viper.SetDefault("tracing.sampleratio", 1.0)
+	viper.SetDefault("logging.dir", "logs")
+	viper.SetDefault("logging.format", "text")
+	viper.SetDefault("logging.level", "info")
+	viper.SetDefault("logging.maxsizemb", 50)
+	viper.SetDefault("logging.maxagedays", 30)
+	viper.SetDefault("logging.maxtotalsizemb", 1024)
+	viper.SetDefault("urlnormalization.dropfragments", urlnorm.DefaultRules.DropFragments)
+	viper.SetDefault("urlnormalization.lowercasehost", urlnorm.DefaultRules.LowercaseHost)
+	viper.SetDefault("urlnormalization.stripparams", urlnorm.DefaultRules.StripParams)
+	viper.SetDefault("urlnormalization.sortparams", urlnorm.DefaultRules.SortParams)
+	viper.SetDefault("urlnormalization.usecanonical", urlnorm.DefaultRules.UseCanonical)
 
 	if err := viper.ReadInConfig(); err != nil {
 		return nil, err
@@ -58,3 +158,85 @@ func (c *Config) GetCrawlDuration() time.Duration {
 	}
 	return duration
 }
+
+// SchedulerOptions returns the configured scheduler options. Configs without a crawlInterval
+// of their own use crawler.crawlInterval.
+func (c *Config) SchedulerOptions() scheduler.Options {
+	return scheduler.Options{
+		Tick:            parseDuration(c.Scheduler.Tick, 30*time.Second),
+		Jitter:          parseDuration(c.Scheduler.Jitter, 0),
+		MissedRunPolicy: c.Scheduler.MissedRunPolicy,
+		MissedRunGrace:  parseDuration(c.Scheduler.MissedRunGrace, 10*time.Minute),
+		DefaultSchedule: c.Crawler.CrawlInterval,
+	}
+}
+
+// DistributedOptions returns the options of distributed crawls, or nil when workers are disabled
+// and crawls fetch their own pages.
+func (c *Config) DistributedOptions() *crawler.DistributedOptions {
+	if !c.Workers.Enabled {
+		return nil
+	}
+	return &crawler.DistributedOptions{
+		PollInterval: parseDuration(c.Workers.PollInterval, 5*time.Second),
+		MaxAttempts:  c.Workers.MaxAttempts,
+		IdleTimeout:  parseDuration(c.Workers.IdleTimeout, 10*time.Minute),
+	}
+}
+
+// WorkerOptions returns the options of a worker process.
+func (c *Config) WorkerOptions() worker.Options {
+	return worker.Options{
+		ID:           utils.InstanceID(),
+		BatchSize:    c.Workers.BatchSize,
+		Lease:        parseDuration(c.Workers.Lease, 2*time.Minute),
+		MaxAttempts:  c.Workers.MaxAttempts,
+		PollInterval: parseDuration(c.Workers.PollInterval, 5*time.Second),
+		AssetHosts:   c.Assets.Hosts,
+	}
+}
+
+// parseDuration parses a duration setting, falling back to def when it is empty or invalid.
+func parseDuration(value string, def time.Duration) time.Duration {
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return def
+	}
+	return d
+}
+
+// URLRules returns the configured URL normalization rules.
+func (c *Config) URLRules() urlnorm.Rules {
+	return urlnorm.Rules{
+		DropFragments: c.URLNormalization.DropFragments,
+		LowercaseHost: c.URLNormalization.LowercaseHost,
+		StripParams:   c.URLNormalization.StripParams,
+		SortParams:    c.URLNormalization.SortParams,
+		UseCanonical:  c.URLNormalization.UseCanonical,
+	}
+}
+
+// TracingOptions returns the configured trace exporter options.
+func (c *Config) TracingOptions() tracing.Options {
+	return tracing.Options{
+		Exporter:    c.Tracing.Exporter,
+		Endpoint:    c.Tracing.Endpoint,
+		Headers:     c.Tracing.Headers,
+		File:        c.Tracing.File,
+		ServiceName: c.Tracing.ServiceName,
+		SampleRatio: c.Tracing.SampleRatio,
+	}
+}
+
+// LogOptions returns the configured logging options.
+func (c *Config) LogOptions() utils.LogOptions {
+	return utils.LogOptions{
+		Dir:    c.Logging.Dir,
+		Format: c.Logging.Format,
+		Level:  utils.ParseLevel(c.Logging.Level),
+
+		MaxSize:      int64(c.Logging.MaxSizeMB) << 20,
+		MaxAge:       time.Duration(c.Logging.MaxAgeDays) * 24 * time.Hour,
+		MaxTotalSize: int64(c.Logging.MaxTotalSizeMB) << 20,
+	}
+}
@@ -2,9 +2,22 @@
 
 import React, { useState, useEffect } from 'react';
 import { Card, CardContent, CardHeader, CardTitle } from '@/components/ui/card';
-import { Download, Plus, X, Pencil } from 'lucide-react';
+import { Download, Plus, X, Pencil, ArrowUp, ArrowDown, Trash2, Play, Power, FlaskConical } from 'lucide-react';
 import Papa from 'papaparse';
 
+interface URLFilter {
+    action: 'include' | 'exclude';
+    type: 'glob' | 'regex';
+    pattern: string;
+}
+
+interface RunSummary {
+    sitemapUrls: number;
+    queuedUrls: number;
+    filteredUrls: number;
+    filtered?: { rule: string; count: number }[];
+}
+
 interface CrawlerEntry {
     id: number;
     product: string;
@@ -15,7 +28,16 @@ interface CrawlerEntry {
     maxDepth?: number;
     defaultCategory?: string;
     allowedDomains?: string[];
-    status: 'Running' | 'Stopped' | 'Error';
+    profile?: string;
+    downloadAssets?: boolean;
+    followLinks?: boolean;
+    includePatterns?: string[];
+    excludePatterns?: string[];
+    urlFilters?: URLFilter[];
+    duplicatePolicy?: '' | 'link';
+    lastRunSummary?: RunSummary;
+    status: 'Draft' | 'Stopped' | 'Scheduled' | 'Queued' | 'Running' | 'Error';
+    enabled?: boolean;
     dateAdded: string;
     dateModified: string;
     lastRunTime: string | null;
@@ -31,7 +53,14 @@ const initialFormData: Partial<CrawlerEntry> = {
     maxDepth: 15, // Default Max Depth
     defaultCategory: '',
     allowedDomains: [],
-    status: 'Stopped',
+    profile: '',
+    downloadAssets: false,
+    followLinks: false,
+    includePatterns: [],
+    excludePatterns: [],
+    urlFilters: [],
+    duplicatePolicy: '',
+    status: 'Draft',
     dateAdded: '',
     dateModified: '',
     lastRunTime: null,
@@ -43,6 +72,9 @@ const CrawlerDashboard: React.FC = () => {
     const [showForm, setShowForm] = useState(false);
     const [formData, setFormData] = useState<Partial<CrawlerEntry>>(initialFormData);
     const [editingId, setEditingId] = useState<number | null>(null);
+    const [fieldErrors, setFieldErrors] = useState<Record<string, string>>({});
+    // The last dry run started for each crawler, whose report can be downloaded once it finishes
+    const [dryRuns, setDryRuns] = useState<Record<number, string>>({});
 
     useEffect(() => {
         fetchEntries();
@@ -83,9 +115,10 @@ const CrawlerDashboard: React.FC = () => {
                 allowedDomains: Array.isArray(formData.allowedDomains)
                     ? formData.allowedDomains
                     : formData.allowedDomains?.split(',').map((domain: string) => domain.trim()) || [],
+                includePatterns: formData.includePatterns?.map((p) => p.trim()).filter(Boolean) || [],
+                excludePatterns: formData.excludePatterns?.map((p) => p.trim()).filter(Boolean) || [],
                 dateModified: new Date().toISOString(),
                 dateAdded: formData.dateAdded || new Date().toISOString(),
-                status: 'Running',
             };
 
             let response;
@@ -99,7 +132,7 @@ const CrawlerDashboard: React.FC = () => {
                     body: JSON.stringify(payload),
                 });
             } else {
-                // Create new entry and start crawling
+                // Create new entry; it stays a draft until it is enabled
                 response = await fetch('http://localhost:8080/api/crawlers', {
                     method: 'POST',
                     headers: {
@@ -107,41 +140,126 @@ const CrawlerDashboard: React.FC = () => {
                     },
                     body: JSON.stringify(payload),
                 });
+            }
 
-                const createdConfig = await response.json();
-
-                // Start the crawler
-                await fetch('http://localhost:8080/api/crawlers/start', {
-                    method: 'POST',
-                    headers: {
-                        'Content-Type': 'application/json',
-                    },
-                    body: JSON.stringify(createdConfig),
-                });
+            if (response.status === 400) {
+                const data = await response.json();
+                setFieldErrors(data.fields || {});
+                return;
             }
 
             fetchEntries();
             setFormData(initialFormData);
             setEditingId(null);
+            setFieldErrors({});
             setShowForm(false);
         } catch (error) {
             console.error('Error saving entry:', error);
         }
     };
+    const updateFilter = (index: number, changes: Partial<URLFilter>) => {
+        setFormData((prev) => ({
+            ...prev,
+            urlFilters: (prev.urlFilters || []).map((filter, i) =>
+                i === index ? { ...filter, ...changes } : filter
+            ),
+        }));
+    };
+
+    const moveFilter = (index: number, offset: number) => {
+        setFormData((prev) => {
+            const filters = [...(prev.urlFilters || [])];
+            const target = index + offset;
+            if (target < 0 || target >= filters.length) return prev;
+            [filters[index], filters[target]] = [filters[target], filters[index]];
+            return { ...prev, urlFilters: filters };
+        });
+    };
+
+    const removeFilter = (index: number) => {
+        setFormData((prev) => ({
+            ...prev,
+            urlFilters: (prev.urlFilters || []).filter((_, i) => i !== index),
+        }));
+    };
+
+    const addFilter = () => {
+        setFormData((prev) => ({
+            ...prev,
+            urlFilters: [...(prev.urlFilters || []), { action: 'exclude', type: 'glob', pattern: '' }],
+        }));
+    };
+
     const handleEdit = (entry: CrawlerEntry) => {
         setFormData(entry);
         setEditingId(entry.id);
+        setFieldErrors({});
         setShowForm(true);
     };
 
+    const handleRun = async (entry: CrawlerEntry) => {
+        try {
+            const response = await fetch(`http://localhost:8080/api/crawlers/${entry.id}/run`, {
+                method: 'POST',
+            });
+            if (response.status === 409) {
+                alert('A run of this crawler is already queued or running');
+            }
+            fetchEntries();
+        } catch (error) {
+            console.error('Error starting run:', error);
+        }
+    };
+
+    const handleDryRun = async (entry: CrawlerEntry) => {
+        try {
+            const response = await fetch(`http://localhost:8080/api/crawlers/${entry.id}/run`, {
+                method: 'POST',
+                headers: {
+                    'Content-Type': 'application/json',
+                },
+                body: JSON.stringify({ dryRun: true, replay: true }),
+            });
+            if (response.status === 409) {
+                alert('A run of this crawler is already queued or running');
+                return;
+            }
+            const data = await response.json();
+            setDryRuns((prev) => ({ ...prev, [entry.id]: data.runId }));
+        } catch (error) {
+            console.error('Error starting dry run:', error);
+        }
+    };
+
+    const handleToggleEnabled = async (entry: CrawlerEntry) => {
+        const action = entry.enabled ? 'disable' : 'enable';
+        try {
+            const response = await fetch(`http://localhost:8080/api/crawlers/${entry.id}/${action}`, {
+                method: 'POST',
+            });
+            if (response.status === 400) {
+                const data = await response.json();
+                const fields = Object.entries(data.fields || {}).map(([field, message]) => `${field}: ${message}`);
+                alert(`Cannot enable this crawler:\n${fields.join('\n')}`);
+            }
+            fetchEntries();
+        } catch (error) {
+            console.error(`Error trying to ${action} crawler:`, error);
+        }
+    };
+
     const getStatusBadgeClasses = (status: string) => {
-        if (status === 'Running') return 'bg-green-100 text-green-800';
+        if (status === 'Running' || status === 'Queued') return 'bg-green-100 text-green-800';
+        if (status === 'Scheduled') return 'bg-blue-100 text-blue-800';
         if (status === 'Error') return 'bg-red-100 text-red-800';
         return 'bg-gray-100 text-gray-800';
     };
+    const fieldError = (name: string) =>
+        fieldErrors[name] && <p className="text-sm text-red-600">{fieldErrors[name]}</p>;
     const handleCancel = () => {
         setFormData(initialFormData);
         setEditingId(null);
+        setFieldErrors({});
         setShowForm(false);
     };
 
@@ -163,6 +281,7 @@ const CrawlerDashboard: React.FC = () => {
                         onClick={() => {
                             setFormData(initialFormData);
                             setEditingId(null);
+                            setFieldErrors({});
                             setShowForm(true);
                         }}
                         className="bg-blue-500 text-white px-4 py-2 rounded-md hover:bg-blue-600 inline-flex items-center"
@@ -200,6 +319,7 @@ const CrawlerDashboard: React.FC = () => {
                                             className="w-full p-2 border rounded-md"
                                             required
                                         />
+                                            {fieldError('product')}
                                     </div>
                                     <div>
                                         <label>Sitemap URL</label>
@@ -211,6 +331,7 @@ const CrawlerDashboard: React.FC = () => {
                                             className="w-full p-2 border rounded-md"
                                             required
                                         />
+                                            {fieldError('sitemapUrl')}
                                     </div>
                                     <div>
                                         <label>Map URL</label>
@@ -221,6 +342,7 @@ const CrawlerDashboard: React.FC = () => {
                                             onChange={handleChange}
                                             className="w-full p-2 border rounded-md"
                                         />
+                                            {fieldError('mapUrl')}
                                     </div>
                                     <div>
                                         <label>User Agent</label>
@@ -241,6 +363,7 @@ const CrawlerDashboard: React.FC = () => {
                                             onChange={handleChange}
                                             className="w-full p-2 border rounded-md"
                                         />
+                                            {fieldError('crawlInterval')}
                                     </div>
                                     <div>
                                         <label>Max Depth</label>
@@ -251,6 +374,7 @@ const CrawlerDashboard: React.FC = () => {
                                             onChange={handleChange}
                                             className="w-full p-2 border rounded-md"
                                         />
+                                            {fieldError('maxDepth')}
                                     </div>
                                     <div>
                                         <label>Default Category</label>
@@ -262,6 +386,159 @@ const CrawlerDashboard: React.FC = () => {
                                             className="w-full p-2 border rounded-md"
                                         />
                                     </div>
+                                    <div>
+                                        <label>Extraction Profile</label>
+                                        <input
+                                            type="text"
+                                            name="profile"
+                                            value={formData.profile || ''}
+                                            onChange={handleChange}
+                                            placeholder="madcap"
+                                            className="w-full p-2 border rounded-md"
+                                        />
+                                            {fieldError('profile')}
+                                    </div>
+                                    <div>
+                                        <label className="inline-flex items-center space-x-2">
+                                            <input
+                                                type="checkbox"
+                                                name="downloadAssets"
+                                                checked={formData.downloadAssets || false}
+                                                onChange={(e) =>
+                                                    setFormData((prev) => ({
+                                                        ...prev,
+                                                        downloadAssets: e.target.checked,
+                                                    }))
+                                                }
+                                            />
+                                            <span>Download images and attachments</span>
+                                        </label>
+                                    </div>
+                                    <div>
+                                        <label className="inline-flex items-center space-x-2">
+                                            <input
+                                                type="checkbox"
+                                                name="duplicatePolicy"
+                                                checked={formData.duplicatePolicy === 'link'}
+                                                onChange={(e) =>
+                                                    setFormData((prev) => ({
+                                                        ...prev,
+                                                        duplicatePolicy: e.target.checked ? 'link' : '',
+                                                    }))
+                                                }
+                                            />
+                                            <span>Link duplicates of existing articles instead of storing them again</span>
+                                        </label>
+                                        {fieldError('duplicatePolicy')}
+                                    </div>
+                                    <div>
+                                        <label className="inline-flex items-center space-x-2">
+                                            <input
+                                                type="checkbox"
+                                                name="followLinks"
+                                                checked={formData.followLinks || false}
+                                                onChange={(e) =>
+                                                    setFormData((prev) => ({
+                                                        ...prev,
+                                                        followLinks: e.target.checked,
+                                                    }))
+                                                }
+                                            />
+                                            <span>Follow links beyond the sitemap (up to Max Depth)</span>
+                                        </label>
+                                    </div>
+                                    {formData.followLinks && (
+                                        <>
+                                            <div>
+                                                <label>Follow Only URLs Matching (one regex per line)</label>
+                                                <textarea
+                                                    name="includePatterns"
+                                                    value={formData.includePatterns?.join('\n') || ''}
+                                                    onChange={(e) =>
+                                                        setFormData((prev) => ({
+                                                            ...prev,
+                                                            includePatterns: e.target.value.split('\n'),
+                                                        }))
+                                                    }
+                                                    className="w-full p-2 border rounded-md h-20 font-mono"
+                                                />
+                                                {fieldError('includePatterns')}
+                                            </div>
+                                            <div>
+                                                <label>Never Follow URLs Matching (one regex per line)</label>
+                                                <textarea
+                                                    name="excludePatterns"
+                                                    value={formData.excludePatterns?.join('\n') || ''}
+                                                    onChange={(e) =>
+                                                        setFormData((prev) => ({
+                                                            ...prev,
+                                                            excludePatterns: e.target.value.split('\n'),
+                                                        }))
+                                                    }
+                                                    className="w-full p-2 border rounded-md h-20 font-mono"
+                                                />
+                                                {fieldError('excludePatterns')}
+                                            </div>
+                                        </>
+                                    )}
+                                    <div>
+                                        <label>URL Filters (first matching rule wins)</label>
+                                        <div className="space-y-2">
+                                            {(formData.urlFilters || []).map((filter, index) => (
+                                                <div key={index} className="flex items-center space-x-2">
+                                                    <select
+                                                        value={filter.action}
+                                                        onChange={(e) =>
+                                                            updateFilter(index, {
+                                                                action: e.target.value as URLFilter['action'],
+                                                            })
+                                                        }
+                                                        className="p-2 border rounded-md"
+                                                    >
+                                                        <option value="exclude">Exclude</option>
+                                                        <option value="include">Include</option>
+                                                    </select>
+                                                    <select
+                                                        value={filter.type}
+                                                        onChange={(e) =>
+                                                            updateFilter(index, {
+                                                                type: e.target.value as URLFilter['type'],
+                                                            })
+                                                        }
+                                                        className="p-2 border rounded-md"
+                                                    >
+                                                        <option value="glob">Glob</option>
+                                                        <option value="regex">Regex</option>
+                                                    </select>
+                                                    <input
+                                                        type="text"
+                                                        value={filter.pattern}
+                                                        onChange={(e) => updateFilter(index, { pattern: e.target.value })}
+                                                        placeholder={filter.type === 'glob' ? '*/ReleaseNotes/*' : '/(fr|de)/'}
+                                                        className="flex-1 p-2 border rounded-md font-mono"
+                                                        required
+                                                    />
+                                                    <button type="button" onClick={() => moveFilter(index, -1)} className="p-1">
+                                                        <ArrowUp className="w-4 h-4" />
+                                                    </button>
+                                                    <button type="button" onClick={() => moveFilter(index, 1)} className="p-1">
+                                                        <ArrowDown className="w-4 h-4" />
+                                                    </button>
+                                                    <button type="button" onClick={() => removeFilter(index)} className="p-1 text-red-500">
+                                                        <Trash2 className="w-4 h-4" />
+                                                    </button>
+                                                </div>
+                                            ))}
+                                            <button
+                                                type="button"
+                                                onClick={addFilter}
+                                                className="text-blue-500 inline-flex items-center"
+                                            >
+                                                <Plus className="w-4 h-4 mr-1" /> Add Filter
+                                            </button>
+                                            {fieldError('urlFilters')}
+                                        </div>
+                                    </div>
                                     <div>
                                         <label>Allowed Domains (comma-separated)</label>
                                         <textarea
@@ -275,6 +552,7 @@ const CrawlerDashboard: React.FC = () => {
                                             }
                                             className="w-full p-2 border rounded-md h-24"
                                         />
+                                            {fieldError('allowedDomains')}
                                     </div>
                                     <div className="flex justify-end space-x-4">
                                         <button
@@ -336,7 +614,19 @@ const CrawlerDashboard: React.FC = () => {
                                     </td>
                                     <td>{new Date(entry.dateAdded).toLocaleDateString()}</td>
                                     <td>{new Date(entry.dateModified).toLocaleDateString()}</td>
-                                    <td>{entry.lastRunTime || 'Never'}</td>
+                                    <td>
+                                        {entry.lastRunTime || 'Never'}
+                                        {entry.lastRunSummary && entry.lastRunSummary.filteredUrls > 0 && (
+                                            <div
+                                                className="text-xs text-gray-500"
+                                                title={entry.lastRunSummary.filtered
+                                                    ?.map((f) => `${f.count} by ${f.rule}`)
+                                                    .join('\n')}
+                                            >
+                                                {entry.lastRunSummary.filteredUrls} URLs filtered
+                                            </div>
+                                        )}
+                                    </td>
                                     <td>
                                         <button
                                             onClick={() => handleEdit(entry)}
@@ -344,6 +634,35 @@ const CrawlerDashboard: React.FC = () => {
                                         >
                                             <Pencil className="w-4 h-4" />
                                         </button>
+                                        <button
+                                            onClick={() => handleRun(entry)}
+                                            className="text-green-600 ml-2"
+                                            title="Run now"
+                                        >
+                                            <Play className="w-4 h-4" />
+                                        </button>
+                                        <button
+                                            onClick={() => handleDryRun(entry)}
+                                            className="text-purple-600 ml-2"
+                                            title="Dry run: report what a crawl would change without saving it"
+                                        >
+                                            <FlaskConical className="w-4 h-4" />
+                                        </button>
+                                        <button
+                                            onClick={() => handleToggleEnabled(entry)}
+                                            className={entry.enabled ? 'text-gray-600 ml-2' : 'text-blue-600 ml-2'}
+                                            title={entry.enabled ? 'Disable scheduled runs' : 'Enable scheduled runs'}
+                                        >
+                                            <Power className="w-4 h-4" />
+                                        </button>
+                                        {dryRuns[entry.id] && (
+                                            <a
+                                                href={`http://localhost:8080/api/runs/${dryRuns[entry.id]}/report?format=html`}
+                                                className="text-xs text-blue-500 underline ml-2"
+                                            >
+                                                Dry run report
+                                            </a>
+                                        )}
                                     </td>
                                 </tr>
                             ))}
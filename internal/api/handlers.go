@@ -1,30 +1,58 @@
 package api
 
 import (
-	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"io"
 	"log"
+	"log/slog"
 	"net/http"
+	"net/url"
+	"os"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"github.com/romangod6/kb-crawler/internal/blobstore"
 	"github.com/romangod6/kb-crawler/internal/crawler"
+	"github.com/romangod6/kb-crawler/internal/dedupe"
+	"github.com/romangod6/kb-crawler/internal/events"
+	"github.com/romangod6/kb-crawler/internal/leader"
 	"github.com/romangod6/kb-crawler/internal/models"
+	"github.com/romangod6/kb-crawler/internal/queue"
+	"github.com/romangod6/kb-crawler/internal/scheduler"
 	"github.com/romangod6/kb-crawler/internal/storage"
+	"github.com/romangod6/kb-crawler/internal/textdiff"
 	"github.com/romangod6/kb-crawler/internal/utils"
+	"mime"
+	"path"
 )
 
 type Handler struct {
-	store storage.Store
+	store     storage.Store
+	blobs     *blobstore.Store
+	events    *events.Bus
+	scheduler *scheduler.Scheduler
+	queue     *queue.Queue
+	elector   *leader.Elector
 }
 
 type ErrorResponse struct {
 	Error string `json:"error"`
 }
 
+// ValidationErrorResponse lists what is wrong with each invalid field of a request, keyed by
+// the field's JSON name.
+type ValidationErrorResponse struct {
+	Error  string            `json:"error"`
+	Fields map[string]string `json:"fields"`
+}
+
 type PaginationResponse struct {
 	Data       interface{} `json:"data"`
 	Page       int         `json:"page"`
@@ -32,8 +60,31 @@ type PaginationResponse struct {
 	TotalCount int         `json:"total_count,omitempty"`
 }
 
-func NewHandler(store storage.Store) *Handler {
-	return &Handler{store: store}
+func NewHandler(store storage.Store, blobs *blobstore.Store, bus *events.Bus, sched *scheduler.Scheduler,
+	jobs *queue.Queue, elector *leader.Elector) *Handler {
+	return &Handler{store: store, blobs: blobs, events: bus, scheduler: sched, queue: jobs, elector: elector}
+}
+
+// GetHealth reports whether the instance is up and which instance leads the scheduler, with
+// the term it was elected in.
+func (h *Handler) GetHealth(c *gin.Context) {
+	if h.elector == nil {
+		c.JSON(http.StatusOK, gin.H{"status": "healthy"})
+		return
+	}
+
+	current, err := h.elector.Leader(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"status": "unhealthy", "error": "Failed to fetch scheduler leader"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":   "healthy",
+		"instance": h.elector.ID(),
+		"isLeader": h.elector.IsLeader(),
+		"leader":   current,
+	})
 }
 
 // Existing handlers
@@ -41,7 +92,7 @@ func (h *Handler) ListArticles(c *gin.Context) {
 	page, limit := getPaginationParams(c)
 	offset := (page - 1) * limit
 
-	articles, err := h.store.ListArticles(c.Request.Context(), limit, offset)
+	articles, err := h.store.ListArticles(c.Request.Context(), limit, offset, includeDeleted(c))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to fetch articles"})
 		return
@@ -75,354 +126,1228 @@ func (h *Handler) GetArticle(c *gin.Context) {
 	c.JSON(http.StatusOK, article)
 }
 
-func (h *Handler) SearchArticles(c *gin.Context) {
-	query := c.Query("q")
-	if query == "" {
-		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Search query is required"})
+func (h *Handler) GetArticleOutline(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid article ID"})
 		return
 	}
 
-	page, limit := getPaginationParams(c)
-	offset := (page - 1) * limit
-
-	articles, err := h.store.SearchArticles(c.Request.Context(), query, limit, offset)
+	article, err := h.store.GetArticle(c.Request.Context(), id)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to search articles"})
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to fetch article"})
 		return
 	}
 
-	c.JSON(http.StatusOK, PaginationResponse{
-		Data:  articles,
-		Page:  page,
-		Limit: limit,
-	})
-}
+	if article == nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Article not found"})
+		return
+	}
 
-func (h *Handler) ListCategories(c *gin.Context) {
-	categories, err := h.store.ListCategories(c.Request.Context())
+	sections, err := h.store.GetArticleSections(c.Request.Context(), id)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to fetch categories"})
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to fetch article outline"})
 		return
 	}
 
-	c.JSON(http.StatusOK, categories)
+	// Leave section bodies out unless asked for, the outline is usually all a caller needs
+	if c.Query("content") != "true" {
+		for _, section := range sections {
+			section.Content = ""
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"article_id": article.ID,
+		"url":        article.URL,
+		"sections":   models.BuildSectionTree(article.URL, sections),
+	})
 }
 
-func (h *Handler) GetCategory(c *gin.Context) {
+func (h *Handler) ListArticleVersions(c *gin.Context) {
 	id, err := uuid.Parse(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid category ID"})
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid article ID"})
 		return
 	}
 
-	category, err := h.store.GetCategory(c.Request.Context(), id)
+	versions, err := h.store.ListArticleVersions(c.Request.Context(), id)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to fetch category"})
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to fetch article versions"})
 		return
 	}
 
-	if category == nil {
-		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Category not found"})
-		return
+	if versions == nil {
+		versions = []*models.ArticleVersion{}
 	}
 
-	c.JSON(http.StatusOK, category)
+	c.JSON(http.StatusOK, versions)
 }
 
-func (h *Handler) GetArticlesByCategory(c *gin.Context) {
-	categoryID, err := uuid.Parse(c.Param("id"))
+// DiffArticleVersions compares two revisions of an article's text. ?to defaults to the latest
+// revision and ?from to the one before it; ?mode=words returns a word-level diff instead of a
+// unified line diff.
+func (h *Handler) DiffArticleVersions(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid category ID"})
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid article ID"})
 		return
 	}
 
-	page, limit := getPaginationParams(c)
-	offset := (page - 1) * limit
+	mode := c.DefaultQuery("mode", "unified")
+	if mode != "unified" && mode != "words" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Mode must be unified or words"})
+		return
+	}
 
-	articles, err := h.store.GetArticlesByCategory(c.Request.Context(), categoryID, limit, offset)
+	ctx := c.Request.Context()
+	to, err := strconv.Atoi(c.Query("to"))
+	if c.Query("to") == "" {
+		versions, listErr := h.store.ListArticleVersions(ctx, id)
+		if listErr != nil {
+			c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to fetch article versions"})
+			return
+		}
+		if len(versions) == 0 {
+			c.JSON(http.StatusNotFound, ErrorResponse{Error: "Article has no versions"})
+			return
+		}
+		to, err = versions[0].Version, nil
+	}
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to fetch articles"})
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid to version"})
 		return
 	}
 
-	c.JSON(http.StatusOK, PaginationResponse{
-		Data:  articles,
-		Page:  page,
-		Limit: limit,
-	})
-}
+	from, err := strconv.Atoi(c.DefaultQuery("from", strconv.Itoa(to-1)))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid from version"})
+		return
+	}
 
-// New Crawler Config Handlers
-func (h *Handler) ListCrawlerConfigs(c *gin.Context) {
-	configs, err := h.store.ListCrawlerConfigs(c.Request.Context())
+	fromVersion, err := h.store.GetArticleVersion(ctx, id, from)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to fetch crawler configs"})
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to fetch article version"})
+		return
+	}
+	toVersion, err := h.store.GetArticleVersion(ctx, id, to)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to fetch article version"})
+		return
+	}
+	if fromVersion == nil || toVersion == nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Article version not found"})
 		return
 	}
 
-	if configs == nil {
-		configs = []*models.CrawlerConfig{}
+	fromText := textdiff.PlainText(fromVersion.Body)
+	toText := textdiff.PlainText(toVersion.Body)
+
+	response := gin.H{
+		"article_id": id,
+		"from":       fromVersion.Version,
+		"to":         toVersion.Version,
+		"mode":       mode,
+	}
+	if mode == "words" {
+		response["edits"] = textdiff.Words(fromText, toText)
+	} else {
+		response["diff"] = textdiff.Unified(
+			fmt.Sprintf("version %d (%s)", fromVersion.Version, fromVersion.CreatedAt.Format(time.RFC3339)),
+			fmt.Sprintf("version %d (%s)", toVersion.Version, toVersion.CreatedAt.Format(time.RFC3339)),
+			fromText, toText, 3)
 	}
 
-	c.JSON(http.StatusOK, configs)
+	c.JSON(http.StatusOK, response)
 }
 
-func (h *Handler) GetCrawlerConfig(c *gin.Context) {
+func (h *Handler) ListArticleAssets(c *gin.Context) {
 	id, err := uuid.Parse(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid crawler config ID"})
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid article ID"})
 		return
 	}
 
-	config, err := h.store.GetCrawlerConfig(c.Request.Context(), id)
+	assets, err := h.store.ListArticleAssets(c.Request.Context(), id)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to fetch crawler config"})
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to fetch article assets"})
 		return
 	}
 
-	if config == nil {
-		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Crawler config not found"})
-		return
+	if assets == nil {
+		assets = []*models.Asset{}
 	}
 
-	c.JSON(http.StatusOK, config)
+	c.JSON(http.StatusOK, assets)
 }
 
-func (h *Handler) CreateCrawlerConfig(c *gin.Context) {
-	var config models.CrawlerConfig
-	if err := c.ShouldBindJSON(&config); err != nil {
-		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid crawler config data"})
+func (h *Handler) ListArticleLinks(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid article ID"})
 		return
 	}
 
-	// Generate new UUID if not provided
-	if config.ID == uuid.Nil {
-		config.ID = uuid.New()
+	links, err := h.store.ListArticleLinks(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to fetch article links"})
+		return
 	}
 
-	// Set initial values for new crawler config
-	now := time.Now()
-	config.Status = "Running"
-	config.IsFirstRun = true
-	config.CreatedAt = now
-	config.UpdatedAt = now
-
-	// Calculate next scheduled run based on crawl interval
-	if interval, err := time.ParseDuration(config.CrawlInterval); err == nil {
-		nextRun := now.Add(interval)
-		config.NextRun = &nextRun
+	if links == nil {
+		links = []*models.ArticleLink{}
 	}
 
-	if err := h.store.CreateCrawlerConfig(c.Request.Context(), &config); err != nil {
-		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to create crawler config"})
+	c.JSON(http.StatusOK, links)
+}
+
+// ListBacklinks returns the links from other articles that point at an article.
+func (h *Handler) ListBacklinks(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid article ID"})
 		return
 	}
 
-	// Start the crawler in a goroutine
-	go func() {
-		log.Printf("Starting crawler for config ID: %s", config.ID)
-		if err := h.runCrawler(config); err != nil {
-			log.Printf("Error running crawler: %v", err)
-			config.Status = "Error"
-			config.Errors = append(config.Errors, err.Error())
-		} else {
-			config.Status = "Completed"
-		}
+	links, err := h.store.ListBacklinks(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to fetch backlinks"})
+		return
+	}
 
-		// Update the config status
-		if err := h.store.UpdateCrawlerConfig(context.Background(), &config); err != nil {
-			log.Printf("Error updating crawler status: %v", err)
-		}
-	}()
+	if links == nil {
+		links = []*models.ArticleLink{}
+	}
 
-	c.JSON(http.StatusCreated, config)
+	c.JSON(http.StatusOK, links)
 }
 
-func (h *Handler) UpdateCrawlerConfig(c *gin.Context) {
+func (h *Handler) GetAsset(c *gin.Context) {
 	id, err := uuid.Parse(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid crawler config ID"})
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid asset ID"})
 		return
 	}
 
-	var config models.CrawlerConfig
-	if err := c.ShouldBindJSON(&config); err != nil {
-		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid crawler config data"})
+	asset, err := h.store.GetAsset(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to fetch asset"})
 		return
 	}
 
-	config.ID = id
-
-	if err := h.store.UpdateCrawlerConfig(c.Request.Context(), &config); err != nil {
-		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to update crawler config"})
+	if asset == nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Asset not found"})
 		return
 	}
 
-	c.JSON(http.StatusOK, config)
+	c.JSON(http.StatusOK, asset)
 }
 
-func (h *Handler) DeleteCrawlerConfig(c *gin.Context) {
+// inlineAssetTypes are the asset types served inline. They are raster images, which browsers
+// never run as script; any other crawled content could carry script onto the API's origin.
+var inlineAssetTypes = map[string]bool{
+	"image/avif": true,
+	"image/bmp":  true,
+	"image/gif":  true,
+	"image/jpeg": true,
+	"image/png":  true,
+	"image/webp": true,
+}
+
+// GetAssetContent serves a downloaded asset from the blob store. Assets that have not been
+// mirrored are not found; their source URL is in the asset's details. Raster images are
+// served inline and everything else as a download, all sandboxed and without type sniffing.
+func (h *Handler) GetAssetContent(c *gin.Context) {
 	id, err := uuid.Parse(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid crawler config ID"})
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid asset ID"})
 		return
 	}
 
-	if err := h.store.DeleteCrawlerConfig(c.Request.Context(), id); err != nil {
-		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to delete crawler config"})
+	asset, err := h.store.GetAsset(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to fetch asset"})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"status": "deleted"})
+	if asset == nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Asset not found"})
+		return
+	}
+
+	if asset.SHA256 == "" || h.blobs == nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Asset has not been downloaded"})
+		return
+	}
+
+	contentType := "application/octet-stream"
+	if mediaType, _, err := mime.ParseMediaType(asset.MIMEType); err == nil {
+		contentType = mediaType
+	}
+	c.Header("Content-Type", contentType)
+	c.Header("X-Content-Type-Options", "nosniff")
+	c.Header("Content-Security-Policy", "sandbox")
+	if !inlineAssetTypes[contentType] {
+		c.Header("Content-Disposition", assetDisposition(asset.URL))
+	}
+	c.Header("Cache-Control", "public, max-age=31536000, immutable")
+	c.File(h.blobs.Path(asset.SHA256))
 }
 
-// Utility functions
-func getPaginationParams(c *gin.Context) (page, limit int) {
-	page, _ = strconv.Atoi(c.DefaultQuery("page", "1"))
-	limit, _ = strconv.Atoi(c.DefaultQuery("limit", "10"))
+// assetDisposition returns an attachment Content-Disposition named after the asset's URL.
+func assetDisposition(assetURL string) string {
+	if u, err := url.Parse(assetURL); err == nil {
+		if name := path.Base(u.Path); name != "." && name != "/" {
+			if disposition := mime.FormatMediaType("attachment", map[string]string{"filename": name}); disposition != "" {
+				return disposition
+			}
+		}
+	}
+	return "attachment"
+}
 
-	if page < 1 {
-		page = 1
+func (h *Handler) SearchArticles(c *gin.Context) {
+	query := c.Query("q")
+	if query == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Search query is required"})
+		return
 	}
-	if limit < 1 || limit > 100 {
-		limit = 10
+
+	page, limit := getPaginationParams(c)
+	offset := (page - 1) * limit
+
+	articles, err := h.store.SearchArticles(c.Request.Context(), query, limit, offset, includeDeleted(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to search articles"})
+		return
 	}
 
-	return page, limit
+	c.JSON(http.StatusOK, PaginationResponse{
+		Data:  articles,
+		Page:  page,
+		Limit: limit,
+	})
 }
-func (h *Handler) StartCrawl(c *gin.Context) {
-	var crawlConfig models.CrawlerConfig
-	if err := c.ShouldBindJSON(&crawlConfig); err != nil {
-		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid request payload"})
+
+func (h *Handler) ListLowConfidenceArticles(c *gin.Context) {
+	threshold, err := strconv.ParseFloat(c.DefaultQuery("threshold", "0.5"), 64)
+	if err != nil || threshold < 0 || threshold > 1 {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Threshold must be a number between 0 and 1"})
 		return
 	}
 
-	// Generate new UUID for the crawl
-	crawlConfig.ID = uuid.New()
-	crawlConfig.Status = "Running"
-	crawlConfig.CreatedAt = time.Now()
-	crawlConfig.UpdatedAt = time.Now()
+	page, limit := getPaginationParams(c)
+	offset := (page - 1) * limit
 
-	// Save the crawl config in the database
-	if err := h.store.CreateCrawlerConfig(c.Request.Context(), &crawlConfig); err != nil {
-		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to save crawl configuration"})
+	articles, err := h.store.ListLowConfidenceArticles(c.Request.Context(), threshold, limit, offset, includeDeleted(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to fetch articles"})
 		return
 	}
 
-	// Start the crawl in a Goroutine
-	go func(config models.CrawlerConfig) {
-		log.Printf("Starting crawl for %s...", config.SitemapURL)
+	c.JSON(http.StatusOK, PaginationResponse{
+		Data:  articles,
+		Page:  page,
+		Limit: limit,
+	})
+}
+
+// ListDuplicates reports clusters of articles with the same or nearly the same text, across all
+// crawler configs. ?max_distance= sets how many SimHash bits near-duplicates may differ by, 0
+// for exact duplicates only; ?config_id= keeps the clusters that include one of its articles.
+func (h *Handler) ListDuplicates(c *gin.Context) {
+	maxDistance, err := strconv.Atoi(c.DefaultQuery("max_distance", strconv.Itoa(dedupe.DefaultMaxDistance)))
+	if err != nil || maxDistance < 0 || maxDistance > 16 {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "max_distance must be a number between 0 and 16"})
+		return
+	}
 
-		// Update logs and status during crawling
-		err := h.runCrawler(config)
-		if err != nil {
-			log.Printf("Crawl failed for %s: %v", config.SitemapURL, err)
-			config.Status = "Error"
-			config.Errors = append(config.Errors, err.Error())
-		} else {
-			log.Printf("Crawl completed for %s", config.SitemapURL)
-			config.Status = "Stopped"
+	var configID uuid.UUID
+	if raw := c.Query("config_id"); raw != "" {
+		if configID, err = uuid.Parse(raw); err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid config ID"})
+			return
 		}
+	}
+
+	fingerprints, err := h.store.ListArticleFingerprints(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to fetch article fingerprints"})
+		return
+	}
 
-		config.UpdatedAt = time.Now()
-		if updateErr := h.store.UpdateCrawlerConfig(context.Background(), &config); updateErr != nil {
-			log.Printf("Failed to update crawl config: %v", updateErr)
+	clusters := make([]*models.DuplicateCluster, 0)
+	for _, cluster := range dedupe.Report(fingerprints, maxDistance) {
+		if configID == uuid.Nil || clusterHasConfig(cluster, configID) {
+			clusters = append(clusters, cluster)
 		}
-	}(crawlConfig)
-
-	c.JSON(http.StatusAccepted, crawlConfig)
-}
-func (h *Handler) runCrawler(config models.CrawlerConfig) error {
-	// Create logger for this crawl
-	logger, err := utils.NewCrawlerLogger(config.Product)
-	if err != nil {
-		log.Printf("Failed to create logger: %v", err)
-		return fmt.Errorf("failed to create logger: %w", err)
-	}
-	defer logger.Close()
-
-	logger.LogInfo("Starting crawler for %s (ID: %s)", config.Product, config.ID)
-	logger.LogInfo("Configuration details:")
-	logger.LogInfo("  Sitemap URL: %s", config.SitemapURL)
-	logger.LogInfo("  Map URL: %s", config.MapURL)
-	logger.LogInfo("  Max Depth: %d", config.MaxDepth)
-	logger.LogInfo("  User Agent: %s", config.UserAgent)
-	logger.LogInfo("  Crawl Interval: %s", config.CrawlInterval)
-	logger.LogInfo("  Default Category: %s", config.DefaultCategory)
-	logger.LogInfo("  Allowed Domains: %v", config.AllowedDomains)
-
-	// Set default MapURL if not provided
-	if config.MapURL == "" {
-		config.MapURL = strings.Replace(config.SitemapURL, "Sitemap.xml", "0HOME/Home.htm", 1)
-		logger.LogInfo("Set default Map URL to: %s", config.MapURL)
-	}
-
-	crawlerInstance := crawler.NewCrawler(h.store, &crawler.CrawlerConfig{
-		SitemapURL:      config.SitemapURL,
-		MapURL:          config.MapURL,
-		UserAgent:       config.UserAgent,
-		MaxDepth:        config.MaxDepth,
-		AllowedDomains:  config.AllowedDomains,
-		DefaultCategory: config.DefaultCategory,
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"clusters":     clusters,
+		"max_distance": maxDistance,
 	})
+}
 
-	// Update status to Running
-	config.Status = "Running"
-	if err := h.store.UpdateCrawlerConfig(context.Background(), &config); err != nil {
-		logger.LogError("Failed to update crawler status: %v", err)
-		return fmt.Errorf("failed to update crawler status: %w", err)
+func clusterHasConfig(cluster *models.DuplicateCluster, configID uuid.UUID) bool {
+	for _, article := range cluster.Articles {
+		if article.ConfigID != nil && *article.ConfigID == configID {
+			return true
+		}
 	}
+	return false
+}
 
-	logger.LogInfo("Beginning category structure mapping...")
-	categoryStructure, err := crawlerInstance.MapCategoryStructure(context.Background())
+func (h *Handler) ListCategories(c *gin.Context) {
+	categories, err := h.store.ListCategories(c.Request.Context())
 	if err != nil {
-		config.Status = "Error"
-		config.Errors = append(config.Errors, err.Error())
-		h.store.UpdateCrawlerConfig(context.Background(), &config)
-		logger.LogError("Failed to map category structure: %v", err)
-		return fmt.Errorf("failed to map category structure: %w", err)
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to fetch categories"})
+		return
 	}
-	logger.LogInfo("Category structure mapping completed successfully")
 
-	logger.LogInfo("Starting crawl process...")
-	err = crawlerInstance.Crawl(context.Background(), categoryStructure)
-	now := time.Now()
+	c.JSON(http.StatusOK, categories)
+}
 
+func (h *Handler) GetCategory(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
 	if err != nil {
-		config.Status = "Error"
-		config.Errors = append(config.Errors, err.Error())
-		logger.LogError("Crawl failed with error: %v", err)
-	} else {
-		config.Status = "Completed"
-		config.LastRun = &now
-
-		// Calculate next run time
-		if interval, err := time.ParseDuration(config.CrawlInterval); err == nil {
-			nextRun := now.Add(interval)
-			config.NextRun = &nextRun
-			logger.LogInfo("Next scheduled run: %v", nextRun)
-		}
-		logger.LogInfo("Crawl completed successfully")
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid category ID"})
+		return
 	}
 
-	config.IsFirstRun = false
-	config.UpdatedAt = now
+	category, err := h.store.GetCategory(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to fetch category"})
+		return
+	}
 
-	if updateErr := h.store.UpdateCrawlerConfig(context.Background(), &config); updateErr != nil {
-		logger.LogError("Error updating crawler status: %v", updateErr)
+	if category == nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Category not found"})
+		return
 	}
 
-	logger.LogInfo("Crawler execution finished. Status: %s", config.Status)
-	if len(config.Errors) > 0 {
-		logger.LogError("Errors encountered during crawl:")
-		for _, err := range config.Errors {
-			logger.LogError("  - %s", err)
-		}
+	c.JSON(http.StatusOK, category)
+}
+
+func (h *Handler) GetArticlesByCategory(c *gin.Context) {
+	categoryID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid category ID"})
+		return
 	}
 
+	page, limit := getPaginationParams(c)
+	offset := (page - 1) * limit
+
+	articles, err := h.store.GetArticlesByCategory(c.Request.Context(), categoryID, limit, offset, includeDeleted(c))
 	if err != nil {
-		return fmt.Errorf("crawl failed: %w", err)
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to fetch articles"})
+		return
 	}
 
-	return nil
+	c.JSON(http.StatusOK, PaginationResponse{
+		Data:  articles,
+		Page:  page,
+		Limit: limit,
+	})
+}
+
+// New Crawler Config Handlers
+// webhookEvents are the events a webhook can subscribe to.
+var webhookEvents = map[string]bool{
+	"article.created": true,
+	"article.updated": true,
+	"article.deleted": true,
+}
+
+// GetSchedule lists every crawler config's next run, soonest first, with the jitter applied
+// and whether the run was missed while the service was down.
+func (h *Handler) GetSchedule(c *gin.Context) {
+	if h.scheduler == nil {
+		c.JSON(http.StatusServiceUnavailable, ErrorResponse{Error: "Scheduler is not running"})
+		return
+	}
+
+	entries, err := h.scheduler.Upcoming(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to fetch schedule"})
+		return
+	}
+
+	c.JSON(http.StatusOK, entries)
+}
+
+// ListChanges returns change log entries after ?since=<seq>. Consumers pass the returned
+// next_since on their next call.
+func (h *Handler) ListChanges(c *gin.Context) {
+	since, err := strconv.ParseInt(c.DefaultQuery("since", "0"), 10, 64)
+	if err != nil || since < 0 {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "since must be a non-negative sequence number"})
+		return
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "100"))
+	if err != nil || limit < 1 || limit > 1000 {
+		limit = 100
+	}
+
+	changes, err := h.store.ListChanges(c.Request.Context(), since, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to fetch changes"})
+		return
+	}
+
+	nextSince := since
+	if len(changes) > 0 {
+		nextSince = changes[len(changes)-1].Seq
+	} else {
+		changes = []*models.ArticleChange{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"changes":    changes,
+		"next_since": nextSince,
+	})
+}
+
+func (h *Handler) ListWebhooks(c *gin.Context) {
+	webhooks, err := h.store.ListWebhooks(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to fetch webhooks"})
+		return
+	}
+
+	// Secrets are only shown once, when the webhook is created
+	for _, w := range webhooks {
+		w.Secret = ""
+	}
+	if webhooks == nil {
+		webhooks = []*models.Webhook{}
+	}
+
+	c.JSON(http.StatusOK, webhooks)
+}
+
+// CreateWebhook registers a webhook. A signing secret is generated unless one is given; it is
+// returned in this response only.
+func (h *Handler) CreateWebhook(c *gin.Context) {
+	var webhook models.Webhook
+	if err := c.ShouldBindJSON(&webhook); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid webhook data"})
+		return
+	}
+
+	if !strings.HasPrefix(webhook.URL, "http://") && !strings.HasPrefix(webhook.URL, "https://") {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Webhook URL must be http or https"})
+		return
+	}
+	for _, event := range webhook.Events {
+		if !webhookEvents[event] {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: fmt.Sprintf("Unknown event %q", event)})
+			return
+		}
+	}
+
+	if webhook.Secret == "" {
+		secret := make([]byte, 32)
+		if _, err := rand.Read(secret); err != nil {
+			c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to generate webhook secret"})
+			return
+		}
+		webhook.Secret = hex.EncodeToString(secret)
+	}
+
+	now := time.Now()
+	webhook.ID = uuid.New()
+	webhook.Active = true
+	webhook.CreatedAt = now
+	webhook.UpdatedAt = now
+
+	if err := h.store.CreateWebhook(c.Request.Context(), &webhook); err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to create webhook"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, webhook)
+}
+
+func (h *Handler) DeleteWebhook(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid webhook ID"})
+		return
+	}
+
+	if err := h.store.DeleteWebhook(c.Request.Context(), id); err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, ErrorResponse{Error: "Webhook not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to delete webhook"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "deleted"})
+}
+
+// ListWebhookDeliveries returns a webhook's deliveries, newest first. ?status=dead lists the
+// dead-lettered ones.
+func (h *Handler) ListWebhookDeliveries(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid webhook ID"})
+		return
+	}
+
+	page, limit := getPaginationParams(c)
+	offset := (page - 1) * limit
+
+	deliveries, err := h.store.ListWebhookDeliveries(c.Request.Context(), id, c.Query("status"), limit, offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to fetch webhook deliveries"})
+		return
+	}
+
+	c.JSON(http.StatusOK, PaginationResponse{
+		Data:  deliveries,
+		Page:  page,
+		Limit: limit,
+	})
+}
+
+// RetryWebhookDelivery requeues a dead-lettered delivery.
+func (h *Handler) RetryWebhookDelivery(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("deliveryId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid delivery ID"})
+		return
+	}
+
+	if err := h.store.RetryWebhookDelivery(c.Request.Context(), id); err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, ErrorResponse{Error: "No dead-lettered delivery with that ID"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to retry delivery"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": models.DeliveryPending})
+}
+
+func (h *Handler) ListCrawlerConfigs(c *gin.Context) {
+	configs, err := h.store.ListCrawlerConfigs(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to fetch crawler configs"})
+		return
+	}
+
+	if configs == nil {
+		configs = []*models.CrawlerConfig{}
+	}
+
+	c.JSON(http.StatusOK, configs)
+}
+
+func (h *Handler) GetCrawlerConfig(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid crawler config ID"})
+		return
+	}
+
+	config, err := h.store.GetCrawlerConfig(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to fetch crawler config"})
+		return
+	}
+
+	if config == nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Crawler config not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, config)
+}
+
+// ListBrokenLinks returns the internal links of a crawler config's articles that point to pages
+// missing from the sitemap or answering with a 4xx status.
+func (h *Handler) ListBrokenLinks(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid crawler config ID"})
+		return
+	}
+
+	links, err := h.store.ListBrokenLinks(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to fetch broken links"})
+		return
+	}
+
+	if links == nil {
+		links = []*models.ArticleLink{}
+	}
+
+	c.JSON(http.StatusOK, links)
+}
+
+// eventKeepalive is how often an idle event stream sends a comment so proxies keep it open.
+const eventKeepalive = 15 * time.Second
+
+// StreamCrawlerEvents streams a crawler config's live progress as server-sent events: mapping
+// started/finished, URLs queued, fetched, saved and failed, progress counts with an ETA, and
+// the final status of each run. The stream opens with a "status" event carrying the config.
+func (h *Handler) StreamCrawlerEvents(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid crawler config ID"})
+		return
+	}
+
+	config, err := h.store.GetCrawlerConfig(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to fetch crawler config"})
+		return
+	}
+	if config == nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Crawler config not found"})
+		return
+	}
+
+	if h.events == nil {
+		c.JSON(http.StatusServiceUnavailable, ErrorResponse{Error: "Live events are not available"})
+		return
+	}
+	stream, unsubscribe := h.events.Subscribe(id)
+	defer unsubscribe()
+
+	// The stream outlives the server's write timeout
+	if err := http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{}); err != nil {
+		slog.Warn("Failed to clear write deadline for event stream", "error", err)
+	}
+	c.Header("Cache-Control", "no-cache")
+	c.Header("X-Accel-Buffering", "no")
+
+	c.SSEvent("status", config)
+	c.Writer.Flush()
+
+	keepalive := time.NewTicker(eventKeepalive)
+	defer keepalive.Stop()
+
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case <-c.Request.Context().Done():
+			return false
+		case event := <-stream:
+			c.SSEvent(event.Type, event)
+		case <-keepalive.C:
+			fmt.Fprint(w, ": keepalive\n\n")
+		}
+		return true
+	})
+}
+
+func (h *Handler) CreateCrawlerConfig(c *gin.Context) {
+	var config models.CrawlerConfig
+	if err := c.ShouldBindJSON(&config); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid crawler config data"})
+		return
+	}
+
+	if !validateCrawlerConfig(c, &config) {
+		return
+	}
+
+	// Generate new UUID if not provided
+	if config.ID == uuid.Nil {
+		config.ID = uuid.New()
+	}
+
+	// New configs are drafts: nothing runs until they are enabled or run on demand
+	now := time.Now()
+	config.Status = models.ConfigDraft
+	config.Enabled = false
+	config.IsFirstRun = true
+	config.LastRun = nil
+	config.NextRun = nil
+	config.LastRunSummary = nil
+	config.LastRunID = nil
+	config.Errors = nil
+	config.Logs = nil
+	config.CreatedAt = now
+	config.UpdatedAt = now
+
+	if err := h.store.CreateCrawlerConfig(c.Request.Context(), &config); err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to create crawler config"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, config)
+}
+
+func (h *Handler) UpdateCrawlerConfig(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid crawler config ID"})
+		return
+	}
+
+	var config models.CrawlerConfig
+	if err := c.ShouldBindJSON(&config); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid crawler config data"})
+		return
+	}
+
+	if !validateCrawlerConfig(c, &config) {
+		return
+	}
+
+	config.ID = id
+
+	existing, err := h.store.GetCrawlerConfig(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to fetch crawler config"})
+		return
+	}
+	if existing == nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Crawler config not found"})
+		return
+	}
+
+	// The lifecycle and run history are kept; they change through enable, disable and runs
+	config.Status = existing.Status
+	config.Enabled = existing.Enabled
+	config.IsFirstRun = existing.IsFirstRun
+	config.LastRun = existing.LastRun
+	config.NextRun = existing.NextRun
+	config.LastRunSummary = existing.LastRunSummary
+	config.LastRunID = existing.LastRunID
+	config.Errors = existing.Errors
+	config.Logs = existing.Logs
+	config.CreatedAt = existing.CreatedAt
+
+	// Reschedule when the schedule changed
+	if config.CrawlInterval != existing.CrawlInterval {
+		config.NextRun = scheduler.NextRun(config.CrawlInterval, time.Now())
+	}
+
+	if err := h.store.UpdateCrawlerConfig(c.Request.Context(), &config); err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to update crawler config"})
+		return
+	}
+
+	c.JSON(http.StatusOK, config)
+}
+
+// EnableCrawlerConfig puts a crawler config on the crawl schedule. A config that never ran is
+// due at once. The config is validated again first, as it may predate validation.
+func (h *Handler) EnableCrawlerConfig(c *gin.Context) {
+	h.setCrawlerConfigEnabled(c, true)
+}
+
+// DisableCrawlerConfig takes a crawler config off the crawl schedule. A run in progress
+// finishes, and the config can still be run on demand.
+func (h *Handler) DisableCrawlerConfig(c *gin.Context) {
+	h.setCrawlerConfigEnabled(c, false)
+}
+
+func (h *Handler) setCrawlerConfigEnabled(c *gin.Context, enabled bool) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid crawler config ID"})
+		return
+	}
+
+	config, err := h.store.GetCrawlerConfig(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to fetch crawler config"})
+		return
+	}
+	if config == nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Crawler config not found"})
+		return
+	}
+
+	status := models.ConfigStopped
+	if enabled {
+		if !validateCrawlerConfig(c, config) {
+			return
+		}
+		status = models.ConfigScheduled
+	}
+	// Clearing the next run makes the scheduler work it out from the last run
+	if err := h.store.SetCrawlerConfigEnabled(c.Request.Context(), id, enabled, status, nil); err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to update crawler config"})
+		return
+	}
+
+	// A config with a live job keeps its status until the run finishes
+	config.Enabled = enabled
+	if config.ActiveJob == "" {
+		config.Status = status
+	}
+	config.NextRun = nil
+	c.JSON(http.StatusOK, config)
+}
+
+// ValidateCrawlerConfig checks a crawler config without saving it, so the dashboard can show
+// field errors as the config is edited.
+func (h *Handler) ValidateCrawlerConfig(c *gin.Context) {
+	var config models.CrawlerConfig
+	if err := c.ShouldBindJSON(&config); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid crawler config data"})
+		return
+	}
+
+	if !validateCrawlerConfig(c, &config) {
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"valid": true})
+}
+
+func (h *Handler) DeleteCrawlerConfig(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid crawler config ID"})
+		return
+	}
+
+	if err := h.store.DeleteCrawlerConfig(c.Request.Context(), id); err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to delete crawler config"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "deleted"})
+}
+
+// Utility functions
+
+// includeDeleted reports whether tombstoned articles were asked for with ?include_deleted=true.
+func includeDeleted(c *gin.Context) bool {
+	return c.Query("include_deleted") == "true"
+}
+
+// validateCrawlerConfig validates a crawler config from a request and responds with its field
+// errors when it is invalid. ?check_reachability=true also fetches the sitemap and map URLs.
+func validateCrawlerConfig(c *gin.Context, config *models.CrawlerConfig) bool {
+	errs := crawler.ValidateConfig(c.Request.Context(), config, crawler.ValidateOptions{
+		CheckReachability: c.Query("check_reachability") == "true",
+		UserAgent:         config.UserAgent,
+	})
+	if errs == nil {
+		return true
+	}
+
+	c.JSON(http.StatusBadRequest, ValidationErrorResponse{Error: "Invalid crawler config", Fields: errs})
+	return false
+}
+
+func getPaginationParams(c *gin.Context) (page, limit int) {
+	page, _ = strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ = strconv.Atoi(c.DefaultQuery("limit", "10"))
+
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 10
+	}
+
+	return page, limit
+}
+
+// maxRunURLs is the most pages a run can be limited to.
+const maxRunURLs = 1000
+
+// RunCrawler queues an on-demand run of a crawler config ahead of scheduled runs and returns
+// the ID the run will have with its job. The optional body overrides what the run crawls:
+// {"mode": "incremental", "urls": [...], "dryRun": true, "replay": true}. A config with a queued or running
+// job is not queued again; 409 returns the active job instead.
+func (h *Handler) RunCrawler(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid crawler config ID"})
+		return
+	}
+
+	var opts models.RunOptions
+	if err := c.ShouldBindJSON(&opts); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid run options"})
+		return
+	}
+	if err := validateRunOptions(&opts); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	if h.queue == nil {
+		c.JSON(http.StatusServiceUnavailable, ErrorResponse{Error: "Job queue is not running"})
+		return
+	}
+
+	config, err := h.store.GetCrawlerConfig(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to fetch crawler config"})
+		return
+	}
+	if config == nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Crawler config not found"})
+		return
+	}
+
+	job, queued, err := h.queue.Submit(c.Request.Context(), config, models.JobTriggerAPI, models.JobPriorityManual, &opts)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to queue crawl"})
+		return
+	}
+	if !queued {
+		c.JSON(http.StatusConflict, gin.H{"error": "A run of this crawler config is already queued or running", "job": job})
+		return
+	}
+
+	// Dry runs leave the config as it is. Only the status is written, and only while the job
+	// is still queued, so a runner that already claimed it keeps its Running status.
+	if !opts.DryRun {
+		if err := h.store.MarkCrawlerConfigQueued(c.Request.Context(), config.ID, job.ID); err != nil {
+			log.Printf("Failed to mark crawler config %s as queued: %v", config.ID, err)
+		}
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"runId": job.RunID, "job": job})
+}
+
+// validateRunOptions checks the overrides of an on-demand run.
+func validateRunOptions(opts *models.RunOptions) error {
+	switch opts.Mode {
+	case "", models.RunModeFull, models.RunModeIncremental:
+	default:
+		return fmt.Errorf("mode must be %q or %q", models.RunModeFull, models.RunModeIncremental)
+	}
+
+	if opts.Replay && !opts.DryRun {
+		return errors.New("replay is only allowed in dry runs")
+	}
+
+	if len(opts.URLs) > maxRunURLs {
+		return fmt.Errorf("a run can be limited to at most %d urls", maxRunURLs)
+	}
+	for _, u := range opts.URLs {
+		parsed, err := url.Parse(u)
+		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			return fmt.Errorf("invalid url %q: must be an absolute http(s) URL", u)
+		}
+	}
+	return nil
+}
+
+// ListCrawlJobs returns the job queue: queued and running jobs in the order they run, then
+// finished jobs, newest first. ?status= limits it to one status.
+func (h *Handler) ListCrawlJobs(c *gin.Context) {
+	status := c.Query("status")
+	switch status {
+	case "", models.JobQueued, models.JobRunning, models.JobCompleted, models.JobFailed, models.JobCancelled:
+	default:
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "status must be queued, running, completed, failed or cancelled"})
+		return
+	}
+
+	page, limit := getPaginationParams(c)
+	offset := (page - 1) * limit
+
+	jobs, err := h.store.ListCrawlJobs(c.Request.Context(), status, limit, offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to fetch jobs"})
+		return
+	}
+
+	c.JSON(http.StatusOK, PaginationResponse{
+		Data:  jobs,
+		Page:  page,
+		Limit: limit,
+	})
+}
+
+func (h *Handler) GetCrawlJob(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid job ID"})
+		return
+	}
+
+	job, err := h.store.GetCrawlJob(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to fetch job"})
+		return
+	}
+	if job == nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Job not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, job)
+}
+
+// CancelCrawlJob cancels a job that is still queued. Running jobs can't be cancelled.
+func (h *Handler) CancelCrawlJob(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid job ID"})
+		return
+	}
+
+	if err := h.store.CancelCrawlJob(c.Request.Context(), id); err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusConflict, ErrorResponse{Error: "No queued job with that ID"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to cancel job"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": models.JobCancelled})
+}
+
+// ListCrawlRuns returns crawl runs, newest first. ?config_id= limits them to one config.
+func (h *Handler) ListCrawlRuns(c *gin.Context) {
+	var configID *uuid.UUID
+	if raw := c.Query("config_id"); raw != "" {
+		id, err := uuid.Parse(raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid config ID"})
+			return
+		}
+		configID = &id
+	}
+
+	page, limit := getPaginationParams(c)
+	offset := (page - 1) * limit
+
+	runs, err := h.store.ListCrawlRuns(c.Request.Context(), configID, limit, offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to fetch runs"})
+		return
+	}
+
+	c.JSON(http.StatusOK, PaginationResponse{
+		Data:  runs,
+		Page:  page,
+		Limit: limit,
+	})
+}
+
+func (h *Handler) GetCrawlRun(c *gin.Context) {
+	run, ok := h.lookupRun(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, run)
+}
+
+// GetCrawlRunLogs returns a run's log records. ?level= drops records below a level (debug,
+// info, warn or error) and ?tail= keeps only the last records; ?format=text returns plain text
+// instead of JSON.
+func (h *Handler) GetCrawlRunLogs(c *gin.Context) {
+	run, ok := h.lookupRun(c)
+	if !ok {
+		return
+	}
+
+	level := slog.LevelDebug
+	if raw := c.Query("level"); raw != "" {
+		if err := level.UnmarshalText([]byte(raw)); err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: "level must be debug, info, warn or error"})
+			return
+		}
+	}
+
+	tail, err := strconv.Atoi(c.DefaultQuery("tail", "0"))
+	if err != nil || tail < 0 {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "tail must be a non-negative number"})
+		return
+	}
+
+	if run.LogPath == "" {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Run has no log"})
+		return
+	}
+	lines, err := utils.ReadLog(run.LogPath, level, tail)
+	if err != nil {
+		if os.IsNotExist(err) {
+			c.JSON(http.StatusNotFound, ErrorResponse{Error: "Run log has been removed by retention"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to read run log"})
+		return
+	}
+
+	if c.Query("format") == "text" {
+		c.String(http.StatusOK, strings.Join(lines, "\n"))
+		return
+	}
+	if lines == nil {
+		lines = []string{}
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"run_id": run.ID,
+		"status": run.Status,
+		"lines":  lines,
+	})
+}
+
+// GetCrawlRunReport returns the report of a dry run: the articles it would create, update,
+// leave alone or tombstone, its extraction failures and unmatched category paths.
+// ?format=html downloads it as an HTML page instead of JSON.
+func (h *Handler) GetCrawlRunReport(c *gin.Context) {
+	run, ok := h.lookupRun(c)
+	if !ok {
+		return
+	}
+	if run.Options == nil || !run.Options.DryRun {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Run is not a dry run"})
+		return
+	}
+
+	report, err := h.store.GetRunReport(c.Request.Context(), run.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to fetch dry run report"})
+		return
+	}
+	if report == nil {
+		if run.Status == models.RunRunning {
+			c.JSON(http.StatusConflict, ErrorResponse{Error: "Dry run is still running"})
+			return
+		}
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Dry run has no report"})
+		return
+	}
+
+	if c.Query("format") == "html" {
+		c.Header("Content-Type", "text/html; charset=utf-8")
+		c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="dry-run-%s.html"`, run.ID))
+		if err := crawler.WriteReportHTML(c.Writer, report); err != nil {
+			log.Printf("Failed to render dry run report %s: %v", run.ID, err)
+		}
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
+
+func (h *Handler) lookupRun(c *gin.Context) (*models.CrawlRun, bool) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Invalid run ID"})
+		return nil, false
+	}
+
+	run, err := h.store.GetCrawlRun(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Failed to fetch run"})
+		return nil, false
+	}
+	if run == nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Run not found"})
+		return nil, false
+	}
+	return run, true
 }
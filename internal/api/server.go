@@ -4,11 +4,20 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/romangod6/kb-crawler/internal/blobstore"
+	"github.com/romangod6/kb-crawler/internal/events"
+	"github.com/romangod6/kb-crawler/internal/leader"
+	"github.com/romangod6/kb-crawler/internal/metrics"
+	"github.com/romangod6/kb-crawler/internal/queue"
+	"github.com/romangod6/kb-crawler/internal/scheduler"
 	"github.com/romangod6/kb-crawler/internal/storage"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
 )
 
 type Server struct {
@@ -17,7 +26,8 @@ type Server struct {
 	server *http.Server
 }
 
-func NewServer(port int, store storage.Store) *Server {
+func NewServer(port int, store storage.Store, blobs *blobstore.Store, bus *events.Bus, sched *scheduler.Scheduler,
+	jobQueue *queue.Queue, elector *leader.Elector) *Server {
 	router := gin.Default()
 
 	// Setup CORS
@@ -30,23 +40,43 @@ func NewServer(port int, store storage.Store) *Server {
 		MaxAge:           12 * time.Hour,
 	}))
 
+	// Record request latency by route and trace each request, continuing the caller's trace
+	// when the request carries a traceparent header. Crawl runs started by a request link back
+	// to its span.
+	router.Use(metricsMiddleware(), otelgin.Middleware("kb-crawler"))
+
+	// Prometheus scrape endpoint
+	router.GET("/metrics", gin.WrapH(promhttp.Handler()))
+
 	// Create handler
-	handler := NewHandler(store)
+	handler := NewHandler(store, blobs, bus, sched, jobQueue, elector)
 
 	// Setup routes
 	api := router.Group("/api")
 	{
-		// Health check
-		api.GET("/health", func(c *gin.Context) {
-			c.JSON(http.StatusOK, gin.H{"status": "healthy"})
-		})
+		// Health check, with the scheduler's leader
+		api.GET("/health", handler.GetHealth)
 
 		// Articles routes
 		articles := api.Group("/articles")
 		{
 			articles.GET("", handler.ListArticles)
 			articles.GET("/:id", handler.GetArticle)
+			articles.GET("/:id/outline", handler.GetArticleOutline)
+			articles.GET("/:id/assets", handler.ListArticleAssets)
+			articles.GET("/:id/links", handler.ListArticleLinks)
+			articles.GET("/:id/backlinks", handler.ListBacklinks)
+			articles.GET("/:id/versions", handler.ListArticleVersions)
+			articles.GET("/:id/diff", handler.DiffArticleVersions)
 			articles.GET("/search", handler.SearchArticles)
+			articles.GET("/low-confidence", handler.ListLowConfidenceArticles)
+		}
+
+		// Assets routes
+		assets := api.Group("/assets")
+		{
+			assets.GET("/:id", handler.GetAsset)
+			assets.GET("/:id/content", handler.GetAssetContent)
 		}
 
 		// Categories routes
@@ -57,12 +87,52 @@ func NewServer(port int, store storage.Store) *Server {
 			categories.GET("/:id/articles", handler.GetArticlesByCategory)
 		}
 
+		// Crawl run routes
+		runs := api.Group("/runs")
+		{
+			runs.GET("", handler.ListCrawlRuns)
+			runs.GET("/:id", handler.GetCrawlRun)
+			runs.GET("/:id/logs", handler.GetCrawlRunLogs)
+			runs.GET("/:id/report", handler.GetCrawlRunReport)
+		}
+
+		// Crawl job queue routes
+		jobs := api.Group("/jobs")
+		{
+			jobs.GET("", handler.ListCrawlJobs)
+			jobs.GET("/:id", handler.GetCrawlJob)
+			jobs.DELETE("/:id", handler.CancelCrawlJob)
+		}
+
+		// Upcoming scheduled runs
+		api.GET("/schedule", handler.GetSchedule)
+
+		// Duplicate report
+		api.GET("/duplicates", handler.ListDuplicates)
+
+		// Change feed and webhook routes
+		api.GET("/changes", handler.ListChanges)
+		webhooks := api.Group("/webhooks")
+		{
+			webhooks.GET("", handler.ListWebhooks)
+			webhooks.POST("", handler.CreateWebhook)
+			webhooks.DELETE("/:id", handler.DeleteWebhook)
+			webhooks.GET("/:id/deliveries", handler.ListWebhookDeliveries)
+			webhooks.POST("/:id/deliveries/:deliveryId/retry", handler.RetryWebhookDelivery)
+		}
+
 		// Crawler Config routes
 		crawlers := api.Group("/crawlers")
 		{
 			crawlers.GET("", handler.ListCrawlerConfigs)
 			crawlers.GET("/:id", handler.GetCrawlerConfig)
+			crawlers.GET("/:id/broken-links", handler.ListBrokenLinks)
+			crawlers.GET("/:id/events", handler.StreamCrawlerEvents)
 			crawlers.POST("", handler.CreateCrawlerConfig)
+			crawlers.POST("/validate", handler.ValidateCrawlerConfig)
+			crawlers.POST("/:id/enable", handler.EnableCrawlerConfig)
+			crawlers.POST("/:id/disable", handler.DisableCrawlerConfig)
+			crawlers.POST("/:id/run", handler.RunCrawler)
 			crawlers.PUT("/:id", handler.UpdateCrawlerConfig)
 			crawlers.DELETE("/:id", handler.DeleteCrawlerConfig)
 		}
@@ -74,6 +144,23 @@ func NewServer(port int, store storage.Store) *Server {
 	}
 }
 
+// metricsMiddleware records the latency of each request by method, route pattern and status.
+// Requests that match no route are grouped under "unmatched" so scans of random paths don't
+// create a series per path.
+func metricsMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		metrics.APIRequestDuration.WithLabelValues(c.Request.Method, route, strconv.Itoa(c.Writer.Status())).
+			Observe(time.Since(start).Seconds())
+	}
+}
+
 func (s *Server) Start() error {
 	s.server = &http.Server{
 		Addr:         fmt.Sprintf(":%d", s.port),
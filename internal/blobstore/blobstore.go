@@ -0,0 +1,86 @@
+// internal/blobstore/blobstore.go
+package blobstore
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// ErrTooLarge is returned by Put when the content exceeds the size limit.
+var ErrTooLarge = errors.New("blob exceeds size limit")
+
+// Store keeps blobs on the local file system, addressed by the SHA-256 of their content.
+// Blobs are laid out as <dir>/<ab>/<cd>/<hash> to keep directories small.
+type Store struct {
+	dir     string
+	maxSize int64
+}
+
+// New creates the blob directory if needed. A maxSize of zero or less means no limit.
+func New(dir string, maxSize int64) (*Store, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create blob directory: %w", err)
+	}
+	return &Store{dir: dir, maxSize: maxSize}, nil
+}
+
+// Put writes the content of r and returns its hash and size. Content that is already
+// stored is not written twice.
+func (s *Store) Put(r io.Reader) (string, int64, error) {
+	tmp, err := os.CreateTemp(s.dir, "upload-*")
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to create temp file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	// Read one byte past the limit so oversized content can be detected
+	if s.maxSize > 0 {
+		r = io.LimitReader(r, s.maxSize+1)
+	}
+
+	hash := sha256.New()
+	size, err := io.Copy(io.MultiWriter(tmp, hash), r)
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to write blob: %w", err)
+	}
+	if s.maxSize > 0 && size > s.maxSize {
+		return "", 0, ErrTooLarge
+	}
+	if err := tmp.Close(); err != nil {
+		return "", 0, fmt.Errorf("failed to write blob: %w", err)
+	}
+
+	sum := hex.EncodeToString(hash.Sum(nil))
+	path := s.Path(sum)
+	if _, err := os.Stat(path); err == nil {
+		return sum, size, nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return "", 0, fmt.Errorf("failed to create blob directory: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return "", 0, fmt.Errorf("failed to store blob: %w", err)
+	}
+
+	return sum, size, nil
+}
+
+// Open returns a reader for the blob with the given hash.
+func (s *Store) Open(hash string) (*os.File, error) {
+	return os.Open(s.Path(hash))
+}
+
+// Path returns where the blob with the given hash is stored.
+func (s *Store) Path(hash string) string {
+	if len(hash) < 4 {
+		return filepath.Join(s.dir, hash)
+	}
+	return filepath.Join(s.dir, hash[:2], hash[2:4], hash)
+}
@@ -0,0 +1,173 @@
+// internal/crawler/assets.go
+package crawler
+
+import (
+	"context"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/romangod6/kb-crawler/internal/blobstore"
+)
+
+// Asset is a file referenced from the main content of a page.
+type Asset struct {
+	URL      string
+	Kind     string
+	AltText  string
+	MIMEType string
+}
+
+// assetKinds maps file extensions of linked downloads to asset kinds.
+var assetKinds = map[string]string{
+	".pdf": "document", ".doc": "document", ".docx": "document", ".xls": "document", ".xlsx": "document",
+	".ppt": "document", ".pptx": "document", ".csv": "document", ".rtf": "document", ".txt": "document",
+	".zip": "archive", ".gz": "archive", ".tgz": "archive", ".7z": "archive", ".rar": "archive", ".tar": "archive",
+	".exe": "installer", ".msi": "installer", ".dmg": "installer", ".pkg": "installer",
+	".deb": "installer", ".rpm": "installer", ".apk": "installer",
+	".mp4": "video", ".webm": "video", ".mov": "video", ".m4v": "video",
+}
+
+// videoHosts are embed hosts whose iframes are recorded as video assets.
+var videoHosts = []string{"youtube.com", "youtube-nocookie.com", "youtu.be", "vimeo.com", "wistia.com", "wistia.net", "vidyard.com"}
+
+// extractAssets records the images, downloadable files and embedded videos in the main content,
+// with URLs resolved against the page URL. Each URL is listed once.
+func extractAssets(main *goquery.Selection, pageURL string) []Asset {
+	base, _ := url.Parse(pageURL)
+	seen := make(map[string]bool)
+	var assets []Asset
+
+	add := func(rawURL, kind, alt, mimeType string) {
+		rawURL = strings.TrimSpace(rawURL)
+		if rawURL == "" || strings.HasPrefix(rawURL, "data:") || strings.HasPrefix(rawURL, "javascript:") {
+			return
+		}
+		resolved := resolveURL(base, rawURL)
+		if seen[resolved] {
+			return
+		}
+		seen[resolved] = true
+
+		if mimeType == "" {
+			mimeType = mimeTypeFor(resolved)
+		}
+		assets = append(assets, Asset{
+			URL:      resolved,
+			Kind:     kind,
+			AltText:  strings.TrimSpace(alt),
+			MIMEType: mimeType,
+		})
+	}
+
+	main.Find("img").Each(func(_ int, s *goquery.Selection) {
+		src, _ := s.Attr("src")
+		if src == "" {
+			src, _ = s.Attr("data-src")
+		}
+		alt, _ := s.Attr("alt")
+		add(src, "image", alt, "")
+	})
+
+	main.Find("a[href]").Each(func(_ int, s *goquery.Selection) {
+		href, _ := s.Attr("href")
+		if kind, ok := assetKinds[extensionOf(href)]; ok {
+			add(href, kind, strings.Join(strings.Fields(s.Text()), " "), "")
+		}
+	})
+
+	main.Find("video[src], video source[src]").Each(func(_ int, s *goquery.Selection) {
+		src, _ := s.Attr("src")
+		mimeType, _ := s.Attr("type")
+		title, _ := s.Closest("video").Attr("title")
+		add(src, "video", title, mimeType)
+	})
+
+	main.Find("iframe[src]").Each(func(_ int, s *goquery.Selection) {
+		src, _ := s.Attr("src")
+		if u, err := url.Parse(resolveURL(base, src)); err == nil && isVideoHost(u.Hostname()) {
+			title, _ := s.Attr("title")
+			add(src, "video", title, "text/html")
+		}
+	})
+
+	return assets
+}
+
+// extensionOf returns the lowercase file extension of a URL path, ignoring query and fragment.
+func extensionOf(rawURL string) string {
+	if u, err := url.Parse(rawURL); err == nil {
+		rawURL = u.Path
+	}
+	return strings.ToLower(path.Ext(rawURL))
+}
+
+// mimeTypeFor guesses the MIME type of a URL from its extension.
+func mimeTypeFor(rawURL string) string {
+	mimeType := mime.TypeByExtension(extensionOf(rawURL))
+	if mediaType, _, err := mime.ParseMediaType(mimeType); err == nil {
+		return mediaType
+	}
+	return mimeType
+}
+
+func isVideoHost(host string) bool {
+	host = strings.ToLower(host)
+	for _, videoHost := range videoHosts {
+		if host == videoHost || strings.HasSuffix(host, "."+videoHost) {
+			return true
+		}
+	}
+	return false
+}
+
+// allowAssetURL reports whether an asset may be downloaded: it must be served over HTTP(S) from
+// one of the crawl's allowed domains, the page's own host when there are none, or one of the
+// configured asset hosts.
+func (c *Crawler) allowAssetURL(assetURL, pageURL string) bool {
+	u, err := url.Parse(assetURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return false
+	}
+	if c.isInternal(assetURL, pageURL) {
+		return true
+	}
+	for _, host := range c.config.AssetHosts {
+		if strings.EqualFold(host, u.Hostname()) {
+			return true
+		}
+	}
+	return false
+}
+
+// downloadAsset fetches an asset into the blob store and returns its hash, size and the
+// MIME type reported by the server.
+func downloadAsset(ctx context.Context, client *http.Client, userAgent, assetURL string, blobs *blobstore.Store) (string, int64, string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, assetURL, nil)
+	if err != nil {
+		return "", 0, "", err
+	}
+	req.Header.Set("User-Agent", userAgent)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", 0, "", fmt.Errorf("failed to fetch asset: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", 0, "", fmt.Errorf("asset fetch returned status: %s", resp.Status)
+	}
+
+	hash, size, err := blobs.Put(resp.Body)
+	if err != nil {
+		return "", 0, "", err
+	}
+
+	mimeType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	return hash, size, mimeType, nil
+}
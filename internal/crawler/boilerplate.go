@@ -0,0 +1,134 @@
+// internal/crawler/boilerplate.go
+package crawler
+
+import (
+	"math"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"golang.org/x/net/html"
+)
+
+// blockSelector matches the containers considered when looking for the densest text block.
+const blockSelector = "div, section, article, main, td"
+
+// textSelector matches the elements whose text contributes to the score of their containers.
+const textSelector = "p, pre, li, dd, td, h1, h2, h3, h4, h5, h6, blockquote"
+
+// selectMainContent returns the first element matching one of the profile's content selectors,
+// falling back to the body. The boolean reports whether a content selector matched.
+func selectMainContent(doc *goquery.Document, profile *Profile) (*goquery.Selection, bool) {
+	for _, selector := range profile.ContentSelectors {
+		if main := doc.Find(selector).First(); main.Length() > 0 {
+			return main, true
+		}
+	}
+	return doc.Find("body").First(), false
+}
+
+// removeBoilerplate strips page chrome from the main content and, when no content selector
+// matched, narrows it down to the block with the highest text density. It returns the
+// selection to store along with a confidence score between 0 and 1.
+func removeBoilerplate(main *goquery.Selection, profile *Profile, matched bool) (*goquery.Selection, float64) {
+	// Remove everything the profile knows to be chrome
+	for _, selector := range profile.StripSelectors {
+		main.Find(selector).Remove()
+	}
+
+	foundBlock := false
+	if !matched {
+		if block := densestBlock(main); block != nil {
+			main = block
+			foundBlock = true
+		}
+	}
+
+	pruneLinkClusters(main)
+
+	// Score the extraction: trust a profile selector most, then how much prose is left and
+	// how little of it is link text
+	selectorScore := 0.2
+	if matched {
+		selectorScore = 1
+	} else if foundBlock {
+		selectorScore = 0.6
+	}
+
+	textLen, linkLen := textAndLinkLength(main)
+	lengthScore := math.Min(1, float64(textLen)/1500)
+	densityScore := 0.0
+	if textLen > 0 {
+		densityScore = 1 - math.Min(1, 2*float64(linkLen)/float64(textLen))
+	}
+
+	confidence := 0.4*selectorScore + 0.3*lengthScore + 0.3*densityScore
+	return main, math.Round(confidence*100) / 100
+}
+
+// densestBlock scores containers the way readability does: every paragraph-like element adds
+// to its parent and, at half weight, to its grandparent, and the total is discounted by link
+// density. It returns nil when there is no text to score.
+func densestBlock(main *goquery.Selection) *goquery.Selection {
+	scores := make(map[*html.Node]float64)
+
+	main.Find(textSelector).Each(func(_ int, s *goquery.Selection) {
+		text := strings.TrimSpace(s.Text())
+		if len(text) < 25 {
+			return
+		}
+
+		score := 1 + float64(strings.Count(text, ",")) + math.Min(3, float64(len(text))/100)
+
+		parent := s.Parent()
+		if parent.Length() > 0 && parent.Is(blockSelector) {
+			scores[parent.Get(0)] += score
+		}
+		if grandparent := parent.Parent(); grandparent.Length() > 0 && grandparent.Is(blockSelector) {
+			scores[grandparent.Get(0)] += score / 2
+		}
+	})
+
+	var best *html.Node
+	bestScore := 0.0
+	for node, score := range scores {
+		textLen, linkLen := textAndLinkLength(goquery.NewDocumentFromNode(node).Selection)
+		if textLen > 0 {
+			score *= 1 - float64(linkLen)/float64(textLen)
+		}
+		if score > bestScore {
+			best, bestScore = node, score
+		}
+	}
+
+	if best == nil {
+		return nil
+	}
+	return main.FindNodes(best)
+}
+
+// pruneLinkClusters drops containers that are mostly links with little prose, such as
+// "related topics" lists and footer link blocks that no strip selector caught.
+func pruneLinkClusters(main *goquery.Selection) {
+	main.Find("div, section, ul, ol, table").Each(func(_ int, s *goquery.Selection) {
+		if s.Find("a").Length() < 3 {
+			return
+		}
+		textLen, linkLen := textAndLinkLength(s)
+		if textLen < 200 && float64(linkLen) > 0.6*float64(textLen) {
+			s.Remove()
+		}
+	})
+}
+
+// textAndLinkLength returns the length of the whitespace-normalized text of the selection
+// and of the part of it that sits inside links.
+func textAndLinkLength(s *goquery.Selection) (int, int) {
+	textLen := len(strings.Join(strings.Fields(s.Text()), " "))
+
+	linkLen := 0
+	s.Find("a").Each(func(_ int, a *goquery.Selection) {
+		linkLen += len(strings.Join(strings.Fields(a.Text()), " "))
+	})
+
+	return textLen, linkLen
+}
@@ -0,0 +1,105 @@
+package crawler
+
+import (
+	"strings"
+	"testing"
+)
+
+// prose is a paragraph of article text long enough to count as content.
+const prose = `Reset a user's password from the admin console, then ask the user to sign in again
+with the temporary password, which expires after one hour, and choose a new one of their own.`
+
+func TestRemoveBoilerplate(t *testing.T) {
+	madcap, _ := GetProfile("madcap")
+	defaultProfile, _ := GetProfile("default")
+
+	tests := []struct {
+		name          string
+		profile       *Profile
+		html          string
+		keep          []string
+		drop          []string
+		minConfidence float64
+		maxConfidence float64
+	}{
+		{
+			name:    "profile selector and chrome",
+			profile: madcap,
+			html: `<html><body>
+				<nav>Home Products Support</nav>
+				<div class="sidenav"><a href="/a">Getting started</a></div>
+				<div id="mc-main-content">
+					<h1>Reset a password</h1>
+					<div class="MCBreadcrumbsBox_0">Admin &gt; Users</div>
+					<p>` + prose + `</p><p>` + prose + `</p>
+					<div class="was-this-helpful">Was this helpful? Yes No</div>
+				</div>
+				<div id="cookie-banner">We use cookies</div>
+				<footer>Copyright</footer>
+			</body></html>`,
+			keep:          []string{"Reset a password", "temporary password"},
+			drop:          []string{"Home Products", "Getting started", "Admin &gt; Users", "Was this helpful", "We use cookies", "Copyright"},
+			minConfidence: 0.7,
+			maxConfidence: 1,
+		},
+		{
+			name:    "densest block without a selector",
+			profile: defaultProfile,
+			html: `<html><body>
+				<div class="menu"><p>Products, Pricing, Partners, Careers and the company blog</p></div>
+				<div class="content">
+					<div class="topic"><p>` + prose + `</p><p>` + prose + `</p><p>` + prose + `</p></div>
+				</div>
+			</body></html>`,
+			keep:          []string{"temporary password"},
+			drop:          []string{"Pricing"},
+			minConfidence: 0.5,
+			maxConfidence: 0.8,
+		},
+		{
+			name:    "link clusters pruned",
+			profile: defaultProfile,
+			html: `<html><body><article>
+				<p>` + prose + `</p>
+				<ul class="related">
+					<li><a href="/a">Add a user</a></li>
+					<li><a href="/b">Remove a user</a></li>
+					<li><a href="/c">Change a role</a></li>
+				</ul>
+			</article></body></html>`,
+			keep:          []string{"temporary password"},
+			drop:          []string{"Add a user", "Change a role"},
+			minConfidence: 0.7,
+			maxConfidence: 1,
+		},
+		{
+			name:          "page chrome only",
+			profile:       defaultProfile,
+			html:          `<html><body><div><a href="/a">Home</a> <a href="/b">Products</a> <a href="/c">Support</a></div></body></html>`,
+			drop:          []string{"Home", "Products"},
+			minConfidence: 0,
+			maxConfidence: 0.3,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parsed, err := ParseHTMLContent(tt.html, ParseOptions{PageURL: "https://kb.example.com/a", Profile: tt.profile})
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, text := range tt.keep {
+				if !strings.Contains(parsed.Content, text) {
+					t.Errorf("content is missing %q:\n%s", text, parsed.Content)
+				}
+			}
+			for _, text := range tt.drop {
+				if strings.Contains(parsed.Content, text) {
+					t.Errorf("content still has %q:\n%s", text, parsed.Content)
+				}
+			}
+			if parsed.Confidence < tt.minConfidence || parsed.Confidence > tt.maxConfidence {
+				t.Errorf("confidence = %.2f, want between %.2f and %.2f", parsed.Confidence, tt.minConfidence, tt.maxConfidence)
+			}
+		})
+	}
+}
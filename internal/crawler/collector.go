@@ -6,36 +6,176 @@ import (
 	"crypto/tls"
 	"encoding/json"
 	"encoding/xml"
+	"errors"
 	"fmt"
 	"io"
-	"log"
+	"log/slog"
 	"net/http"
+	"sort"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/gocolly/colly/v2"
 	"github.com/google/uuid"
+	"github.com/romangod6/kb-crawler/internal/blobstore"
+	"github.com/romangod6/kb-crawler/internal/dedupe"
+	"github.com/romangod6/kb-crawler/internal/events"
+	"github.com/romangod6/kb-crawler/internal/metrics"
 	"github.com/romangod6/kb-crawler/internal/models"
 	"github.com/romangod6/kb-crawler/internal/storage"
-	"github.com/romangod6/kb-crawler/internal/utils"
+	"github.com/romangod6/kb-crawler/internal/tracing"
+	"github.com/romangod6/kb-crawler/internal/urlnorm"
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // Crawler represents the web crawler with its dependencies.
 type Crawler struct {
-	collector *colly.Collector
-	store     storage.Store
-	config    *CrawlerConfig
+	collector  *colly.Collector
+	store      storage.Store
+	log        *slog.Logger
+	config     *CrawlerConfig
+	profile    *Profile
+	httpClient *http.Client
+	// assetClient downloads assets, verifying TLS certificates and following redirects only
+	// to hosts assets may be downloaded from
+	assetClient *http.Client
+
+	// fetchStatus holds the HTTP status of every page fetched in this crawl, by normalized URL
+	// and, for the pages turned into articles, by the article's canonical URL too
+	fetchStatus map[string]int
+	statusMu    sync.Mutex
+
+	// sitemapURLs is the normalized sitemap of the current crawl; queued tracks every URL
+	// visited so far, including pages found by following links
+	sitemapURLs map[string]bool
+	queued      map[string]bool
+	queueMu     sync.Mutex
+
+	// filters decide which sitemap URLs and discovered links are queued at all, including the
+	// discovery scope patterns; filtered counts the rejected URLs by rule, guarded by queueMu
+	filters  *URLFilterSet
+	rejected map[string]bool
+	filtered map[string]int
+	// unmodified counts the sitemap URLs an incremental crawl skipped, guarded by queueMu
+	unmodified int
+
+	// saved holds the URLs of the articles stored this crawl, guarded by queueMu
+	saved      map[string]bool
+	tombstoned int
+
+	// duplicates matches articles to canonical copies under the link policy; nil otherwise.
+	// linkedDuplicates is guarded by queueMu
+	duplicates       *dedupe.Index
+	linkedDuplicates int
+
+	// Progress counts for live events, guarded by queueMu
+	startedAt  time.Time
+	fetched    int
+	savedCount int
+	failed     int
+
+	// pageSpans holds the trace span of each page in flight, by colly request ID
+	pageSpans sync.Map
+
+	// worker is set when the crawler fetches pages claimed from a distributed run
+	worker bool
+
+	// report collects what a dry run would change; nil unless the crawl is a dry run.
+	// replayed counts the pages served from the page cache
+	report   *dryRunReport
+	replayed atomic.Int64
 }
 
 // CrawlerConfig holds the configuration parameters for the crawler.
 type CrawlerConfig struct {
+	ConfigID        uuid.UUID
 	SitemapURL      string
 	MapURL          string
 	UserAgent       string
 	MaxDepth        int
 	DefaultCategory string
 	AllowedDomains  []string
+	Profile         string
+	DownloadAssets  bool
+	// FollowLinks enables discovery mode: in-scope links are followed up to MaxDepth
+	FollowLinks     bool
+	IncludePatterns []string
+	ExcludePatterns []string
+	// URLFilters are applied in order to every URL before it is queued
+	URLFilters []models.URLFilter
+	// DuplicatePolicy "link" stores articles that duplicate an existing one as links to it
+	DuplicatePolicy string
+	// AssetStore receives downloaded assets; downloads are skipped when it is nil
+	AssetStore *blobstore.Store
+	// AssetHosts are hosts assets may be downloaded from besides the allowed domains, such as
+	// the knowledge base's CDN
+	AssetHosts []string
+	// Notifier is told about article changes; may be nil
+	Notifier ChangeNotifier
+	// Logger is the run's logger, carrying its run and config IDs; slog.Default() when nil
+	Logger *slog.Logger
+	// RunID identifies the run in published events
+	RunID uuid.UUID
+	// Events receives live progress events; may be nil
+	Events *events.Bus
+	// Distributed hands the pages to worker processes instead of fetching them here; nil
+	// crawls locally
+	Distributed *DistributedOptions
+	// URLs limits the crawl to these pages; the sitemap is still read to check links and
+	// tombstones against
+	URLs []string
+	// ModifiedSince makes the crawl incremental: stored pages whose sitemap lastmod is not
+	// after it are skipped
+	ModifiedSince *time.Time
+	// DryRun fetches and extracts pages without writing categories, articles, links or
+	// tombstones, and reports what would have changed
+	DryRun bool
+	// PageCache keeps a copy of each page fetched by crawls that are not dry runs; may be nil
+	PageCache *PageCache
+	// Replay serves pages from PageCache where it has them instead of fetching them
+	Replay bool
+}
+
+// ChangeNotifier is told about the article changes recorded during a crawl.
+type ChangeNotifier interface {
+	Notify(ctx context.Context, changes ...*models.ArticleChange)
+}
+
+// ConfigFromModel builds the crawler configuration for a stored crawler config.
+func ConfigFromModel(config models.CrawlerConfig) *CrawlerConfig {
+	return &CrawlerConfig{
+		ConfigID:        config.ID,
+		SitemapURL:      config.SitemapURL,
+		MapURL:          config.MapURL,
+		UserAgent:       config.UserAgent,
+		MaxDepth:        config.MaxDepth,
+		AllowedDomains:  config.AllowedDomains,
+		DefaultCategory: config.DefaultCategory,
+		Profile:         config.Profile,
+		DownloadAssets:  config.DownloadAssets,
+		FollowLinks:     config.FollowLinks,
+		IncludePatterns: config.IncludePatterns,
+		ExcludePatterns: config.ExcludePatterns,
+		URLFilters:      config.URLFilters,
+		DuplicatePolicy: config.DuplicatePolicy,
+	}
+}
+
+// RunConfig builds the crawler configuration of a run of a stored crawler config with the
+// overrides it was triggered with.
+func RunConfig(config models.CrawlerConfig, opts models.RunOptions) *CrawlerConfig {
+	crawlerConfig := ConfigFromModel(config)
+	crawlerConfig.URLs = opts.URLs
+	crawlerConfig.DryRun = opts.DryRun
+	crawlerConfig.Replay = opts.Replay
+	if opts.Mode == models.RunModeIncremental {
+		crawlerConfig.ModifiedSince = config.LastRun
+	}
+	return crawlerConfig
 }
 
 // CategoryStructure holds the mapped categories with thread-safe access.
@@ -66,6 +206,18 @@ func (cs *CategoryStructure) GetCategory(path string) (*models.Category, bool) {
 	return cat, exists
 }
 
+// Paths returns the category paths in the structure, sorted.
+func (cs *CategoryStructure) Paths() []string {
+	cs.mutex.RLock()
+	defer cs.mutex.RUnlock()
+	paths := make([]string, 0, len(cs.categories))
+	for path := range cs.categories {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
 // ContextKey is a type for context keys to avoid collisions.
 type ContextKey string
 
@@ -73,7 +225,10 @@ const tagsContextKey = "crawler_product_feature_tags"
 
 // NewCrawler initializes and returns a new Crawler instance.
 func NewCrawler(store storage.Store, config *CrawlerConfig) *Crawler {
-	logger, _ := utils.NewCrawlerLogger(config.DefaultCategory)
+	logger := config.Logger
+	if logger == nil {
+		logger = slog.Default()
+	}
 
 	// Create collector with extended configuration
 	c := colly.NewCollector(
@@ -85,26 +240,26 @@ func NewCrawler(store storage.Store, config *CrawlerConfig) *Crawler {
 	)
 
 	// Configure transport
-	c.WithTransport(&http.Transport{
+	transport := &http.Transport{
 		DisableKeepAlives: true,
 		TLSClientConfig: &tls.Config{
 			InsecureSkipVerify: true,
 		},
-	})
+	}
 
 	// Set timeouts
 	c.SetRequestTimeout(30 * time.Second)
 
 	// Debug callback to see what we're receiving
 	c.OnResponse(func(r *colly.Response) {
-		logger.LogDebug("Raw HTML preview for %s:\n%s", r.Request.URL, string(r.Body[:min(1000, len(r.Body))]))
+		logger.Debug("Raw HTML preview", "url", r.Request.URL.String(), "body", string(r.Body[:min(1000, len(r.Body))]))
 	})
 
 	// Error handling
 	c.OnError(func(r *colly.Response, err error) {
-		logger.LogError("Error on %v: %v", r.Request.URL, err)
+		logger.Error("Request failed", "url", r.Request.URL.String(), "status", r.StatusCode, "error", err)
 		if r != nil {
-			logger.LogError("Response headers: %v", r.Headers)
+			logger.Debug("Failed response headers", "url", r.Request.URL.String(), "headers", r.Headers)
 		}
 	})
 
@@ -115,17 +270,85 @@ func NewCrawler(store storage.Store, config *CrawlerConfig) *Crawler {
 		Parallelism: 2,
 	})
 
-	return &Crawler{
-		collector: c,
-		store:     store,
-		config:    config,
+	// Resolve the extraction profile, falling back to the default for unknown names
+	profile, ok := GetProfile(config.Profile)
+	if !ok {
+		logger.Error("Unknown extraction profile, using the default", "profile", config.Profile, "default", DefaultProfile)
+		profile, _ = GetProfile(DefaultProfile)
 	}
+
+	crawler := &Crawler{
+		collector:   c,
+		store:       store,
+		log:         logger,
+		config:      config,
+		profile:     profile,
+		httpClient:  &http.Client{Transport: transport, Timeout: 2 * time.Minute},
+		fetchStatus: make(map[string]int),
+		sitemapURLs: make(map[string]bool),
+		queued:      make(map[string]bool),
+		rejected:    make(map[string]bool),
+		filtered:    make(map[string]int),
+		saved:       make(map[string]bool),
+	}
+	crawler.assetClient = &http.Client{
+		Timeout: 2 * time.Minute,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= 10 {
+				return errors.New("stopped after 10 redirects")
+			}
+			if !crawler.allowAssetURL(req.URL.String(), via[0].URL.String()) {
+				return fmt.Errorf("redirect to %s, which assets may not be downloaded from", req.URL.Host)
+			}
+			return nil
+		},
+	}
+	if config.DryRun {
+		crawler.report = newDryRunReport()
+	}
+
+	// Replays are answered from the page cache ahead of timing, so they don't count as fetches
+	var fetcher http.RoundTripper = timedTransport{transport}
+	if config.Replay && config.PageCache != nil {
+		fetcher = replayTransport{RoundTripper: fetcher, cache: config.PageCache, replayed: &crawler.replayed}
+	}
+	c.WithTransport(fetcher)
+
+	// Compile the filters and discovery scope. Configs are validated when saved, so a bad rule
+	// or pattern here only comes from an older config.
+	if filters, err := NewURLFilterSet(config.URLFilters, config.IncludePatterns, config.ExcludePatterns); err != nil {
+		logger.Error("Ignoring URL filters", "error", err)
+	} else {
+		crawler.filters = filters
+	}
+
+	// Remember page statuses for the link checker and count fetches for metrics
+	c.OnResponse(func(r *colly.Response) {
+		recordFetch(r)
+		crawler.recordStatus(urlnorm.Normalize(r.Request.URL.String()), r.StatusCode)
+		crawler.cachePage(r)
+	})
+	c.OnError(func(r *colly.Response, err error) {
+		if r == nil {
+			return
+		}
+		recordFetch(r)
+		if r.StatusCode != 0 {
+			crawler.recordStatus(urlnorm.Normalize(r.Request.URL.String()), r.StatusCode)
+		}
+	})
+
+	return crawler
 }
 
 // MapCategoryStructure maps the category structure from the MapURL.
 func (c *Crawler) MapCategoryStructure(ctx context.Context) (*CategoryStructure, error) {
-	logger, _ := utils.NewCrawlerLogger(c.config.DefaultCategory)
+	logger := c.log.With("map_url", c.config.MapURL)
 	cs := NewCategoryStructure()
+	c.emit(events.Event{Type: events.MappingStarted, URL: c.config.MapURL})
+
+	ctx, span := tracer.Start(ctx, "crawl.map", trace.WithAttributes(semconv.URLFull(c.config.MapURL)))
+	defer span.End()
 
 	// Create root category
 	rootCat := &models.Category{
@@ -136,9 +359,12 @@ func (c *Crawler) MapCategoryStructure(ctx context.Context) (*CategoryStructure,
 		UpdatedAt:   time.Now(),
 	}
 
-	if err := c.store.CreateCategory(ctx, rootCat); err != nil {
-		logger.LogError("Failed to create root category: %v", err)
-		return nil, fmt.Errorf("failed to create root category: %w", err)
+	if err := c.createCategory(ctx, rootCat); err != nil {
+		logger.Error("Failed to create root category", "error", err)
+		err = fmt.Errorf("failed to create root category: %w", err)
+		tracing.RecordError(span, err)
+		c.emitMappingFailed(err)
+		return nil, err
 	}
 
 	cs.AddCategory(c.config.DefaultCategory, rootCat)
@@ -163,12 +389,11 @@ func (c *Crawler) MapCategoryStructure(ctx context.Context) (*CategoryStructure,
 
 	// Debug response
 	mapper.OnResponse(func(r *colly.Response) {
-		logger.LogDebug("Mapping response from %s", r.Request.URL)
-		logger.LogDebug("Response body preview: %s", string(r.Body[:min(1000, len(r.Body))]))
+		logger.Debug("Mapping response", "url", r.Request.URL.String(), "body", string(r.Body[:min(1000, len(r.Body))]))
 	})
 
 	mapper.OnHTML("nav.sidebarNav", func(e *colly.HTMLElement) {
-		logger.LogInfo("Found navigation structure")
+		logger.Info("Found navigation structure")
 
 		e.ForEach("li", func(_ int, el *colly.HTMLElement) {
 			text := strings.TrimSpace(el.Text)
@@ -182,7 +407,7 @@ func (c *Crawler) MapCategoryStructure(ctx context.Context) (*CategoryStructure,
 
 			// Traverse up to find parent categories
 			current := el.DOM
-			for parent := current.Parent(); parent != nil; parent = parent.Parent() {
+			for parent := current.Parent(); parent.Length() > 0; parent = parent.Parent() {
 				// Check if this parent is a list item
 				if parent.Is("li") {
 					parentText := strings.TrimSpace(parent.Text())
@@ -195,7 +420,7 @@ func (c *Crawler) MapCategoryStructure(ctx context.Context) (*CategoryStructure,
 			pathParts = append(pathParts, text)
 			categoryPath := strings.Join(pathParts, ":")
 
-			logger.LogInfo("Found category: %s", categoryPath)
+			logger.Debug("Found category", "category", categoryPath)
 
 			// Create category
 			cat := &models.Category{
@@ -207,27 +432,32 @@ func (c *Crawler) MapCategoryStructure(ctx context.Context) (*CategoryStructure,
 				UpdatedAt:   time.Now(),
 			}
 
-			if err := c.store.CreateCategory(ctx, cat); err != nil {
-				logger.LogError("Error creating category %s: %v", categoryPath, err)
+			if err := c.createCategory(ctx, cat); err != nil {
+				logger.Error("Error creating category", "category", categoryPath, "error", err)
 				return
 			}
 
 			cs.AddCategory(categoryPath, cat)
-			logger.LogInfo("Added category to structure: %s", categoryPath)
+			logger.Info("Added category to structure", "category", categoryPath)
 		})
 	})
 
 	// Visit the map URL
-	logger.LogInfo("Visiting map URL: %s", c.config.MapURL)
+	logger.Info("Visiting map URL")
 	if err := mapper.Visit(c.config.MapURL); err != nil {
-		logger.LogError("Failed to visit map URL: %v", err)
-		return nil, fmt.Errorf("failed to map structure: %w", err)
+		logger.Error("Failed to visit map URL", "error", err)
+		err = fmt.Errorf("failed to map structure: %w", err)
+		tracing.RecordError(span, err)
+		c.emitMappingFailed(err)
+		return nil, err
 	}
 
 	// Wait for async operations to finish
 	mapper.Wait()
 
-	logger.LogInfo("Category mapping completed. Total categories: %d", len(cs.categories))
+	logger.Info("Category mapping completed", "categories", len(cs.categories))
+	span.SetAttributes(attribute.Int("crawl.categories", len(cs.categories)))
+	c.emit(events.Event{Type: events.MappingFinished, Count: len(cs.categories)})
 	return cs, nil
 }
 
@@ -236,60 +466,83 @@ func (c *Crawler) Crawl(ctx context.Context, cs *CategoryStructure) error {
 	// Setup content handlers first
 	c.setupHandlers(cs)
 
-	logger, err := utils.NewCrawlerLogger(c.config.DefaultCategory)
-	if err != nil {
-		log.Printf("Failed to create logger: %v", err)
-	} else {
-		defer logger.Close()
-	}
-
-	logMsg := func(level string, format string, v ...interface{}) {
-		if logger != nil {
-			switch level {
-			case "error":
-				logger.LogError(format, v...)
-			case "debug":
-				logger.LogDebug(format, v...)
-			default:
-				logger.LogInfo(format, v...)
-			}
-		} else {
-			log.Printf(format, v...)
-		}
-	}
-
-	// Add collector callbacks for logging
-	c.collector.OnRequest(func(r *colly.Request) {
-		logMsg("info", "Visiting: %s", r.URL.String())
-	})
+	logger := c.log.With("sitemap_url", c.config.SitemapURL)
+	defer metrics.QueueDepth.DeleteLabelValues(c.config.ConfigID.String())
 
-	c.collector.OnError(func(r *colly.Response, err error) {
-		logMsg("error", "Error visiting %s: %v", r.Request.URL.String(), err)
-	})
+	c.queueMu.Lock()
+	c.startedAt = time.Now()
+	c.queueMu.Unlock()
 
-	c.collector.OnResponse(func(r *colly.Response) {
-		logMsg("info", "Received response from %s: Status %d", r.Request.URL.String(), r.StatusCode)
-	})
+	if c.config.Distributed == nil {
+		c.setupCallbacks(ctx, logger)
+	}
 
 	// Parse sitemap
 	sitemap, err := parseSitemap(c.config.SitemapURL)
 	if err != nil {
-		logMsg("error", "Failed to parse sitemap: %v", err)
+		logger.Error("Failed to parse sitemap", "error", err)
+		c.emitFinished(models.RunFailed, err)
 		return err
 	}
 
-	logMsg("info", "Successfully parsed sitemap, found %d URLs", len(sitemap.URLs))
+	logger.Info("Parsed sitemap", "urls", len(sitemap.URLs))
+
+	// Normalize sitemap URLs so variants of the same page are only visited once
+	urls := normalizeSitemapURLs(sitemap)
+	if skipped := len(sitemap.URLs) - len(urls); skipped > 0 {
+		logger.Info("Skipped duplicate sitemap URLs after normalization", "skipped", skipped)
+	}
+
+	for _, url := range urls {
+		c.sitemapURLs[url] = true
+	}
+	urls = c.selectURLs(ctx, sitemap, urls, logger)
+
+	// Dry runs compare what they extract against the config's stored articles
+	if err := c.report.loadStored(ctx, c); err != nil {
+		logger.Error("Failed to load stored articles for the dry run report", "error", err)
+		c.emitFinished(models.RunFailed, err)
+		return err
+	}
+
+	if c.config.DuplicatePolicy == models.DuplicatePolicyLink {
+		if err := c.loadDuplicateIndex(ctx); err != nil {
+			logger.Error("Failed to load article fingerprints, duplicates will be stored in full", "error", err)
+		}
+	}
+
+	// Hand the pages to the workers of a distributed crawl and wait for them
+	if c.config.Distributed != nil {
+		if err := c.coordinate(ctx, urls, cs, logger); err != nil {
+			status := models.RunFailed
+			if errors.Is(err, context.Canceled) {
+				status = models.RunCancelled
+			}
+			c.emitFinished(status, err)
+			return err
+		}
+		return c.finish(ctx, logger)
+	}
 
 	// Visit each URL from sitemap
-	for idx, url := range sitemap.URLs {
+	for idx, url := range urls {
 		select {
 		case <-ctx.Done():
-			logMsg("info", "Context cancelled, stopping crawl")
+			logger.Info("Context cancelled, stopping crawl")
+			c.collector.Wait()
+			c.emitFinished(models.RunCancelled, ctx.Err())
 			return ctx.Err()
 		default:
-			logMsg("info", "Processing URL %d/%d: %s", idx+1, len(sitemap.URLs), url.Loc)
-			if err := c.collector.Visit(url.Loc); err != nil {
-				logMsg("error", "Error visiting %s: %v", url.Loc, err)
+			if !c.allowURL(url) {
+				logger.Debug("Filtered URL", "url", url, "index", idx+1, "total", len(urls))
+				continue
+			}
+			logger.Debug("Queueing URL", "url", url, "index", idx+1, "total", len(urls))
+			if !c.markQueued(url) {
+				continue
+			}
+			if err := c.collector.Visit(url); err != nil {
+				logger.Error("Error visiting URL", "url", url, "error", err)
 			}
 		}
 	}
@@ -297,19 +550,92 @@ func (c *Crawler) Crawl(ctx context.Context, cs *CategoryStructure) error {
 	// Wait for async operations to finish
 	c.collector.Wait()
 
-	logMsg("info", "Crawl completed successfully")
+	return c.finish(ctx, logger)
+}
+
+// setupCallbacks adds the collector callbacks for logging, tracing and live progress. Failed
+// requests are logged by NewCrawler.
+func (c *Crawler) setupCallbacks(ctx context.Context, logger *slog.Logger) {
+	c.collector.OnRequest(func(r *colly.Request) {
+		logger.Info("Visiting", "url", r.URL.String(), "depth", r.Depth)
+		c.startPageSpan(ctx, r)
+		c.emit(events.Event{Type: events.URLRequested, URL: r.URL.String()})
+	})
+
+	c.collector.OnResponse(func(r *colly.Response) {
+		logger.Info("Received response", "url", r.Request.URL.String(), "status", r.StatusCode)
+		c.pageSpan(r.Request).SetAttributes(semconv.HTTPResponseStatusCode(r.StatusCode))
+		c.countFetched()
+		c.emit(events.Event{Type: events.URLFetched, URL: r.Request.URL.String(), StatusCode: r.StatusCode})
+		c.emitProgress()
+	})
+
+	c.collector.OnScraped(func(r *colly.Response) {
+		c.endPageSpan(r.Request, nil)
+	})
+
+	c.collector.OnError(func(r *colly.Response, err error) {
+		c.endPageSpan(r.Request, err)
+		c.countFailed()
+		c.report.failure(r.Request.URL.String(), models.FailureFetch, r.StatusCode, err.Error())
+		c.emit(events.Event{Type: events.URLFailed, URL: r.Request.URL.String(), StatusCode: r.StatusCode, Error: err.Error()})
+		c.emitProgress()
+	})
+}
+
+// finish checks links and tombstones vanished articles once every page has been fetched, then
+// logs the summary and publishes the end of the run.
+func (c *Crawler) finish(ctx context.Context, logger *slog.Logger) error {
+	// Check internal links against the sitemap and the statuses seen during the crawl
+	if c.config.ConfigID != uuid.Nil && c.config.DryRun && len(c.sitemapURLs) > 0 {
+		// Report the articles a real run would tombstone
+		c.report.deleted(c.missingArticles(c.report.storedRefs()))
+	}
+	if c.config.ConfigID != uuid.Nil && !c.config.DryRun {
+		logger.Info("Checking internal links")
+		if err := c.checkLinks(ctx); err != nil {
+			logger.Error("Failed to check links", "error", err)
+		}
+
+		// Tombstone articles whose pages vanished, unless the sitemap came back empty and
+		// everything would look deleted
+		if len(c.sitemapURLs) > 0 {
+			tombstoned, err := c.tombstoneMissing(ctx)
+			if err != nil {
+				logger.Error("Failed to tombstone deleted pages", "error", err)
+			}
+			c.tombstoned = tombstoned
+			logger.Info("Tombstoned articles whose pages were deleted", "articles", tombstoned)
+		}
+	}
+
+	summary := c.Summary()
+	logger.Info("Crawl completed",
+		"queued", summary.QueuedURLs,
+		"sitemap_urls", summary.SitemapURLs,
+		"filtered", summary.FilteredURLs,
+		"unmodified", summary.UnmodifiedURLs,
+		"tombstoned", summary.TombstonedArticles,
+		"linked_duplicates", summary.LinkedDuplicates)
+	for _, f := range summary.Filtered {
+		logger.Info("URLs filtered by rule", "rule", f.Rule, "count", f.Count)
+	}
+
+	c.emitFinished(models.RunCompleted, nil)
 	return nil
 }
 
 const TagsContextKey = "crawler_product_feature_tags"
 
+// lowConfidenceThreshold is the content confidence below which an extraction is flagged in the log.
+const lowConfidenceThreshold = 0.5
+
 // setupHandlers sets up the HTML handlers for the collector.
 func (c *Crawler) setupHandlers(cs *CategoryStructure) {
-	logger, _ := utils.NewCrawlerLogger(c.config.DefaultCategory)
-
 	// Handler for the <head> section to extract meta tags
 	c.collector.OnHTML("head", func(e *colly.HTMLElement) {
-		logger.LogDebug("Processing <head> section for meta tags")
+		logger := c.log.With("url", e.Request.URL.String())
+		logger.Debug("Processing <head> section for meta tags")
 		var tags []string
 
 		// Extract ProductFeatureTags using parser.go's function
@@ -319,113 +645,316 @@ func (c *Crawler) setupHandlers(cs *CategoryStructure) {
 		e.Request.Ctx.Put("tags", tags)
 	})
 
-	// Handler for main content selectors
-	pageHandlers := []string{"#mc-main-content", "div[role='main']", "body"}
-	for _, selector := range pageHandlers {
-		c.collector.OnHTML(selector, func(e *colly.HTMLElement) {
-			logger.LogDebug("Processing selector: %s", selector)
+	if c.config.FollowLinks {
+		c.setupDiscovery()
+	}
+
+	// Handler for the page content. The extraction profile decides which part of the page is
+	// the article, so the whole document is handed to the parser once per page.
+	c.collector.OnHTML("html", func(e *colly.HTMLElement) {
+		logger := c.log.With("url", e.Request.URL.String())
+		logger.Debug("Processing page", "profile", c.profile.Name)
+		ctx := c.pageContext(e.Request)
+
+		// Initialize empty tags slice
+		tags := make([]string, 0)
+
+		// Get tags from context if they exist
+		if storedTags := e.Request.Ctx.GetAny("tags"); storedTags != nil {
+			if tagList, ok := storedTags.([]string); ok {
+				tags = tagList
+				logger.Debug("Retrieved tags from context", "tags", len(tags))
+			}
+		}
+
+		// Extract and parse the raw HTML content
+		rawHTMLBytes := e.Response.Body
+		_, parseSpan := tracer.Start(ctx, "ParseHTMLContent", trace.WithAttributes(
+			attribute.String("crawl.profile", c.profile.Name),
+			attribute.Int("html.size", len(rawHTMLBytes))))
+		parsedContent, err := ParseHTMLContent(string(rawHTMLBytes), ParseOptions{
+			PageURL: e.Request.URL.String(),
+			Profile: c.profile,
+		})
+		tracing.RecordError(parseSpan, err)
+		if err == nil {
+			parseSpan.SetAttributes(attribute.Float64("content.confidence", parsedContent.Confidence))
+		}
+		parseSpan.End()
+		if err != nil {
+			logger.Error("Error parsing HTML content", "error", err)
+			metrics.ParseFailures.WithLabelValues("parse_error").Inc()
+			c.report.failure(e.Request.URL.String(), models.FailureParse, 0, err.Error())
+			return
+		}
+
+		// Assign the extracted tags if not already assigned
+		if len(parsedContent.Tags) > 0 && len(tags) == 0 {
+			tags = parsedContent.Tags
+		}
+
+		// Determine the category path
+		_, categorySpan := tracer.Start(ctx, "crawl.resolve_category")
+		var categoryPath []string
+		categoryPath = append(categoryPath, c.config.DefaultCategory)
 
-			// Initialize empty tags slice
-			tags := make([]string, 0)
+		navSelectors := []string{
+			".sidenav li.is-selected",
+			".breadcrumbs li",
+			".navigation .selected",
+			"nav .mc-breadcrumb li",
+		}
 
-			// Get tags from context if they exist
-			if storedTags := e.Request.Ctx.GetAny("tags"); storedTags != nil {
-				if tagList, ok := storedTags.([]string); ok {
-					tags = tagList
-					logger.LogDebug("Retrieved %d tags from context", len(tags))
+		for _, navSelector := range navSelectors {
+			e.ForEach(navSelector, func(_ int, s *colly.HTMLElement) {
+				if text := strings.TrimSpace(s.Text); text != "" {
+					categoryPath = append(categoryPath, text)
+					logger.Debug("Found category component", "component", text)
 				}
-			}
+			})
+		}
 
-			// Extract and parse the raw HTML content
-			rawHTMLBytes := e.Response.Body
-			parsedContent, err := ParseHTMLContent(string(rawHTMLBytes))
-			if err != nil {
-				logger.LogError("Error parsing HTML content: %v", err)
+		// Construct the category string
+		categoryString := strings.Join(categoryPath, ":")
+		logger.Debug("Resolved category path", "category", categoryString)
+
+		// Retrieve the category from the structure
+		category, exists := cs.GetCategory(categoryString)
+		categorySpan.SetAttributes(
+			attribute.String("crawl.category_path", categoryString),
+			attribute.Bool("crawl.category_found", exists))
+		if !exists {
+			logger.Info("Category not found, using default", "category", categoryString)
+			c.report.unmatchedCategory(categoryString, e.Request.URL.String())
+			category, exists = cs.GetCategory(c.config.DefaultCategory)
+			if !exists {
+				err := fmt.Errorf("default category %q not found", c.config.DefaultCategory)
+				logger.Error("Default category not found", "category", c.config.DefaultCategory)
+				tracing.RecordError(categorySpan, err)
+				categorySpan.End()
+				metrics.ParseFailures.WithLabelValues("no_category").Inc()
+				c.report.failure(e.Request.URL.String(), models.FailureCategory, 0, err.Error())
 				return
 			}
+		}
+		categorySpan.End()
+
+		if parsedContent.Title == "" || parsedContent.Content == "" {
+			logger.Error("Missing required content",
+				"has_title", parsedContent.Title != "", "has_content", parsedContent.Content != "")
+			metrics.ParseFailures.WithLabelValues("missing_content").Inc()
+			c.report.failure(e.Request.URL.String(), models.FailureContent, 0, missingContent(parsedContent.Title, parsedContent.Content))
+			return
+		}
 
-			// Assign the extracted tags if not already assigned
-			if len(parsedContent.Tags) > 0 && len(tags) == 0 {
-				tags = parsedContent.Tags
-			}
+		// Create metadata
+		metadata := map[string]interface{}{
+			"categoryPath":       categoryPath,
+			"fullCategoryString": categoryString,
+			"url":                e.Request.URL.String(),
+			"metaTags":           tags,
+		}
 
-			// Determine the category path
-			var categoryPath []string
-			categoryPath = append(categoryPath, c.config.DefaultCategory)
+		metadataJSON, err := json.Marshal(metadata)
+		if err != nil {
+			logger.Error("Error marshaling metadata", "error", err)
+			return
+		}
 
-			navSelectors := []string{
-				".sidenav li.is-selected",
-				".breadcrumbs li",
-				".navigation .selected",
-				"nav .mc-breadcrumb li",
-			}
+		// Create article, keyed by the normalized canonical URL so URL variants update one row
+		article := &models.Article{
+			ID:         uuid.New(),
+			CategoryID: category.ID,
+			Name:       parsedContent.Title,
+			Body:       parsedContent.Content,
+			URL:        urlnorm.ResolveCanonical(e.Request.URL.String(), parsedContent.CanonicalURL),
+			Tags:       tags,
+			Author:     parsedContent.Author,
+			Metadata:   (*json.RawMessage)(&metadataJSON),
+			CreatedAt:  time.Now(),
+			UpdatedAt:  time.Now(),
+
+			ContentConfidence: &parsedContent.Confidence,
+			CanonicalURL:      parsedContent.CanonicalURL,
+			Language:          parsedContent.Language,
+			Description:       parsedContent.Description,
+			PublishedAt:       parsedContent.PublishedAt,
+			ModifiedAt:        parsedContent.ModifiedAt,
+			OpenGraph:         parsedContent.OpenGraph,
+			StructuredData:    parsedContent.StructuredData,
+			InSitemap:         c.pageInSitemap(e.Request, urlnorm.Normalize(e.Request.URL.String())),
+		}
+		if c.config.ConfigID != uuid.Nil {
+			article.ConfigID = &c.config.ConfigID
+		}
 
-			for _, navSelector := range navSelectors {
-				e.ForEach(navSelector, func(_ int, s *colly.HTMLElement) {
-					if text := strings.TrimSpace(s.Text); text != "" {
-						categoryPath = append(categoryPath, text)
-						logger.LogInfo("Found category component: %s", text)
-					}
-				})
-			}
+		// Stored articles and the links to them use the canonical URL, so the page's status is
+		// looked up under it as well as under the URL it was fetched from
+		c.recordStatus(article.URL, e.Response.StatusCode)
 
-			// Construct the category string
-			categoryString := strings.Join(categoryPath, ":")
-			logger.LogInfo("Category path: %s", categoryString)
+		if parsedContent.Confidence < lowConfidenceThreshold {
+			logger.Info("Low content confidence, the extraction may include page chrome",
+				"confidence", parsedContent.Confidence)
+		}
 
-			// Retrieve the category from the structure
-			category, exists := cs.GetCategory(categoryString)
-			if !exists {
-				logger.LogInfo("Category not found for path: %s, using default", categoryString)
-				category, exists = cs.GetCategory(c.config.DefaultCategory)
-				if !exists {
-					logger.LogError("Default category not found!")
-					return
-				}
-			}
+		// Fingerprint the text; under the link policy a copy of another article keeps only a link
+		if c.fingerprint(article) {
+			logger.Info("Article duplicates another, storing a link to it", "duplicate_of", article.DuplicateOf.String())
+			parsedContent.Sections = nil
+			parsedContent.Assets = nil
+		}
 
-			if parsedContent.Title == "" || parsedContent.Content == "" {
-				logger.LogError("Missing required content - Title found: %v, Content found: %v",
-					parsedContent.Title != "", parsedContent.Content != "")
-				return
-			}
+		if c.config.DryRun {
+			logger.Info("Extracted article, not saving it in a dry run", "title", parsedContent.Title, "category", categoryString)
+			c.markSaved(article.URL)
+			c.report.article(article, categoryString)
+			return
+		}
 
-			// Create metadata
-			metadata := map[string]interface{}{
-				"categoryPath":       categoryPath,
-				"fullCategoryString": categoryString,
-				"url":                e.Request.URL.String(),
-				"metaTags":           tags,
-			}
+		logger.Debug("Saving article", "title", parsedContent.Title)
+		change, err := c.store.CreateArticle(ctx, article)
+		if err != nil {
+			logger.Error("Error saving article", "error", err)
+			return
+		}
+		logger.Info("Saved article", "title", parsedContent.Title, "category", categoryString, "tags", tags)
+		c.markSaved(article.URL)
+		e.Request.Ctx.Put(savedURLKey, article.URL)
+		c.indexArticle(article)
+		c.countSaved()
+		c.emit(events.Event{Type: events.URLSaved, URL: article.URL})
+		if change != nil {
+			metrics.Articles.WithLabelValues(string(change.Type)).Inc()
+			c.notify(change)
+		} else {
+			metrics.Articles.WithLabelValues("unchanged").Inc()
+		}
 
-			metadataJSON, err := json.Marshal(metadata)
-			if err != nil {
-				logger.LogError("Error marshaling metadata: %v", err)
-				return
-			}
+		// Store the section outline against the saved article
+		sections := buildArticleSections(article.ID, parsedContent.Sections)
+		if err := c.store.ReplaceArticleSections(ctx, article.ID, sections); err != nil {
+			logger.Error("Error saving sections", "error", err)
+		} else {
+			logger.Debug("Saved sections", "sections", len(sections))
+		}
 
-			// Create article
-			article := &models.Article{
-				ID:         uuid.New(),
-				CategoryID: category.ID,
-				Name:       parsedContent.Title,
-				Body:       parsedContent.Content,
-				URL:        e.Request.URL.String(),
-				Tags:       tags,
-				Metadata:   (*json.RawMessage)(&metadataJSON),
-				CreatedAt:  time.Now(),
-				UpdatedAt:  time.Now(),
-			}
+		// Record the article's assets, downloading them when the config asks for it
+		c.saveAssets(ctx, article, parsedContent.Assets, logger)
+
+		// Record outbound links for the link graph
+		c.saveLinks(ctx, article, parsedContent.Links, e.Request.URL.String(), logger)
+	})
+}
+
+// createCategory stores a mapped category; dry runs keep it in memory only.
+func (c *Crawler) createCategory(ctx context.Context, category *models.Category) error {
+	if c.config.DryRun {
+		return nil
+	}
+	return c.store.CreateCategory(ctx, category)
+}
+
+// saveAssets records the assets referenced by an article and links them to it. Assets are
+// downloaded into the asset store once; later crawls reuse the stored blob.
+func (c *Crawler) saveAssets(ctx context.Context, article *models.Article, parsed []Asset, logger *slog.Logger) {
+	download := c.config.DownloadAssets && c.config.AssetStore != nil
+
+	assets := make([]*models.Asset, 0, len(parsed))
+	for _, p := range parsed {
+		now := time.Now()
+		asset := &models.Asset{
+			ID:        uuid.New(),
+			URL:       p.URL,
+			Kind:      p.Kind,
+			MIMEType:  p.MIMEType,
+			CreatedAt: now,
+			UpdatedAt: now,
+		}
+		if err := c.store.UpsertAsset(ctx, asset); err != nil {
+			logger.Error("Error saving asset", "asset_url", p.URL, "error", err)
+			continue
+		}
+		asset.AltText = p.AltText
 
-			logger.LogInfo("Attempting to save article: %s", parsedContent.Title)
-			if err := c.store.CreateArticle(context.Background(), article); err != nil {
-				logger.LogError("Error saving article: %v", err)
+		// Embedded players are pages rather than files, so there is nothing to mirror. Assets
+		// on other hosts are recorded but not fetched.
+		if download && asset.SHA256 == "" && asset.MIMEType != "text/html" && c.allowAssetURL(asset.URL, article.URL) {
+			hash, size, mimeType, err := downloadAsset(ctx, c.assetClient, c.config.UserAgent, asset.URL, c.config.AssetStore)
+			if err != nil {
+				logger.Error("Error downloading asset", "asset_url", asset.URL, "error", err)
 			} else {
-				logger.LogInfo("Successfully saved article: %s with category path: %s and tags: %v",
-					parsedContent.Title, categoryString, tags)
+				downloadedAt := time.Now()
+				asset.SHA256, asset.Size, asset.DownloadedAt = hash, size, &downloadedAt
+				if mimeType != "" {
+					asset.MIMEType = mimeType
+				}
+				if err := c.store.UpdateAssetBlob(ctx, asset); err != nil {
+					logger.Error("Error saving download details for asset", "asset_url", asset.URL, "error", err)
+				} else {
+					logger.Debug("Downloaded asset", "asset_url", asset.URL, "bytes", size)
+				}
 			}
+		}
+
+		assets = append(assets, asset)
+	}
+
+	if err := c.store.ReplaceArticleAssets(ctx, article.ID, assets); err != nil {
+		logger.Error("Error linking assets to article", "error", err)
+	}
+}
+
+// notify passes article changes on to the configured notifier.
+func (c *Crawler) notify(changes ...*models.ArticleChange) {
+	if c.config.Notifier == nil || len(changes) == 0 {
+		return
+	}
+	c.config.Notifier.Notify(context.Background(), changes...)
+}
+
+// saveLinks replaces the stored outbound links of an article. Link URLs are normalized so
+// internal links match the URLs articles are stored under.
+func (c *Crawler) saveLinks(ctx context.Context, article *models.Article, parsed []Link, pageURL string, logger *slog.Logger) {
+	now := time.Now()
+	links := make([]*models.ArticleLink, 0, len(parsed))
+	for i, p := range parsed {
+		links = append(links, &models.ArticleLink{
+			ID:              uuid.New(),
+			SourceArticleID: article.ID,
+			TargetURL:       urlnorm.Normalize(p.URL),
+			AnchorText:      p.AnchorText,
+			Internal:        c.isInternal(p.URL, pageURL),
+			Position:        i,
+			CreatedAt:       now,
 		})
 	}
+
+	if err := c.store.ReplaceArticleLinks(ctx, article.ID, links); err != nil {
+		logger.Error("Error saving links", "error", err)
+	}
+}
+
+// buildArticleSections converts parsed sections into models, resolving parent indexes to IDs.
+func buildArticleSections(articleID uuid.UUID, parsed []Section) []*models.ArticleSection {
+	now := time.Now()
+	sections := make([]*models.ArticleSection, 0, len(parsed))
+	for i, p := range parsed {
+		section := &models.ArticleSection{
+			ID:        uuid.New(),
+			ArticleID: articleID,
+			Level:     p.Level,
+			Title:     p.Title,
+			Anchor:    p.Anchor,
+			Position:  i,
+			Content:   p.Content,
+			CreatedAt: now,
+		}
+		if p.Parent >= 0 {
+			section.ParentID = &sections[p.Parent].ID
+		}
+		sections = append(sections, section)
+	}
+	return sections
 }
 
 // min returns the smaller of two integers.
@@ -436,29 +965,19 @@ func min(a, b int) int {
 	return b
 }
 
-// runCrawler is the main entry point to start the crawling process.
-func (h *Crawler) runCrawler(config models.CrawlerConfig) error {
-
-	crawler := NewCrawler(h.store, &CrawlerConfig{
-		SitemapURL:      config.SitemapURL,
-		UserAgent:       config.UserAgent,
-		MaxDepth:        config.MaxDepth,
-		AllowedDomains:  config.AllowedDomains,
-		DefaultCategory: config.DefaultCategory,
-	})
-
-	categoryStructure, err := crawler.MapCategoryStructure(context.Background())
-	if err != nil {
-		return fmt.Errorf("failed to map category structure: %w", err)
-	}
-
-	// Start crawling
-	err = crawler.Crawl(context.Background(), categoryStructure)
-	if err != nil {
-		return fmt.Errorf("crawl failed: %w", err)
+// normalizeSitemapURLs returns the normalized sitemap locations in order, without duplicates.
+func normalizeSitemapURLs(sitemap *models.Sitemap) []string {
+	seen := make(map[string]bool, len(sitemap.URLs))
+	urls := make([]string, 0, len(sitemap.URLs))
+	for _, entry := range sitemap.URLs {
+		loc := urlnorm.Normalize(entry.Loc)
+		if loc == "" || seen[loc] {
+			continue
+		}
+		seen[loc] = true
+		urls = append(urls, loc)
 	}
-
-	return nil
+	return urls
 }
 
 // parseSitemap parses the sitemap XML from the given URL and returns a Sitemap structure.
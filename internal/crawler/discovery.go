@@ -0,0 +1,82 @@
+// internal/crawler/discovery.go
+package crawler
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/gocolly/colly/v2"
+	"github.com/romangod6/kb-crawler/internal/events"
+	"github.com/romangod6/kb-crawler/internal/urlnorm"
+)
+
+// CompilePatterns compiles URL patterns given as regular expressions, reporting the first
+// pattern that doesn't compile.
+func CompilePatterns(patterns []string) ([]*regexp.Regexp, error) {
+	compiled := make([]*regexp.Regexp, 0, len(patterns))
+	for _, pattern := range patterns {
+		if pattern == "" {
+			continue
+		}
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid URL pattern %q: %w", pattern, err)
+		}
+		compiled = append(compiled, re)
+	}
+	return compiled, nil
+}
+
+// markQueued records a URL as queued for this crawl. It returns false when the URL was already
+// queued, so each page is only visited once whether it came from the sitemap or a link.
+func (c *Crawler) markQueued(pageURL string) bool {
+	c.queueMu.Lock()
+	if c.queued[pageURL] {
+		c.queueMu.Unlock()
+		return false
+	}
+	c.queued[pageURL] = true
+	c.queueMu.Unlock()
+
+	c.observeQueue()
+	c.emit(events.Event{Type: events.URLQueued, URL: pageURL})
+	return true
+}
+
+// inSitemap reports whether a normalized URL was listed in the sitemap.
+func (c *Crawler) inSitemap(pageURL string) bool {
+	return c.sitemapURLs[pageURL]
+}
+
+// setupDiscovery follows in-scope links from every crawled page. colly stops at MaxDepth, with
+// sitemap URLs at depth 1.
+func (c *Crawler) setupDiscovery() {
+	c.collector.OnHTML("a[href]", func(e *colly.HTMLElement) {
+		// Links on pages at the depth limit would be rejected by colly anyway
+		if c.config.MaxDepth > 0 && pageDepth(e.Request) >= c.config.MaxDepth {
+			return
+		}
+
+		link := e.Request.AbsoluteURL(e.Attr("href"))
+		if link == "" {
+			return
+		}
+
+		// External links are out of scope and not counted as filtered; the include and
+		// exclude patterns and the URL filters decide on internal ones
+		link = urlnorm.Normalize(link)
+		if !c.isInternal(link, e.Request.URL.String()) || !c.followURL(link) || !c.markQueued(link) {
+			return
+		}
+
+		// Workers queue links for whichever worker claims them
+		if c.worker {
+			c.queueDiscovered(e.Request, link)
+			return
+		}
+
+		if err := e.Request.Visit(link); err != nil {
+			c.log.Debug("Not following link", "url", e.Request.URL.String(), "link", link, "error", err)
+		}
+	})
+}
@@ -0,0 +1,265 @@
+package crawler
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gocolly/colly/v2"
+	"github.com/google/uuid"
+	"github.com/romangod6/kb-crawler/internal/events"
+	"github.com/romangod6/kb-crawler/internal/metrics"
+	"github.com/romangod6/kb-crawler/internal/models"
+)
+
+// DistributedOptions configures a crawl whose pages are fetched by worker processes.
+type DistributedOptions struct {
+	// PollInterval is how often the coordinator checks the run's progress
+	PollInterval time.Duration
+	// MaxAttempts is how often a page is claimed before it counts as failed
+	MaxAttempts int
+	// IdleTimeout fails the run when, for this long, no worker has held a page of it and no
+	// page has been finished, as when no worker is running; zero waits indefinitely
+	IdleTimeout time.Duration
+}
+
+// Request context keys of a worker's pages.
+const (
+	runURLKey   = "run_url"
+	savedURLKey = "saved_url"
+	linksKey    = "discovered_links"
+)
+
+// coordinate queues the sitemap URLs that pass the filters for the workers, stores the category
+// structure they resolve pages against, and waits until every page, including the ones the
+// workers discovered, has been fetched or has failed. What the workers found is then merged
+// into this crawler, so links and tombstones are checked as after a local crawl. When the
+// workers stay idle for the configured timeout, the pages are withdrawn and the run fails.
+func (c *Crawler) coordinate(ctx context.Context, urls []string, cs *CategoryStructure, logger *slog.Logger) error {
+	if c.config.RunID == uuid.Nil {
+		return fmt.Errorf("distributed crawls need a run")
+	}
+
+	categories := make(map[string]uuid.UUID)
+	cs.mutex.RLock()
+	for path, category := range cs.categories {
+		categories[path] = category.ID
+	}
+	cs.mutex.RUnlock()
+	if err := c.store.SetRunCategories(ctx, c.config.RunID, categories); err != nil {
+		return fmt.Errorf("failed to store category structure: %w", err)
+	}
+
+	queued := make([]*models.RunURL, 0, len(urls))
+	for _, url := range urls {
+		if !c.allowURL(url) || !c.markQueued(url) {
+			continue
+		}
+		queued = append(queued, &models.RunURL{URL: url, Depth: 1, InSitemap: true})
+	}
+	if _, err := c.store.AddRunURLs(ctx, c.config.RunID, queued); err != nil {
+		return fmt.Errorf("failed to queue URLs for workers: %w", err)
+	}
+	logger.Info("Queued URLs for workers", "urls", len(queued))
+
+	ticker := time.NewTicker(c.config.Distributed.PollInterval)
+	defer ticker.Stop()
+
+	idleTimeout := c.config.Distributed.IdleTimeout
+	lastActive, finished := time.Now(), -1
+	for {
+		select {
+		case <-ctx.Done():
+			logger.Info("Context cancelled, withdrawing URLs from workers")
+			if _, err := c.store.CancelRunURLs(context.WithoutCancel(ctx), c.config.RunID, "run cancelled"); err != nil {
+				logger.Error("Failed to withdraw URLs from workers", "error", err)
+			}
+			return ctx.Err()
+		case <-ticker.C:
+		}
+
+		progress, err := c.store.GetRunURLProgress(ctx, c.config.RunID, c.config.Distributed.MaxAttempts)
+		if err != nil {
+			logger.Warn("Failed to check worker progress", "error", err)
+			continue
+		}
+		c.observeWorkers(progress)
+		if progress.Finished() {
+			break
+		}
+
+		// Workers renew the leases of the pages they fetch, so without a live lease or a newly
+		// finished page nobody is working on the run
+		if progress.Live > 0 || progress.Done+progress.Failed != finished {
+			lastActive, finished = time.Now(), progress.Done+progress.Failed
+		} else if idleTimeout > 0 && time.Since(lastActive) >= idleTimeout {
+			err := fmt.Errorf("no worker fetched a page of the run for %s, %d pages left; is a worker running?",
+				idleTimeout, progress.Pending+progress.Leased)
+			logger.Error("Workers idle, withdrawing URLs", "error", err)
+			if _, cancelErr := c.store.CancelRunURLs(context.WithoutCancel(ctx), c.config.RunID, "no worker available"); cancelErr != nil {
+				logger.Error("Failed to withdraw URLs from workers", "error", cancelErr)
+			}
+			return err
+		}
+	}
+
+	return c.collectResults(ctx, logger)
+}
+
+// observeWorkers publishes a distributed run's progress as reported by the store.
+func (c *Crawler) observeWorkers(progress *models.RunURLProgress) {
+	total := progress.Pending + progress.Leased + progress.Done + progress.Failed
+
+	c.queueMu.Lock()
+	c.fetched, c.failed = progress.Done, progress.Failed
+	startedAt := c.startedAt
+	c.queueMu.Unlock()
+
+	metrics.QueueDepth.WithLabelValues(c.config.ConfigID.String()).Set(float64(progress.Pending + progress.Leased))
+	if c.config.Events == nil {
+		return
+	}
+
+	p := &events.Progress{Queued: total, Fetched: progress.Done, Failed: progress.Failed}
+	if done := progress.Done + progress.Failed; done > 0 && total > done {
+		perURL := time.Since(startedAt).Seconds() / float64(done)
+		p.ETASeconds = perURL * float64(total-done)
+	}
+	c.emit(events.Event{Type: events.ProgressUpdate, Progress: p})
+}
+
+// collectResults loads the outcome of every page of the run: the URLs the workers discovered,
+// the statuses they saw and the articles they saved.
+func (c *Crawler) collectResults(ctx context.Context, logger *slog.Logger) error {
+	results, err := c.store.ListRunURLs(ctx, c.config.RunID)
+	if err != nil {
+		return fmt.Errorf("failed to load worker results: %w", err)
+	}
+
+	c.queueMu.Lock()
+	c.statusMu.Lock()
+	failed := 0
+	for _, u := range results {
+		c.queued[u.URL] = true
+		if u.StatusCode != 0 {
+			c.fetchStatus[u.URL] = u.StatusCode
+			if u.SavedURL != "" {
+				c.fetchStatus[u.SavedURL] = u.StatusCode
+			}
+		}
+		if u.SavedURL != "" && !c.saved[u.SavedURL] {
+			c.saved[u.SavedURL] = true
+			c.savedCount++
+		}
+		if u.Status != models.RunURLDone {
+			failed++
+		}
+	}
+	c.statusMu.Unlock()
+	c.queueMu.Unlock()
+
+	logger.Info("Workers finished", "urls", len(results), "failed", failed)
+	return nil
+}
+
+// PrepareWorker sets the crawler up to fetch pages of its run claimed by a worker process,
+// resolving categories against the structure the coordinator mapped. In discovery mode, links
+// are queued for the run instead of being followed here.
+func (c *Crawler) PrepareWorker(ctx context.Context, categories map[string]uuid.UUID) {
+	cs := NewCategoryStructure()
+	for path, id := range categories {
+		cs.AddCategory(path, &models.Category{ID: id})
+	}
+
+	c.worker = true
+	c.setupHandlers(cs)
+	c.setupCallbacks(ctx, c.log)
+
+	if c.config.DuplicatePolicy == models.DuplicatePolicyLink {
+		if err := c.loadDuplicateIndex(ctx); err != nil {
+			c.log.Error("Failed to load article fingerprints, duplicates will be stored in full", "error", err)
+		}
+	}
+
+	c.collector.OnScraped(func(r *colly.Response) {
+		c.completeRunURL(r, nil)
+	})
+	c.collector.OnError(func(r *colly.Response, err error) {
+		c.completeRunURL(r, err)
+	})
+}
+
+// FetchRunURLs fetches a batch of claimed pages and waits for them, recording each page's
+// outcome on its RunURL. Pages the context stopped before they were requested keep an empty
+// status and are left for their lease to run out.
+func (c *Crawler) FetchRunURLs(ctx context.Context, urls []*models.RunURL) {
+	for _, u := range urls {
+		if ctx.Err() != nil {
+			break
+		}
+
+		requestCtx := colly.NewContext()
+		requestCtx.Put(runURLKey, u)
+		if err := c.collector.Request(http.MethodGet, u.URL, nil, requestCtx, nil); err != nil {
+			u.Status = models.RunURLFailed
+			u.Error = err.Error()
+		}
+	}
+	c.collector.Wait()
+}
+
+// completeRunURL records the outcome of a worker's page and queues the links it discovered.
+func (c *Crawler) completeRunURL(r *colly.Response, err error) {
+	u := runURL(r.Request)
+	if u == nil {
+		return
+	}
+
+	if links, ok := r.Ctx.GetAny(linksKey).([]*models.RunURL); ok && len(links) > 0 {
+		if _, err := c.store.AddRunURLs(c.pageContext(r.Request), u.RunID, links); err != nil {
+			c.log.Error("Failed to queue discovered links", "url", u.URL, "links", len(links), "error", err)
+		}
+	}
+
+	u.StatusCode = r.StatusCode
+	u.SavedURL = r.Ctx.Get(savedURLKey)
+	if err != nil {
+		u.Status = models.RunURLFailed
+		u.Error = err.Error()
+		return
+	}
+	u.Status = models.RunURLDone
+}
+
+// queueDiscovered remembers a link found on a worker's page, to be queued for the run once the
+// page is done.
+func (c *Crawler) queueDiscovered(r *colly.Request, link string) {
+	links, _ := r.Ctx.GetAny(linksKey).([]*models.RunURL)
+	r.Ctx.Put(linksKey, append(links, &models.RunURL{URL: link, Depth: pageDepth(r) + 1}))
+}
+
+// runURL returns the claimed page a worker's request fetches, or nil for other requests.
+func runURL(r *colly.Request) *models.RunURL {
+	u, _ := r.Ctx.GetAny(runURLKey).(*models.RunURL)
+	return u
+}
+
+// pageDepth returns a page's depth in the crawl. Workers request every page at colly depth 1,
+// so their pages carry the depth the coordinator recorded.
+func pageDepth(r *colly.Request) int {
+	if u := runURL(r); u != nil {
+		return u.Depth
+	}
+	return r.Depth
+}
+
+// pageInSitemap reports whether the sitemap listed a page. Workers don't read the sitemap;
+// their pages carry whether it did.
+func (c *Crawler) pageInSitemap(r *colly.Request, pageURL string) bool {
+	if u := runURL(r); u != nil {
+		return u.InSitemap
+	}
+	return c.inSitemap(pageURL)
+}
@@ -0,0 +1,203 @@
+package crawler
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/romangod6/kb-crawler/internal/models"
+)
+
+// maxUnmatchedURLs is how many example pages are kept for each unmatched category path.
+const maxUnmatchedURLs = 5
+
+// dryRunReport collects what a dry run would change. It is safe for concurrent use, and a nil
+// report records nothing, so crawls that are not dry runs can call it unconditionally.
+type dryRunReport struct {
+	mu     sync.Mutex
+	report models.DryRunReport
+	// stored holds the config's articles by URL, with the hash of their latest revision
+	stored map[string]*models.ArticleRef
+	// seen holds the article URLs already reported, as several pages can share a canonical URL
+	seen      map[string]bool
+	unmatched map[string]*models.UnmatchedCategory
+}
+
+func newDryRunReport() *dryRunReport {
+	return &dryRunReport{
+		stored:    make(map[string]*models.ArticleRef),
+		seen:      make(map[string]bool),
+		unmatched: make(map[string]*models.UnmatchedCategory),
+	}
+}
+
+// loadStored loads the articles a dry run compares what it extracts against.
+func (r *dryRunReport) loadStored(ctx context.Context, c *Crawler) error {
+	if r == nil {
+		return nil
+	}
+	refs, err := c.store.ListActiveArticleRefs(ctx, c.config.ConfigID)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, ref := range refs {
+		r.stored[ref.URL] = ref
+	}
+	return nil
+}
+
+// storedRefs returns the articles loaded by loadStored.
+func (r *dryRunReport) storedRefs() []*models.ArticleRef {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	refs := make([]*models.ArticleRef, 0, len(r.stored))
+	for _, ref := range r.stored {
+		refs = append(refs, ref)
+	}
+	return refs
+}
+
+// article reports an extracted article as new, updated or unchanged. Like the store, it
+// compares the body against the latest stored revision, and counts tombstoned articles as new.
+func (r *dryRunReport) article(article *models.Article, category string) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.seen[article.URL] {
+		return
+	}
+	r.seen[article.URL] = true
+
+	entry := models.DryRunArticle{URL: article.URL, Title: article.Name, Category: category}
+	switch ref, ok := r.stored[article.URL]; {
+	case !ok:
+		r.report.New = append(r.report.New, entry)
+	case article.DuplicateOf != nil:
+		// Linked duplicates are stored without a revision, like an unchanged article
+		r.report.Unchanged = append(r.report.Unchanged, entry)
+	case ref.ContentHash != models.ContentHash(article.Body):
+		r.report.Updated = append(r.report.Updated, entry)
+	default:
+		r.report.Unchanged = append(r.report.Unchanged, entry)
+	}
+}
+
+// failure reports a page that could not be turned into an article.
+func (r *dryRunReport) failure(pageURL, stage string, statusCode int, message string) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.report.Failures = append(r.report.Failures, models.DryRunFailure{
+		URL:        pageURL,
+		Stage:      stage,
+		StatusCode: statusCode,
+		Error:      message,
+	})
+}
+
+// unmatchedCategory reports a page whose category path is not in the category map.
+func (r *dryRunReport) unmatchedCategory(path, pageURL string) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	u, ok := r.unmatched[path]
+	if !ok {
+		u = &models.UnmatchedCategory{Path: path}
+		r.unmatched[path] = u
+	}
+	u.Pages++
+	if len(u.URLs) < maxUnmatchedURLs {
+		u.URLs = append(u.URLs, pageURL)
+	}
+}
+
+// deleted reports the stored articles a real run would tombstone, by reason.
+func (r *dryRunReport) deleted(missing map[string][]*models.ArticleRef) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for reason, refs := range missing {
+		for _, ref := range refs {
+			r.report.Deleted = append(r.report.Deleted, models.DryRunArticle{URL: ref.URL, Reason: reason})
+		}
+	}
+}
+
+// DryRunReport returns what the crawl found when it is a dry run, or nil otherwise. The
+// caller fills in the run's product.
+func (c *Crawler) DryRunReport() *models.DryRunReport {
+	if c.report == nil {
+		return nil
+	}
+
+	c.report.mu.Lock()
+	report := c.report.report
+	for _, u := range c.report.unmatched {
+		report.UnmatchedCategories = append(report.UnmatchedCategories, *u)
+	}
+	c.report.mu.Unlock()
+
+	report.RunID = c.config.RunID
+	if c.config.ConfigID != uuid.Nil {
+		report.ConfigID = &c.config.ConfigID
+	}
+	report.Profile = c.profile.Name
+
+	c.queueMu.Lock()
+	report.StartedAt = c.startedAt
+	report.FetchedPages = c.fetched
+	c.queueMu.Unlock()
+	report.ReplayedPages = int(c.replayed.Load())
+	report.FetchedPages -= report.ReplayedPages
+	report.FinishedAt = time.Now()
+
+	// Copy and sort the lists, so reports of the same site compare line by line
+	report.New = sortedArticles(report.New)
+	report.Updated = sortedArticles(report.Updated)
+	report.Unchanged = sortedArticles(report.Unchanged)
+	report.Deleted = sortedArticles(report.Deleted)
+	report.Failures = append([]models.DryRunFailure{}, report.Failures...)
+	sort.Slice(report.Failures, func(i, j int) bool { return report.Failures[i].URL < report.Failures[j].URL })
+	if report.UnmatchedCategories == nil {
+		report.UnmatchedCategories = []models.UnmatchedCategory{}
+	}
+	sort.Slice(report.UnmatchedCategories, func(i, j int) bool {
+		a, b := report.UnmatchedCategories[i], report.UnmatchedCategories[j]
+		if a.Pages != b.Pages {
+			return a.Pages > b.Pages
+		}
+		return a.Path < b.Path
+	})
+	return &report
+}
+
+func sortedArticles(articles []models.DryRunArticle) []models.DryRunArticle {
+	sorted := append([]models.DryRunArticle{}, articles...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].URL < sorted[j].URL })
+	return sorted
+}
+
+// missingContent describes what an extraction without a title or body lacked.
+func missingContent(title, content string) string {
+	switch {
+	case title == "" && content == "":
+		return "no title or content extracted"
+	case title == "":
+		return "no title extracted"
+	default:
+		return "no content extracted"
+	}
+}
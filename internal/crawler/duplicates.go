@@ -0,0 +1,61 @@
+package crawler
+
+import (
+	"context"
+
+	"github.com/romangod6/kb-crawler/internal/dedupe"
+	"github.com/romangod6/kb-crawler/internal/models"
+)
+
+// loadDuplicateIndex indexes the stored articles that are not duplicates themselves, so
+// articles crawled under the link policy can be matched against every product's articles.
+func (c *Crawler) loadDuplicateIndex(ctx context.Context) error {
+	fingerprints, err := c.store.ListArticleFingerprints(ctx)
+	if err != nil {
+		return err
+	}
+
+	index := dedupe.NewIndex(dedupe.DefaultMaxDistance)
+	for _, fp := range fingerprints {
+		if fp.DuplicateOf == nil {
+			index.Add(fp.ID, fp.URL, dedupe.Fingerprint{TextHash: fp.TextHash, SimHash: uint64(fp.SimHash)})
+		}
+	}
+	c.duplicates = index
+	return nil
+}
+
+// fingerprint sets the article's text fingerprint. Under the link policy an article that
+// duplicates an indexed one is turned into a link to it, dropping its body, and true is
+// returned. The store keeps no revision or change event for such links.
+func (c *Crawler) fingerprint(article *models.Article) bool {
+	fp := dedupe.Compute(article.Body)
+	article.TextHash = fp.TextHash
+	article.SimHash = int64(fp.SimHash)
+
+	if c.duplicates == nil {
+		return false
+	}
+	canonicalID, ok := c.duplicates.Match(article.URL, fp)
+	if !ok {
+		return false
+	}
+
+	article.DuplicateOf = &canonicalID
+	article.Body = ""
+
+	c.queueMu.Lock()
+	c.linkedDuplicates++
+	c.queueMu.Unlock()
+	return true
+}
+
+// indexArticle makes a saved article a candidate canonical for the rest of the crawl.
+func (c *Crawler) indexArticle(article *models.Article) {
+	if c.duplicates != nil && article.DuplicateOf == nil {
+		c.duplicates.Add(article.ID, article.URL, dedupe.Fingerprint{
+			TextHash: article.TextHash,
+			SimHash:  uint64(article.SimHash),
+		})
+	}
+}
@@ -0,0 +1,70 @@
+package crawler
+
+import (
+	"time"
+
+	"github.com/romangod6/kb-crawler/internal/events"
+	"github.com/romangod6/kb-crawler/internal/models"
+)
+
+// emit publishes a crawl event when the config has an event bus.
+func (c *Crawler) emit(event events.Event) {
+	if c.config.Events == nil {
+		return
+	}
+	event.ConfigID = c.config.ConfigID
+	event.RunID = c.config.RunID
+	c.config.Events.Publish(event)
+}
+
+// countFetched, countSaved and countFailed track the run's progress.
+func (c *Crawler) countFetched() { c.count(&c.fetched) }
+func (c *Crawler) countSaved()   { c.count(&c.savedCount) }
+func (c *Crawler) countFailed()  { c.count(&c.failed) }
+
+func (c *Crawler) count(counter *int) {
+	c.queueMu.Lock()
+	*counter++
+	c.queueMu.Unlock()
+	c.observeQueue()
+}
+
+// progress returns the run's counts so far and an estimate of the time left.
+func (c *Crawler) progress() *events.Progress {
+	c.queueMu.Lock()
+	defer c.queueMu.Unlock()
+
+	p := &events.Progress{
+		Queued:  len(c.queued),
+		Fetched: c.fetched,
+		Saved:   c.savedCount,
+		Failed:  c.failed,
+	}
+	if done := c.fetched + c.failed; done > 0 && p.Queued > done && !c.startedAt.IsZero() {
+		perURL := time.Since(c.startedAt).Seconds() / float64(done)
+		p.ETASeconds = perURL * float64(p.Queued-done)
+	}
+	return p
+}
+
+// emitProgress publishes the run's progress.
+func (c *Crawler) emitProgress() {
+	if c.config.Events != nil {
+		c.emit(events.Event{Type: events.ProgressUpdate, Progress: c.progress()})
+	}
+}
+
+// emitMappingFailed publishes the end of a run that failed while mapping categories.
+func (c *Crawler) emitMappingFailed(err error) {
+	c.emit(events.Event{Type: events.MappingFinished, Error: err.Error()})
+	c.emitFinished(models.RunFailed, err)
+}
+
+// emitFinished publishes the end of the run with its final status and counts.
+func (c *Crawler) emitFinished(status string, err error) {
+	event := events.Event{Type: events.RunFinished, Status: status, Progress: c.progress()}
+	if err != nil {
+		event.Error = err.Error()
+	}
+	c.emit(event)
+}
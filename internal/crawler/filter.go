@@ -0,0 +1,198 @@
+// internal/crawler/filter.go
+package crawler
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/romangod6/kb-crawler/internal/models"
+)
+
+// Summary labels for URLs rejected because include rules or patterns exist and none of them
+// matched.
+const (
+	noIncludeMatched        = "no include rule matched"
+	noIncludePatternMatched = "no include pattern matched"
+)
+
+// URLFilterSet applies a crawler config's ordered URL filter rules to every URL, and its
+// discovery scope patterns to the links it follows.
+type URLFilterSet struct {
+	rules      []compiledFilter
+	hasInclude bool
+
+	// include and exclude scope link-following in discovery mode
+	include []*regexp.Regexp
+	exclude []*regexp.Regexp
+}
+
+type compiledFilter struct {
+	filter models.URLFilter
+	re     *regexp.Regexp
+}
+
+// NewURLFilterSet compiles filter rules and the discovery scope's include and exclude
+// patterns, reporting the first invalid rule by position or the first invalid pattern.
+func NewURLFilterSet(filters []models.URLFilter, include, exclude []string) (*URLFilterSet, error) {
+	set := &URLFilterSet{}
+	var err error
+	if set.include, err = CompilePatterns(include); err != nil {
+		return nil, fmt.Errorf("include patterns: %w", err)
+	}
+	if set.exclude, err = CompilePatterns(exclude); err != nil {
+		return nil, fmt.Errorf("exclude patterns: %w", err)
+	}
+
+	for i, filter := range filters {
+		if filter.Action != "include" && filter.Action != "exclude" {
+			return nil, fmt.Errorf("URL filter %d: action must be \"include\" or \"exclude\"", i+1)
+		}
+		if filter.Pattern == "" {
+			return nil, fmt.Errorf("URL filter %d: pattern is required", i+1)
+		}
+
+		expr := filter.Pattern
+		switch filter.Type {
+		case "glob":
+			expr = globToRegexp(filter.Pattern)
+		case "regex":
+		default:
+			return nil, fmt.Errorf("URL filter %d: type must be \"glob\" or \"regex\"", i+1)
+		}
+
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, fmt.Errorf("URL filter %d: invalid pattern %q: %w", i+1, filter.Pattern, err)
+		}
+
+		set.rules = append(set.rules, compiledFilter{filter: filter, re: re})
+		if filter.Action == "include" {
+			set.hasInclude = true
+		}
+	}
+	return set, nil
+}
+
+// Allow reports whether a URL may be queued. The first matching rule decides; when no rule
+// matches, the URL is allowed unless there are include rules. For rejected URLs the rule
+// responsible is returned as a summary label.
+func (s *URLFilterSet) Allow(pageURL string) (bool, string) {
+	for _, rule := range s.rules {
+		if rule.re.MatchString(pageURL) {
+			if rule.filter.Action == "exclude" {
+				return false, rule.filter.String()
+			}
+			return true, ""
+		}
+	}
+
+	if s.hasInclude {
+		return false, noIncludeMatched
+	}
+	return true, ""
+}
+
+// Follow reports whether a discovered link may be queued: it must match no exclude pattern,
+// one of the include patterns when there are any, and then pass the filter rules as in Allow.
+func (s *URLFilterSet) Follow(linkURL string) (bool, string) {
+	for _, re := range s.exclude {
+		if re.MatchString(linkURL) {
+			return false, fmt.Sprintf("exclude pattern %q", re.String())
+		}
+	}
+
+	if len(s.include) > 0 {
+		included := false
+		for _, re := range s.include {
+			if re.MatchString(linkURL) {
+				included = true
+				break
+			}
+		}
+		if !included {
+			return false, noIncludePatternMatched
+		}
+	}
+	return s.Allow(linkURL)
+}
+
+// globToRegexp converts a glob matched against the whole URL. "*" matches any run of
+// characters, including slashes, and "?" matches a single character.
+func globToRegexp(glob string) string {
+	var b strings.Builder
+	b.WriteString("^")
+	for _, r := range glob {
+		switch r {
+		case '*':
+			b.WriteString(".*")
+		case '?':
+			b.WriteString(".")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	b.WriteString("$")
+	return b.String()
+}
+
+// allowURL applies the URL filters and counts each URL they reject once, however many pages
+// link to it.
+func (c *Crawler) allowURL(pageURL string) bool {
+	if c.filters == nil {
+		return true
+	}
+	allowed, rule := c.filters.Allow(pageURL)
+	return c.countRejected(pageURL, allowed, rule)
+}
+
+// followURL applies the discovery scope patterns and the URL filters to a discovered link,
+// counting it like allowURL when it is rejected.
+func (c *Crawler) followURL(linkURL string) bool {
+	if c.filters == nil {
+		return true
+	}
+	allowed, rule := c.filters.Follow(linkURL)
+	return c.countRejected(linkURL, allowed, rule)
+}
+
+// countRejected counts a rejected URL under the rule responsible, once per URL, and returns
+// allowed.
+func (c *Crawler) countRejected(pageURL string, allowed bool, rule string) bool {
+	if !allowed {
+		c.queueMu.Lock()
+		if !c.rejected[pageURL] {
+			c.rejected[pageURL] = true
+			c.filtered[rule]++
+		}
+		c.queueMu.Unlock()
+	}
+	return allowed
+}
+
+// Summary reports what the latest crawl queued, filtered and tombstoned. Filter counts are sorted with the
+// busiest rule first.
+func (c *Crawler) Summary() models.RunSummary {
+	c.queueMu.Lock()
+	defer c.queueMu.Unlock()
+
+	summary := models.RunSummary{
+		SitemapURLs:        len(c.sitemapURLs),
+		QueuedURLs:         len(c.queued),
+		UnmodifiedURLs:     c.unmodified,
+		TombstonedArticles: c.tombstoned,
+		LinkedDuplicates:   c.linkedDuplicates,
+	}
+	for rule, count := range c.filtered {
+		summary.FilteredURLs += count
+		summary.Filtered = append(summary.Filtered, models.FilterCount{Rule: rule, Count: count})
+	}
+	sort.Slice(summary.Filtered, func(i, j int) bool {
+		if summary.Filtered[i].Count != summary.Filtered[j].Count {
+			return summary.Filtered[i].Count > summary.Filtered[j].Count
+		}
+		return summary.Filtered[i].Rule < summary.Filtered[j].Rule
+	})
+	return summary
+}
@@ -0,0 +1,174 @@
+// internal/crawler/links.go
+package crawler
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/romangod6/kb-crawler/internal/models"
+)
+
+// Link is an outbound link found in the main content of a page.
+type Link struct {
+	URL        string
+	AnchorText string
+}
+
+// linkCheckWorkers limits how many link targets are checked concurrently.
+const linkCheckWorkers = 4
+
+// extractLinks returns the links in the main content with URLs resolved against the page URL.
+// Same-page fragment links and non-HTTP schemes such as mailto: are skipped.
+func extractLinks(main *goquery.Selection, pageURL string) []Link {
+	base, _ := url.Parse(pageURL)
+	var links []Link
+
+	main.Find("a[href]").Each(func(_ int, s *goquery.Selection) {
+		href := strings.TrimSpace(s.AttrOr("href", ""))
+		if href == "" || strings.HasPrefix(href, "#") {
+			return
+		}
+
+		resolved, err := url.Parse(resolveURL(base, href))
+		if err != nil || (resolved.Scheme != "http" && resolved.Scheme != "https") {
+			return
+		}
+
+		links = append(links, Link{
+			URL:        resolved.String(),
+			AnchorText: strings.Join(strings.Fields(s.Text()), " "),
+		})
+	})
+
+	return links
+}
+
+// isInternal reports whether a URL belongs to the crawled knowledge base, meaning its host is
+// one of the allowed domains. Without allowed domains only the page's own host counts.
+func (c *Crawler) isInternal(linkURL, pageURL string) bool {
+	u, err := url.Parse(linkURL)
+	if err != nil {
+		return false
+	}
+	host := strings.ToLower(u.Hostname())
+
+	if len(c.config.AllowedDomains) == 0 {
+		page, err := url.Parse(pageURL)
+		return err == nil && strings.EqualFold(page.Hostname(), host)
+	}
+
+	for _, domain := range c.config.AllowedDomains {
+		if strings.EqualFold(domain, host) {
+			return true
+		}
+	}
+	return false
+}
+
+// recordStatus remembers the HTTP status a URL returned during the crawl so the link checker
+// doesn't need to fetch it again.
+func (c *Crawler) recordStatus(pageURL string, status int) {
+	c.statusMu.Lock()
+	defer c.statusMu.Unlock()
+	c.fetchStatus[pageURL] = status
+}
+
+// checkLinks flags internal links that point to pages missing from the sitemap or that answer
+// with a 4xx status, then resolves link targets to article IDs.
+func (c *Crawler) checkLinks(ctx context.Context) error {
+	targets, err := c.store.ListInternalLinkTargets(ctx, c.config.ConfigID)
+	if err != nil {
+		return err
+	}
+
+	jobs := make(chan string)
+	results := make(chan models.LinkStatus)
+
+	var wg sync.WaitGroup
+	for i := 0; i < linkCheckWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for target := range jobs {
+				results <- c.checkLink(ctx, target)
+			}
+		}()
+	}
+
+	go func() {
+		defer close(jobs)
+		for _, target := range targets {
+			select {
+			case jobs <- target:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	statuses := make([]models.LinkStatus, 0, len(targets))
+	for status := range results {
+		statuses = append(statuses, status)
+	}
+
+	if err := c.store.UpdateLinkStatuses(ctx, c.config.ConfigID, statuses); err != nil {
+		return err
+	}
+
+	return c.store.ResolveLinkTargets(ctx, c.config.ConfigID)
+}
+
+// checkLink determines the status of a single internal link target.
+func (c *Crawler) checkLink(ctx context.Context, target string) models.LinkStatus {
+	c.statusMu.Lock()
+	status, crawled := c.fetchStatus[target]
+	c.statusMu.Unlock()
+
+	if !crawled {
+		status = c.fetchLinkStatus(ctx, target)
+	}
+
+	result := models.LinkStatus{URL: target, StatusCode: status}
+	switch {
+	case status >= 400 && status < 500:
+		result.Broken = true
+		result.Reason = "http_" + strconv.Itoa(status)
+	case !c.inSitemap(target):
+		result.Broken = true
+		result.Reason = "not_in_sitemap"
+	}
+	return result
+}
+
+// fetchLinkStatus returns the HTTP status of a URL, trying HEAD first and falling back to GET
+// for servers that don't support it. Network failures return 0.
+func (c *Crawler) fetchLinkStatus(ctx context.Context, target string) int {
+	for _, method := range []string{http.MethodHead, http.MethodGet} {
+		req, err := http.NewRequestWithContext(ctx, method, target, nil)
+		if err != nil {
+			return 0
+		}
+		req.Header.Set("User-Agent", c.config.UserAgent)
+
+		resp, err := c.httpClient.Do(req)
+		if err != nil {
+			return 0
+		}
+		resp.Body.Close()
+
+		if resp.StatusCode != http.StatusMethodNotAllowed && resp.StatusCode != http.StatusNotImplemented {
+			return resp.StatusCode
+		}
+	}
+	return 0
+}
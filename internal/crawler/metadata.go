@@ -0,0 +1,395 @@
+// internal/crawler/metadata.go
+package crawler
+
+import (
+	"encoding/json"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/romangod6/kb-crawler/internal/models"
+	"unicode"
+)
+
+// dateLayouts are the formats tried when reading dates from meta tags, JSON-LD and page text.
+var dateLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05Z0700",
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02",
+	time.RFC1123,
+	time.RFC1123Z,
+	"January 2, 2006",
+	"January 2 2006",
+	"Jan 2, 2006",
+	"Jan 2 2006",
+	"2 January 2006",
+	"2 Jan 2006",
+	"01/02/2006",
+	"1/2/2006",
+}
+
+// lastUpdatedPattern finds visible "Last updated" style notices and captures the date that follows.
+var lastUpdatedPattern = regexp.MustCompile(`(?i)(?:last\s+(?:updated|modified|revised)|updated\s+on|modified\s+on)\s*(?:on)?\s*[:\-]?\s*` +
+	`([A-Za-z]{3,9}\.?\s+\d{1,2},?\s+\d{4}|\d{4}-\d{2}-\d{2}|\d{1,2}/\d{1,2}/\d{4}|\d{1,2}\s+[A-Za-z]{3,9}\s+\d{4})`)
+
+// extractMetadata fills the typed page metadata on parsed: canonical URL, language, description,
+// OpenGraph and Twitter cards, JSON-LD structured data and published/modified dates.
+func extractMetadata(doc *goquery.Document, pageURL string, parsed *ParsedContent) {
+	base, _ := url.Parse(pageURL)
+
+	// Canonical URL, resolved against the page URL
+	if href, ok := doc.Find("link[rel='canonical']").First().Attr("href"); ok {
+		parsed.CanonicalURL = resolveURL(base, strings.TrimSpace(href))
+	}
+
+	// Document language
+	if lang, _ := doc.Find("html").First().Attr("lang"); normalizeLanguage(lang) != "" {
+		parsed.Language = normalizeLanguage(lang)
+	} else {
+		parsed.Language = normalizeLanguage(metaContent(doc, "meta[http-equiv='content-language']"))
+	}
+
+	parsed.Description = metaContent(doc, "meta[name='description']")
+
+	// OpenGraph and Twitter cards
+	og := &models.OpenGraph{
+		Title:       metaContent(doc, "meta[property='og:title']"),
+		Description: metaContent(doc, "meta[property='og:description']"),
+		Type:        metaContent(doc, "meta[property='og:type']"),
+		URL:         metaContent(doc, "meta[property='og:url']"),
+		Image:       metaContent(doc, "meta[property='og:image']"),
+		SiteName:    metaContent(doc, "meta[property='og:site_name']"),
+		TwitterCard: metaContent(doc, "meta[name='twitter:card']"),
+		TwitterSite: metaContent(doc, "meta[name='twitter:site']"),
+	}
+	if og.Title == "" {
+		og.Title = metaContent(doc, "meta[name='twitter:title']")
+	}
+	if og.Description == "" {
+		og.Description = metaContent(doc, "meta[name='twitter:description']")
+	}
+	if og.Image == "" {
+		og.Image = metaContent(doc, "meta[name='twitter:image']")
+	}
+	if *og != (models.OpenGraph{}) {
+		og.URL = resolveURL(base, og.URL)
+		og.Image = resolveURL(base, og.Image)
+		parsed.OpenGraph = og
+	}
+	if parsed.Description == "" && og.Description != "" {
+		parsed.Description = og.Description
+	}
+
+	// JSON-LD structured data
+	data := &models.StructuredData{}
+	var published, modified string
+	doc.Find("script[type='application/ld+json']").Each(func(_ int, s *goquery.Selection) {
+		var raw interface{}
+		if err := json.Unmarshal([]byte(s.Text()), &raw); err != nil {
+			return
+		}
+		for _, node := range jsonLDNodes(raw) {
+			readJSONLDNode(node, data, parsed, &published, &modified)
+		}
+	})
+	if len(data.Types) > 0 {
+		parsed.StructuredData = data
+	}
+
+	// Dates: the published date comes from JSON-LD, then meta tags; the modified date from meta
+	// tags, then JSON-LD, then visible "Last updated" text
+	if published == "" {
+		published = firstMetaContent(doc,
+			"meta[property='article:published_time']",
+			"meta[name='date']",
+			"meta[name='dcterms.created']",
+			"meta[name='DC.date.created']",
+		)
+	}
+	if m := firstMetaContent(doc,
+		"meta[property='article:modified_time']",
+		"meta[property='og:updated_time']",
+		"meta[name='last-modified']",
+		"meta[name='dcterms.modified']",
+		"meta[name='DC.date.modified']",
+	); m != "" {
+		modified = m
+	}
+	if modified == "" {
+		if match := lastUpdatedPattern.FindStringSubmatch(strings.Join(strings.Fields(doc.Find("body").Text()), " ")); match != nil {
+			modified = match[1]
+		}
+	}
+
+	parsed.PublishedAt = parseDate(published)
+	parsed.ModifiedAt = parseDate(modified)
+}
+
+// maxLanguageLength is the longest language tag the articles table stores.
+const maxLanguageLength = 35
+
+// languageTagPattern matches a BCP 47 language tag: a two or three letter language followed by
+// subtags such as a script, region or variant.
+var languageTagPattern = regexp.MustCompile(`^[A-Za-z]{2,3}(-[A-Za-z0-9]{1,8})*$`)
+
+// normalizeLanguage returns the first language tag of a lang attribute or Content-Language
+// value, which may list several, or "" when it isn't a language tag that fits the column.
+func normalizeLanguage(value string) string {
+	tags := strings.FieldsFunc(value, func(r rune) bool { return r == ',' || r == ';' || unicode.IsSpace(r) })
+	if len(tags) == 0 {
+		return ""
+	}
+	tag := strings.ReplaceAll(tags[0], "_", "-")
+	if len(tag) > maxLanguageLength || !languageTagPattern.MatchString(tag) {
+		return ""
+	}
+	return tag
+}
+
+// readJSONLDNode copies the fields of a single JSON-LD node that we know how to use.
+func readJSONLDNode(node map[string]interface{}, data *models.StructuredData, parsed *ParsedContent, published, modified *string) {
+	types := jsonLDTypes(node)
+	data.Types = appendUnique(data.Types, types...)
+
+	for _, t := range types {
+		switch t {
+		case "Article", "TechArticle", "NewsArticle", "BlogPosting", "WebPage":
+			if headline := jsonString(node["headline"]); headline != "" && data.Headline == "" {
+				data.Headline = headline
+			}
+			if description := jsonString(node["description"]); description != "" && parsed.Description == "" {
+				parsed.Description = description
+			}
+			if lang := normalizeLanguage(jsonString(node["inLanguage"])); lang != "" && parsed.Language == "" {
+				parsed.Language = lang
+			}
+			if author := jsonLDName(node["author"]); author != "" && parsed.Author == "" {
+				parsed.Author = author
+			}
+			if d := jsonString(node["datePublished"]); d != "" && *published == "" {
+				*published = d
+			}
+			if d := jsonString(node["dateModified"]); d != "" && *modified == "" {
+				*modified = d
+			}
+
+		case "FAQPage":
+			for _, entity := range jsonList(node["mainEntity"]) {
+				question, ok := entity.(map[string]interface{})
+				if !ok {
+					continue
+				}
+				answer, _ := question["acceptedAnswer"].(map[string]interface{})
+				data.FAQ = append(data.FAQ, models.FAQEntry{
+					Question: textOf(jsonString(question["name"])),
+					Answer:   textOf(jsonString(answer["text"])),
+				})
+			}
+
+		case "HowTo":
+			data.HowToSteps = append(data.HowToSteps, howToSteps(node["step"])...)
+
+		case "BreadcrumbList":
+			data.Breadcrumbs = breadcrumbNames(node["itemListElement"])
+		}
+	}
+}
+
+// howToSteps flattens HowTo steps, including steps grouped into HowToSections.
+func howToSteps(value interface{}) []models.HowToStep {
+	var steps []models.HowToStep
+	for _, item := range jsonList(value) {
+		switch step := item.(type) {
+		case string:
+			steps = append(steps, models.HowToStep{Text: textOf(step)})
+		case map[string]interface{}:
+			if contains(jsonLDTypes(step), "HowToSection") {
+				steps = append(steps, howToSteps(step["itemListElement"])...)
+				continue
+			}
+			steps = append(steps, models.HowToStep{
+				Name: textOf(jsonString(step["name"])),
+				Text: textOf(jsonString(step["text"])),
+			})
+		}
+	}
+	return steps
+}
+
+// breadcrumbNames returns the names of a BreadcrumbList in position order.
+func breadcrumbNames(value interface{}) []string {
+	type crumb struct {
+		position float64
+		name     string
+	}
+
+	var crumbs []crumb
+	for i, item := range jsonList(value) {
+		element, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		name := jsonString(element["name"])
+		if name == "" {
+			name = jsonLDName(element["item"])
+		}
+		if name == "" {
+			continue
+		}
+		position, ok := element["position"].(float64)
+		if !ok {
+			position = float64(i + 1)
+		}
+		crumbs = append(crumbs, crumb{position: position, name: textOf(name)})
+	}
+
+	sort.SliceStable(crumbs, func(i, j int) bool { return crumbs[i].position < crumbs[j].position })
+
+	names := make([]string, 0, len(crumbs))
+	for _, c := range crumbs {
+		names = append(names, c.name)
+	}
+	return names
+}
+
+// jsonLDNodes returns every typed node in a JSON-LD document, unwrapping arrays and @graph.
+func jsonLDNodes(raw interface{}) []map[string]interface{} {
+	var nodes []map[string]interface{}
+	switch v := raw.(type) {
+	case []interface{}:
+		for _, item := range v {
+			nodes = append(nodes, jsonLDNodes(item)...)
+		}
+	case map[string]interface{}:
+		if graph, ok := v["@graph"]; ok {
+			nodes = append(nodes, jsonLDNodes(graph)...)
+		}
+		if _, ok := v["@type"]; ok {
+			nodes = append(nodes, v)
+		}
+	}
+	return nodes
+}
+
+// jsonLDTypes returns the @type of a node, which may be a single string or a list.
+func jsonLDTypes(node map[string]interface{}) []string {
+	var types []string
+	for _, t := range jsonList(node["@type"]) {
+		if s, ok := t.(string); ok {
+			types = append(types, s)
+		}
+	}
+	return types
+}
+
+// jsonLDName returns a name from a value that is either a plain string or an object with a name.
+func jsonLDName(value interface{}) string {
+	for _, item := range jsonList(value) {
+		switch v := item.(type) {
+		case string:
+			return v
+		case map[string]interface{}:
+			if name := jsonString(v["name"]); name != "" {
+				return name
+			}
+		}
+	}
+	return ""
+}
+
+// jsonList wraps a single JSON value in a slice so lists and scalars can be handled alike.
+func jsonList(value interface{}) []interface{} {
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case []interface{}:
+		return v
+	default:
+		return []interface{}{v}
+	}
+}
+
+func jsonString(value interface{}) string {
+	s, _ := value.(string)
+	return strings.TrimSpace(s)
+}
+
+// textOf strips markup from JSON-LD text values, which often contain HTML.
+func textOf(value string) string {
+	if !strings.Contains(value, "<") {
+		return value
+	}
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(value))
+	if err != nil {
+		return value
+	}
+	return strings.Join(strings.Fields(doc.Text()), " ")
+}
+
+func metaContent(doc *goquery.Document, selector string) string {
+	content, _ := doc.Find(selector).First().Attr("content")
+	return strings.TrimSpace(content)
+}
+
+func firstMetaContent(doc *goquery.Document, selectors ...string) string {
+	for _, selector := range selectors {
+		if content := metaContent(doc, selector); content != "" {
+			return content
+		}
+	}
+	return ""
+}
+
+// resolveURL makes href absolute against base, returning href unchanged when either can't be parsed.
+func resolveURL(base *url.URL, href string) string {
+	if href == "" || base == nil {
+		return href
+	}
+	ref, err := url.Parse(href)
+	if err != nil {
+		return href
+	}
+	return base.ResolveReference(ref).String()
+}
+
+// parseDate tries each known layout and returns nil when none match.
+func parseDate(value string) *time.Time {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil
+	}
+
+	// Also try without the abbreviation dot in dates like "Jan. 2, 2006"
+	for _, candidate := range []string{value, strings.Replace(value, ".", "", 1)} {
+		for _, layout := range dateLayouts {
+			if t, err := time.Parse(layout, candidate); err == nil {
+				t = t.UTC()
+				return &t
+			}
+		}
+	}
+	return nil
+}
+
+func appendUnique(list []string, values ...string) []string {
+	for _, v := range values {
+		if !contains(list, v) {
+			list = append(list, v)
+		}
+	}
+	return list
+}
+
+func contains(list []string, value string) bool {
+	for _, v := range list {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
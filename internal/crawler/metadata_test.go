@@ -0,0 +1,183 @@
+package crawler
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/romangod6/kb-crawler/internal/models"
+)
+
+func TestNormalizeLanguage(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{value: "en", want: "en"},
+		{value: " en-US ", want: "en-US"},
+		{value: "zh-Hant-TW", want: "zh-Hant-TW"},
+		{value: "en_GB", want: "en-GB"},
+		{value: "de, en", want: "de"},
+		{value: "fr;q=0.8", want: "fr"},
+		{value: "", want: ""},
+		{value: "english", want: ""},
+		{value: "{{ page.lang }}", want: ""},
+		{value: "en-" + strings.Repeat("abcdefgh-", 4) + "x", want: ""},
+	}
+	for _, tt := range tests {
+		if got := normalizeLanguage(tt.value); got != tt.want {
+			t.Errorf("normalizeLanguage(%q) = %q, want %q", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestParseDate(t *testing.T) {
+	tests := []struct {
+		value string
+		want  time.Time
+	}{
+		{value: "2024-03-05T10:30:00Z", want: time.Date(2024, 3, 5, 10, 30, 0, 0, time.UTC)},
+		{value: "2024-03-05T10:30:00+02:00", want: time.Date(2024, 3, 5, 8, 30, 0, 0, time.UTC)},
+		{value: "2024-03-05T10:30:00+0200", want: time.Date(2024, 3, 5, 8, 30, 0, 0, time.UTC)},
+		{value: "2024-03-05 10:30:00", want: time.Date(2024, 3, 5, 10, 30, 0, 0, time.UTC)},
+		{value: "2024-03-05", want: time.Date(2024, 3, 5, 0, 0, 0, 0, time.UTC)},
+		{value: "Tue, 05 Mar 2024 10:30:00 GMT", want: time.Date(2024, 3, 5, 10, 30, 0, 0, time.UTC)},
+		{value: "March 5, 2024", want: time.Date(2024, 3, 5, 0, 0, 0, 0, time.UTC)},
+		{value: "Mar. 5, 2024", want: time.Date(2024, 3, 5, 0, 0, 0, 0, time.UTC)},
+		{value: "5 March 2024", want: time.Date(2024, 3, 5, 0, 0, 0, 0, time.UTC)},
+		{value: "03/05/2024", want: time.Date(2024, 3, 5, 0, 0, 0, 0, time.UTC)},
+		{value: "3/5/2024", want: time.Date(2024, 3, 5, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		got := parseDate(tt.value)
+		if got == nil || !got.Equal(tt.want) {
+			t.Errorf("parseDate(%q) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+
+	for _, value := range []string{"", "yesterday", "2024-13-45"} {
+		if got := parseDate(value); got != nil {
+			t.Errorf("parseDate(%q) = %v, want nil", value, got)
+		}
+	}
+}
+
+func TestExtractMetadata(t *testing.T) {
+	tests := []struct {
+		name string
+		html string
+		want ParsedContent
+	}{
+		{
+			name: "meta tags",
+			html: `<html lang="en-US, de"><head>
+				<link rel="canonical" href="/docs/reset">
+				<meta name="description" content="How to reset a password">
+				<meta property="article:published_time" content="2024-01-10">
+				<meta property="article:modified_time" content="2024-03-05T10:30:00Z">
+			</head><body></body></html>`,
+			want: ParsedContent{
+				CanonicalURL: "https://kb.example.com/docs/reset",
+				Language:     "en-US",
+				Description:  "How to reset a password",
+				PublishedAt:  date(2024, 1, 10),
+				ModifiedAt:   ptrTime(time.Date(2024, 3, 5, 10, 30, 0, 0, time.UTC)),
+			},
+		},
+		{
+			name: "content language when the lang attribute is junk",
+			html: `<html lang="{{lang}}"><head>
+				<meta http-equiv="content-language" content="fr, en">
+			</head><body></body></html>`,
+			want: ParsedContent{Language: "fr"},
+		},
+		{
+			name: "OpenGraph and Twitter fallbacks",
+			html: `<html><head>
+				<meta property="og:title" content="Reset a password">
+				<meta property="og:type" content="article">
+				<meta property="og:url" content="/docs/reset">
+				<meta name="twitter:description" content="Steps to reset a password">
+				<meta name="twitter:image" content="/img/reset.png">
+				<meta name="twitter:card" content="summary">
+			</head><body></body></html>`,
+			want: ParsedContent{
+				Description: "Steps to reset a password",
+				OpenGraph: &models.OpenGraph{
+					Title:       "Reset a password",
+					Description: "Steps to reset a password",
+					Type:        "article",
+					URL:         "https://kb.example.com/docs/reset",
+					Image:       "https://kb.example.com/img/reset.png",
+					TwitterCard: "summary",
+				},
+			},
+		},
+		{
+			name: "JSON-LD graph",
+			html: `<html><head>
+				<meta property="article:published_time" content="2020-01-01">
+				<meta property="article:modified_time" content="2024-03-05">
+				<script type="application/ld+json">{
+					"@context": "https://schema.org",
+					"@graph": [
+						{"@type": ["TechArticle", "WebPage"], "headline": "Reset a password",
+						 "inLanguage": "en-GB", "author": {"@type": "Person", "name": "Support Team"},
+						 "datePublished": "2023-06-01", "dateModified": "2023-07-01"},
+						{"@type": "BreadcrumbList", "itemListElement": [
+							{"position": 2, "name": "Users"},
+							{"position": 1, "item": {"name": "Admin"}}
+						]},
+						{"@type": "FAQPage", "mainEntity": [
+							{"name": "Does the link expire?", "acceptedAnswer": {"text": "<p>After <b>one</b> hour.</p>"}}
+						]}
+					]
+				}</script>
+				<script type="application/ld+json">{"@type": "HowTo", "step": [
+					"Open the console",
+					{"@type": "HowToSection", "itemListElement": [{"name": "Reset", "text": "Select Reset password"}]}
+				]}</script>
+				<script type="application/ld+json">{not json</script>
+			</head><body></body></html>`,
+			want: ParsedContent{
+				Language:    "en-GB",
+				Author:      "Support Team",
+				PublishedAt: date(2023, 6, 1),
+				ModifiedAt:  date(2024, 3, 5),
+				StructuredData: &models.StructuredData{
+					Types:       []string{"TechArticle", "WebPage", "BreadcrumbList", "FAQPage", "HowTo"},
+					Headline:    "Reset a password",
+					Breadcrumbs: []string{"Admin", "Users"},
+					FAQ:         []models.FAQEntry{{Question: "Does the link expire?", Answer: "After one hour."}},
+					HowToSteps:  []models.HowToStep{{Text: "Open the console"}, {Name: "Reset", Text: "Select Reset password"}},
+				},
+			},
+		},
+		{
+			name: "last updated text",
+			html: `<html><body><p>Last updated: Mar. 5, 2024</p></body></html>`,
+			want: ParsedContent{ModifiedAt: date(2024, 3, 5)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := goquery.NewDocumentFromReader(strings.NewReader(tt.html))
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got ParsedContent
+			extractMetadata(doc, "https://kb.example.com/docs/page", &got)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("extractMetadata() =\n%+v\nwant\n%+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func date(year int, month time.Month, day int) *time.Time {
+	return ptrTime(time.Date(year, month, day, 0, 0, 0, 0, time.UTC))
+}
+
+func ptrTime(t time.Time) *time.Time { return &t }
@@ -0,0 +1,82 @@
+package crawler
+
+import (
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gocolly/colly/v2"
+	"github.com/romangod6/kb-crawler/internal/metrics"
+	"github.com/romangod6/kb-crawler/internal/tracing"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// timedTransport records how long page fetches take and traces them. colly's rate limiter
+// waits between OnRequest and sending the request, so timing the callbacks would count the
+// random delay.
+type timedTransport struct {
+	http.RoundTripper
+}
+
+func (t timedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req, span := startFetchSpan(req)
+	start := time.Now()
+	resp, err := t.RoundTripper.RoundTrip(req)
+	if err != nil {
+		metrics.FetchDuration.WithLabelValues(req.URL.Host).Observe(time.Since(start).Seconds())
+		tracing.RecordError(span, err)
+		span.End()
+		return nil, err
+	}
+	span.SetAttributes(semconv.HTTPResponseStatusCode(resp.StatusCode))
+	resp.Body = &timedBody{ReadCloser: resp.Body, host: req.URL.Host, start: start, span: span}
+	return resp, nil
+}
+
+// timedBody completes a fetch's timing and span once colly has read and closed the body.
+type timedBody struct {
+	io.ReadCloser
+	host  string
+	start time.Time
+	span  trace.Span
+	size  int64
+	once  sync.Once
+}
+
+func (b *timedBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.size += int64(n)
+	return n, err
+}
+
+func (b *timedBody) Close() error {
+	b.once.Do(func() {
+		metrics.FetchDuration.WithLabelValues(b.host).Observe(time.Since(b.start).Seconds())
+		b.span.SetAttributes(semconv.HTTPResponseBodySize(int(b.size)))
+		b.span.End()
+	})
+	return b.ReadCloser.Close()
+}
+
+// recordFetch counts a fetched page and its bytes by status and host. Requests that got no
+// response are counted with the status "error".
+func recordFetch(r *colly.Response) {
+	status := "error"
+	if r.StatusCode != 0 {
+		status = strconv.Itoa(r.StatusCode)
+	}
+	host := r.Request.URL.Host
+	metrics.PagesFetched.WithLabelValues(status, host).Inc()
+	metrics.FetchBytes.WithLabelValues(host).Add(float64(len(r.Body)))
+}
+
+// observeQueue updates the queue depth of the crawl: URLs queued and not yet fetched or failed.
+func (c *Crawler) observeQueue() {
+	c.queueMu.Lock()
+	depth := len(c.queued) - c.fetched - c.failed
+	c.queueMu.Unlock()
+	metrics.QueueDepth.WithLabelValues(c.config.ConfigID.String()).Set(float64(max(depth, 0)))
+}
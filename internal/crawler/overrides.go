@@ -0,0 +1,88 @@
+package crawler
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/romangod6/kb-crawler/internal/models"
+	"github.com/romangod6/kb-crawler/internal/urlnorm"
+)
+
+// lastmodLayouts are the W3C datetime forms sitemaps give lastmod in.
+var lastmodLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04Z07:00",
+	"2006-01-02",
+}
+
+// selectURLs returns the pages a run crawls out of the normalized sitemap URLs: the pages the
+// run asked for, or under an incremental crawl the sitemap URLs that are new or modified since
+// the last run. Every sitemap URL is crawled otherwise.
+func (c *Crawler) selectURLs(ctx context.Context, sitemap *models.Sitemap, urls []string, logger *slog.Logger) []string {
+	if len(c.config.URLs) > 0 {
+		requested := make([]string, 0, len(c.config.URLs))
+		seen := make(map[string]bool, len(c.config.URLs))
+		for _, u := range c.config.URLs {
+			loc := urlnorm.Normalize(u)
+			if loc == "" || seen[loc] {
+				continue
+			}
+			seen[loc] = true
+			requested = append(requested, loc)
+		}
+		logger.Info("Crawling the requested URLs only", "urls", len(requested))
+		return requested
+	}
+
+	if c.config.ModifiedSince == nil {
+		return urls
+	}
+
+	refs, err := c.store.ListActiveArticleRefs(ctx, c.config.ConfigID)
+	if err != nil {
+		logger.Error("Failed to load stored articles, crawling every sitemap URL", "error", err)
+		return urls
+	}
+	stored := make(map[string]bool, len(refs))
+	for _, ref := range refs {
+		stored[ref.URL] = true
+	}
+
+	unmodified := make(map[string]bool)
+	for _, entry := range sitemap.URLs {
+		loc := urlnorm.Normalize(entry.Loc)
+		if !stored[loc] {
+			continue
+		}
+		if lastmod, ok := parseLastmod(entry.LastMod); ok && !lastmod.After(*c.config.ModifiedSince) {
+			unmodified[loc] = true
+		}
+	}
+
+	selected := make([]string, 0, len(urls))
+	for _, u := range urls {
+		if !unmodified[u] {
+			selected = append(selected, u)
+		}
+	}
+
+	c.queueMu.Lock()
+	c.unmodified = len(urls) - len(selected)
+	c.queueMu.Unlock()
+	logger.Info("Skipped pages not modified since the last run",
+		"since", *c.config.ModifiedSince, "skipped", len(urls)-len(selected), "urls", len(selected))
+	return selected
+}
+
+// parseLastmod parses a sitemap lastmod; pages without a valid one are always crawled.
+func parseLastmod(value string) (time.Time, bool) {
+	value = strings.TrimSpace(value)
+	for _, layout := range lastmodLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
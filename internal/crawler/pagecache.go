@@ -0,0 +1,117 @@
+package crawler
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+
+	"github.com/gocolly/colly/v2"
+	"github.com/romangod6/kb-crawler/internal/urlnorm"
+)
+
+// PageCache keeps the last fetched copy of each crawled page on the local file system, so dry
+// runs can replay pages instead of fetching them again. Pages are stored by the SHA-256 of
+// their normalized URL, laid out as <dir>/<ab>/<hash>.html.
+type PageCache struct {
+	dir string
+}
+
+// NewPageCache creates the page cache directory if needed.
+func NewPageCache(dir string) (*PageCache, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create page cache directory: %w", err)
+	}
+	return &PageCache{dir: dir}, nil
+}
+
+// Put stores the body of a page, replacing the copy stored before.
+func (p *PageCache) Put(pageURL string, body []byte) error {
+	path := p.path(pageURL)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create page cache directory: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(p.dir, "page-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temp file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	if _, err := tmp.Write(body); err != nil {
+		return fmt.Errorf("failed to write page: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write page: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to store page: %w", err)
+	}
+	return nil
+}
+
+// Get returns the stored body of a page; ok is false when the page is not cached.
+func (p *PageCache) Get(pageURL string) (body []byte, ok bool) {
+	body, err := os.ReadFile(p.path(pageURL))
+	if err != nil {
+		return nil, false
+	}
+	return body, true
+}
+
+func (p *PageCache) path(pageURL string) string {
+	sum := sha256.Sum256([]byte(urlnorm.Normalize(pageURL)))
+	hash := hex.EncodeToString(sum[:])
+	return filepath.Join(p.dir, hash[:2], hash+".html")
+}
+
+// replayTransport answers GET requests for cached pages from the page cache and sends every
+// other request on. replayed counts the pages served from the cache.
+type replayTransport struct {
+	http.RoundTripper
+	cache    *PageCache
+	replayed *atomic.Int64
+}
+
+func (t replayTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodGet {
+		return t.RoundTripper.RoundTrip(req)
+	}
+	body, ok := t.cache.Get(req.URL.String())
+	if !ok {
+		return t.RoundTripper.RoundTrip(req)
+	}
+
+	t.replayed.Add(1)
+	return &http.Response{
+		Status:        "200 OK",
+		StatusCode:    http.StatusOK,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        http.Header{"Content-Type": []string{"text/html; charset=utf-8"}},
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}, nil
+}
+
+// cachePage stores a fetched HTML page in the page cache. Dry runs leave the cache as it is.
+func (c *Crawler) cachePage(r *colly.Response) {
+	if c.config.PageCache == nil || c.config.DryRun || r.StatusCode != http.StatusOK {
+		return
+	}
+	if !strings.Contains(r.Headers.Get("Content-Type"), "html") {
+		return
+	}
+	if err := c.config.PageCache.Put(r.Request.URL.String(), r.Body); err != nil {
+		c.log.Error("Failed to cache page", "url", r.Request.URL.String(), "error", err)
+	}
+}
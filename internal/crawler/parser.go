@@ -4,11 +4,15 @@ package crawler
 import (
 	"bytes"
 	"fmt"
+	"log/slog"
+	"strconv"
 	"strings"
+	"time"
+	"unicode"
 
 	"github.com/PuerkitoBio/goquery"
 	"github.com/gocolly/colly/v2"
-	"github.com/romangod6/kb-crawler/internal/utils"
+	"github.com/romangod6/kb-crawler/internal/models"
 	"golang.org/x/net/html"
 )
 
@@ -19,10 +23,42 @@ type ParsedContent struct {
 	Tags       []string
 	Author     string
 	CategoryID string
+	Sections   []Section
+	Assets     []Asset
+	Links      []Link
+	Confidence float64 // how likely it is that Content holds only the article, from 0 to 1
+
+	CanonicalURL   string
+	Language       string
+	Description    string
+	PublishedAt    *time.Time
+	ModifiedAt     *time.Time
+	OpenGraph      *models.OpenGraph
+	StructuredData *models.StructuredData
+}
+
+// ParseOptions controls how ParseHTMLContent extracts content from a page.
+type ParseOptions struct {
+	// PageURL is the address the content was fetched from, used to resolve relative URLs.
+	PageURL string
+	// Profile selects the content and boilerplate selectors; nil uses the default profile.
+	Profile *Profile
 }
 
+// Section is a heading-delimited slice of the main content.
+type Section struct {
+	Level   int
+	Title   string
+	Anchor  string
+	Content string
+	Parent  int // index of the enclosing section, -1 for top-level sections
+}
+
+// headingSelector matches every heading level considered when building the outline.
+const headingSelector = "h1, h2, h3, h4, h5, h6"
+
 // ExtractProductFeatureTags extracts the ProductFeatureTags from the <meta> tags in the <head> section.
-func ExtractProductFeatureTags(e *colly.HTMLElement, tags *[]string, logger *utils.CrawlerLogger) {
+func ExtractProductFeatureTags(e *colly.HTMLElement, tags *[]string, logger *slog.Logger) {
 	e.DOM.Find("meta[name='ProductFeatureTags']").Each(func(i int, s *goquery.Selection) {
 		content, exists := s.Attr("content")
 		if exists {
@@ -30,7 +66,7 @@ func ExtractProductFeatureTags(e *colly.HTMLElement, tags *[]string, logger *uti
 			for _, pftag := range pftags {
 				pftag = strings.TrimSpace(pftag)
 				if pftag != "" {
-					logger.LogDebug("Found ProductFeatureTag: %s", pftag)
+					logger.Debug("Found ProductFeatureTag", "tag", pftag)
 					*tags = append(*tags, pftag)
 				}
 			}
@@ -38,14 +74,14 @@ func ExtractProductFeatureTags(e *colly.HTMLElement, tags *[]string, logger *uti
 	})
 
 	if len(*tags) > 0 {
-		logger.LogInfo("Found ProductFeatureTags: %v", *tags)
+		logger.Debug("Found ProductFeatureTags", "tags", *tags)
 	} else {
-		logger.LogDebug("No ProductFeatureTags found in meta elements")
+		logger.Debug("No ProductFeatureTags found in meta elements")
 	}
 }
 
 // ParseHTMLContent parses the raw HTML content and extracts relevant information.
-func ParseHTMLContent(content string) (*ParsedContent, error) {
+func ParseHTMLContent(content string, opts ParseOptions) (*ParsedContent, error) {
 	doc, err := goquery.NewDocumentFromReader(strings.NewReader(content))
 	if err != nil {
 		return nil, fmt.Errorf("error parsing HTML: %w", err)
@@ -98,15 +134,27 @@ func ParseHTMLContent(content string) (*ParsedContent, error) {
 		}
 	})
 
-	// Extract main content from the <article> tag or other selectors
-	var mainContent string
-	if article := doc.Find("article"); article.Length() > 0 {
-		mainContent, _ = article.Html()
-	} else {
-		// Fallback to the entire body
-		mainContent, _ = doc.Find("body").Html()
+	// Extract canonical URL, language, dates, OpenGraph and JSON-LD before any markup is stripped
+	extractMetadata(doc, opts.PageURL, parsed)
+
+	profile := opts.Profile
+	if profile == nil {
+		profile, _ = GetProfile(DefaultProfile)
 	}
 
+	// Extract main content using the profile selectors, falling back to the entire body,
+	// and strip the page chrome before anything is rendered
+	main, matched := selectMainContent(doc, profile)
+	main, parsed.Confidence = removeBoilerplate(main, profile, matched)
+
+	// Record images, downloads and embedded videos referenced by the content
+	parsed.Assets = extractAssets(main, opts.PageURL)
+	parsed.Links = extractLinks(main, opts.PageURL)
+
+	// Build the section outline before rendering so generated anchors end up in the content
+	parsed.Sections = extractSections(main)
+
+	mainContent, _ := main.Html()
 	parsed.Content = cleanHTML(mainContent)
 
 	return parsed, nil
@@ -151,3 +199,128 @@ func cleanHTML(content string) string {
 
 	return strings.TrimSpace(cleaned)
 }
+
+// extractSections walks the headings inside the main content and returns them as a flat,
+// document-ordered list of sections. Headings without an id get a generated anchor so that
+// deep links into the stored content resolve.
+func extractSections(main *goquery.Selection) []Section {
+	var sections []Section
+	used := make(map[string]bool)
+
+	// Collect ids already present in the content so generated anchors don't collide
+	main.Find("[id]").Each(func(_ int, s *goquery.Selection) {
+		if id, _ := s.Attr("id"); id != "" {
+			used[id] = true
+		}
+	})
+
+	// stack holds the indexes of the currently open sections, outermost first
+	var stack []int
+
+	main.Find(headingSelector).Each(func(_ int, h *goquery.Selection) {
+		title := strings.Join(strings.Fields(h.Text()), " ")
+		if title == "" {
+			return
+		}
+
+		level, _ := strconv.Atoi(strings.TrimPrefix(goquery.NodeName(h), "h"))
+
+		anchor := headingAnchor(h)
+		if anchor == "" {
+			anchor = uniqueAnchor(slugify(title), used)
+			h.SetAttr("id", anchor)
+		}
+
+		// The section spans every following sibling up to the next heading of the same or higher rank
+		var content strings.Builder
+		h.NextUntil(headingsUpTo(level)).Each(func(_ int, s *goquery.Selection) {
+			if html, err := goquery.OuterHtml(s); err == nil {
+				content.WriteString(html)
+			}
+		})
+
+		sectionHTML := content.String()
+		if sectionHTML != "" {
+			sectionHTML = cleanHTML(sectionHTML)
+		}
+
+		for len(stack) > 0 && sections[stack[len(stack)-1]].Level >= level {
+			stack = stack[:len(stack)-1]
+		}
+
+		parent := -1
+		if len(stack) > 0 {
+			parent = stack[len(stack)-1]
+		}
+
+		sections = append(sections, Section{
+			Level:   level,
+			Title:   title,
+			Anchor:  anchor,
+			Content: sectionHTML,
+			Parent:  parent,
+		})
+		stack = append(stack, len(sections)-1)
+	})
+
+	return sections
+}
+
+// headingAnchor returns the id of the heading itself or of a named anchor placed inside it.
+func headingAnchor(h *goquery.Selection) string {
+	if id, _ := h.Attr("id"); id != "" {
+		return id
+	}
+
+	var anchor string
+	h.Find("a[id], a[name]").EachWithBreak(func(_ int, a *goquery.Selection) bool {
+		if id, _ := a.Attr("id"); id != "" {
+			anchor = id
+		} else if name, _ := a.Attr("name"); name != "" {
+			anchor = name
+		}
+		return anchor == ""
+	})
+
+	return anchor
+}
+
+// headingsUpTo returns a selector matching headings of the given level or higher rank.
+func headingsUpTo(level int) string {
+	selectors := make([]string, 0, level)
+	for i := 1; i <= level; i++ {
+		selectors = append(selectors, "h"+strconv.Itoa(i))
+	}
+	return strings.Join(selectors, ", ")
+}
+
+// slugify turns heading text into a lowercase, hyphen-separated anchor.
+func slugify(text string) string {
+	var b strings.Builder
+	hyphen := false
+	for _, r := range strings.ToLower(text) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+			hyphen = false
+		} else if !hyphen && b.Len() > 0 {
+			b.WriteRune('-')
+			hyphen = true
+		}
+	}
+
+	slug := strings.TrimSuffix(b.String(), "-")
+	if slug == "" {
+		slug = "section"
+	}
+	return slug
+}
+
+// uniqueAnchor appends a numeric suffix to the slug until it no longer collides with a used id.
+func uniqueAnchor(slug string, used map[string]bool) string {
+	anchor := slug
+	for i := 2; used[anchor]; i++ {
+		anchor = fmt.Sprintf("%s-%d", slug, i)
+	}
+	used[anchor] = true
+	return anchor
+}
@@ -0,0 +1,81 @@
+// internal/crawler/profile.go
+package crawler
+
+import (
+	"sort"
+	"strings"
+	"sync"
+)
+
+// DefaultProfile is used when a crawler config does not name an extraction profile.
+const DefaultProfile = "madcap"
+
+// Profile describes how content is extracted from a particular kind of knowledge base.
+type Profile struct {
+	Name string
+	// ContentSelectors are tried in order to find the main content; the body is used when none match.
+	ContentSelectors []string
+	// StripSelectors match page chrome that is removed from the content before it is stored.
+	StripSelectors []string
+}
+
+// commonStripSelectors covers chrome found on most KB sites regardless of the authoring tool.
+var commonStripSelectors = []string{
+	"script", "style", "noscript", "form", "button",
+	"header", "footer", "nav", "aside",
+	"[role='navigation']", "[role='banner']", "[role='contentinfo']", "[role='search']",
+	".breadcrumbs", ".breadcrumb",
+	"[class*='cookie']", "[id*='cookie']", "#onetrust-consent-sdk", ".cc-window",
+	"[class*='feedback']", "[id*='feedback']", ".was-this-helpful", ".helpful",
+}
+
+var (
+	profilesMu sync.RWMutex
+	profiles   = map[string]*Profile{
+		"default": {
+			Name:             "default",
+			ContentSelectors: []string{"article", "main", "div[role='main']"},
+			StripSelectors:   commonStripSelectors,
+		},
+		"madcap": {
+			Name:             "madcap",
+			ContentSelectors: []string{"article", "#mc-main-content", "div[role='main']"},
+			StripSelectors: append([]string{
+				".sidenav", ".sidenav-wrapper", ".sidebarNav", ".off-canvas", ".title-bar", ".nav-search",
+				".topic-toolbar", ".MCBreadcrumbsBox_0", ".MCMiniTocBox_0", ".MCWebHelpFramesetLink",
+			}, commonStripSelectors...),
+		},
+	}
+)
+
+// RegisterProfile adds or replaces an extraction profile.
+func RegisterProfile(profile *Profile) {
+	profilesMu.Lock()
+	defer profilesMu.Unlock()
+	profiles[strings.ToLower(profile.Name)] = profile
+}
+
+// GetProfile looks up an extraction profile by name. An empty name returns the default profile.
+func GetProfile(name string) (*Profile, bool) {
+	if name == "" {
+		name = DefaultProfile
+	}
+
+	profilesMu.RLock()
+	defer profilesMu.RUnlock()
+	profile, ok := profiles[strings.ToLower(name)]
+	return profile, ok
+}
+
+// ProfileNames returns the names of all registered extraction profiles, sorted.
+func ProfileNames() []string {
+	profilesMu.RLock()
+	defer profilesMu.RUnlock()
+
+	names := make([]string, 0, len(profiles))
+	for name := range profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
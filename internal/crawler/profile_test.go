@@ -0,0 +1,31 @@
+package crawler
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestProfiles(t *testing.T) {
+	if profile, ok := GetProfile(""); !ok || profile.Name != DefaultProfile {
+		t.Errorf("GetProfile(\"\") = %v, %v, want the %s profile", profile, ok, DefaultProfile)
+	}
+	if profile, ok := GetProfile("MadCap"); !ok || profile.Name != "madcap" {
+		t.Errorf("GetProfile(\"MadCap\") = %v, %v, want the madcap profile", profile, ok)
+	}
+	if _, ok := GetProfile("zendesk"); ok {
+		t.Error("GetProfile(\"zendesk\") found a profile that isn't registered")
+	}
+
+	RegisterProfile(&Profile{Name: "Confluence", ContentSelectors: []string{"#main-content"}})
+	t.Cleanup(func() {
+		profilesMu.Lock()
+		defer profilesMu.Unlock()
+		delete(profiles, "confluence")
+	})
+	if _, ok := GetProfile("confluence"); !ok {
+		t.Error("registered profile not found")
+	}
+	if got, want := strings.Join(ProfileNames(), ","), "confluence,default,madcap"; got != want {
+		t.Errorf("ProfileNames() = %s, want %s", got, want)
+	}
+}
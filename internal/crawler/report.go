@@ -0,0 +1,64 @@
+package crawler
+
+import (
+	"html/template"
+	"io"
+
+	"github.com/romangod6/kb-crawler/internal/models"
+)
+
+// reportTemplate renders a dry run report as a standalone page, to be downloaded and shared.
+var reportTemplate = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Dry run of {{.Product}}</title>
+<style>
+body { font-family: sans-serif; margin: 2em; color: #222; }
+table { border-collapse: collapse; width: 100%; margin-bottom: 2em; }
+th, td { border: 1px solid #ddd; padding: 4px 8px; text-align: left; vertical-align: top; }
+th { background: #f4f4f4; }
+.counts td { font-weight: bold; }
+</style>
+</head>
+<body>
+<h1>Dry run of {{.Product}}</h1>
+<p>Run {{.RunID}} with the {{.Profile}} profile, {{.StartedAt.Format "2006-01-02 15:04:05"}} to {{.FinishedAt.Format "2006-01-02 15:04:05"}}.
+{{.FetchedPages}} pages fetched, {{.ReplayedPages}} replayed from the page cache.</p>
+<table class="counts">
+<tr><th>New</th><th>Updated</th><th>Unchanged</th><th>Deleted</th><th>Extraction failures</th><th>Unmatched category paths</th></tr>
+<tr><td>{{len .New}}</td><td>{{len .Updated}}</td><td>{{len .Unchanged}}</td><td>{{len .Deleted}}</td><td>{{len .Failures}}</td><td>{{len .UnmatchedCategories}}</td></tr>
+</table>
+{{define "articles"}}{{if .}}<table>
+<tr><th>URL</th><th>Title</th><th>Category</th></tr>
+{{range .}}<tr><td><a href="{{.URL}}">{{.URL}}</a></td><td>{{.Title}}</td><td>{{.Category}}</td></tr>
+{{end}}</table>{{else}}<p>None.</p>{{end}}{{end}}
+<h2>New articles</h2>
+{{template "articles" .New}}
+<h2>Updated articles</h2>
+{{template "articles" .Updated}}
+<h2>Deleted articles</h2>
+{{if .Deleted}}<table>
+<tr><th>URL</th><th>Reason</th></tr>
+{{range .Deleted}}<tr><td><a href="{{.URL}}">{{.URL}}</a></td><td>{{.Reason}}</td></tr>
+{{end}}</table>{{else}}<p>None.</p>{{end}}
+<h2>Extraction failures</h2>
+{{if .Failures}}<table>
+<tr><th>URL</th><th>Stage</th><th>Status</th><th>Error</th></tr>
+{{range .Failures}}<tr><td><a href="{{.URL}}">{{.URL}}</a></td><td>{{.Stage}}</td><td>{{if .StatusCode}}{{.StatusCode}}{{end}}</td><td>{{.Error}}</td></tr>
+{{end}}</table>{{else}}<p>None.</p>{{end}}
+<h2>Unmatched category paths</h2>
+{{if .UnmatchedCategories}}<table>
+<tr><th>Path</th><th>Pages</th><th>Examples</th></tr>
+{{range .UnmatchedCategories}}<tr><td>{{.Path}}</td><td>{{.Pages}}</td><td>{{range .URLs}}<a href="{{.}}">{{.}}</a><br>{{end}}</td></tr>
+{{end}}</table>{{else}}<p>None.</p>{{end}}
+<h2>Unchanged articles</h2>
+{{template "articles" .Unchanged}}
+</body>
+</html>
+`))
+
+// WriteReportHTML renders a dry run report as an HTML page.
+func WriteReportHTML(w io.Writer, report *models.DryRunReport) error {
+	return reportTemplate.Execute(w, report)
+}
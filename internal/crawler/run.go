@@ -0,0 +1,124 @@
+package crawler
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/romangod6/kb-crawler/internal/metrics"
+	"github.com/romangod6/kb-crawler/internal/models"
+	"github.com/romangod6/kb-crawler/internal/storage"
+	"github.com/romangod6/kb-crawler/internal/tracing"
+	"github.com/romangod6/kb-crawler/internal/utils"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// runLogTail is the number of log records kept on a crawler config after each run.
+const runLogTail = 50
+
+// Run is one crawl of a crawler config, recorded in the runs table together with the path of
+// its log file.
+type Run struct {
+	*models.CrawlRun
+	Logger *utils.RunLogger
+	store  storage.Store
+	span   trace.Span
+}
+
+// StartRun records a new run of a crawler config under runID, with the overrides it was
+// triggered with, and creates its logger, which carries the run ID, config ID and product on
+// every record. The run is traced in a trace of its own, linked to the span in ctx such as the
+// API request that started it.
+func StartRun(ctx context.Context, store storage.Store, opts utils.LogOptions, config *models.CrawlerConfig,
+	runID uuid.UUID, runOpts *models.RunOptions) (*Run, error) {
+	run := &models.CrawlRun{
+		ID:        runID,
+		Product:   config.Product,
+		Status:    models.RunRunning,
+		Options:   runOpts,
+		StartedAt: time.Now(),
+	}
+	if config.ID != uuid.Nil {
+		run.ConfigID = &config.ID
+	}
+
+	logger, err := utils.NewRunLogger(opts, config.Product, run.ID.String(),
+		"config_id", config.ID.String(), "product", config.Product)
+	if err != nil {
+		return nil, err
+	}
+	run.LogPath = logger.Path()
+
+	ctx, span := tracer.Start(ctx, "crawl.run",
+		trace.WithNewRoot(),
+		trace.WithLinks(trace.LinkFromContext(ctx)),
+		trace.WithAttributes(
+			attribute.String("crawl.run_id", run.ID.String()),
+			attribute.String("crawl.config_id", config.ID.String()),
+			attribute.String("crawl.product", config.Product),
+			attribute.String("crawl.sitemap_url", config.SitemapURL)))
+
+	if err := store.CreateCrawlRun(ctx, run); err != nil {
+		tracing.RecordError(span, err)
+		span.End()
+		logger.Close()
+		return nil, err
+	}
+
+	metrics.ActiveRuns.Inc()
+	return &Run{CrawlRun: run, Logger: logger, store: store, span: span}, nil
+}
+
+// Context returns ctx carrying the run's span, so the work of the run is traced under it.
+func (r *Run) Context(ctx context.Context) context.Context {
+	return trace.ContextWithSpan(ctx, r.span)
+}
+
+// Finish records the run's outcome, copies the tail of its log and the summary onto the
+// config, and closes the log.
+func (r *Run) Finish(ctx context.Context, config *models.CrawlerConfig, summary *models.RunSummary, runErr error) {
+	now := time.Now()
+	r.Status = models.RunCompleted
+	r.Summary = summary
+	r.FinishedAt = &now
+	if runErr != nil {
+		r.Status = models.RunFailed
+		if errors.Is(runErr, context.Canceled) {
+			r.Status = models.RunCancelled
+		}
+		r.Error = runErr.Error()
+	}
+
+	metrics.ActiveRuns.Dec()
+	metrics.Runs.WithLabelValues(r.Status).Inc()
+	metrics.RunDuration.Observe(now.Sub(r.StartedAt).Seconds())
+
+	r.Logger.Info("Run finished", "status", r.Status, "duration", now.Sub(r.StartedAt).Round(time.Second).String())
+	if err := r.store.FinishCrawlRun(r.Context(ctx), r.CrawlRun); err != nil {
+		r.Logger.Error("Failed to record run outcome", "error", err)
+	}
+
+	if tail, err := utils.ReadLog(r.LogPath, slog.LevelInfo, runLogTail); err != nil {
+		r.Logger.Error("Failed to read run log", "error", err)
+	} else {
+		config.Logs = tail
+	}
+	config.LastRunID = &r.ID
+	if summary != nil {
+		config.LastRunSummary = summary
+	}
+
+	r.span.SetAttributes(attribute.String("crawl.status", r.Status))
+	if summary != nil {
+		r.span.SetAttributes(
+			attribute.Int("crawl.queued_urls", summary.QueuedURLs),
+			attribute.Int("crawl.filtered_urls", summary.FilteredURLs))
+	}
+	tracing.RecordError(r.span, runErr)
+	r.span.End()
+
+	r.Logger.Close()
+}
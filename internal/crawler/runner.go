@@ -0,0 +1,194 @@
+package crawler
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/romangod6/kb-crawler/internal/blobstore"
+	"github.com/romangod6/kb-crawler/internal/events"
+	"github.com/romangod6/kb-crawler/internal/models"
+	"github.com/romangod6/kb-crawler/internal/storage"
+	"github.com/romangod6/kb-crawler/internal/utils"
+)
+
+// Runner runs crawls of crawler configs with the dependencies shared by every run. Crawls are
+// started through the job queue, which calls Run.
+type Runner struct {
+	Store      storage.Store
+	LogOptions utils.LogOptions
+	// AssetStore, Notifier and Events are passed to each crawler; they may be nil
+	AssetStore *blobstore.Store
+	// AssetHosts are hosts assets may be downloaded from besides each config's allowed domains
+	AssetHosts []string
+	Notifier   ChangeNotifier
+	Events     *events.Bus
+	// Distributed queues each crawl's pages for worker processes when set
+	Distributed *DistributedOptions
+	// PageCache keeps the pages crawls fetch for dry runs to replay; may be nil
+	PageCache *PageCache
+}
+
+// Run runs one crawl of a config as the run runID, with its own log. opts may override what
+// the run crawls; nil crawls the whole sitemap. The config's status, summary and log tail are
+// updated with the run's, except after a dry run, which leaves the config as it was and stores
+// a report of what would have changed on the run instead. Its settings are never written, so
+// edits made during the crawl are kept for the next run.
+func (r *Runner) Run(ctx context.Context, config *models.CrawlerConfig, runID uuid.UUID, opts *models.RunOptions) error {
+	if opts == nil {
+		opts = &models.RunOptions{}
+	}
+
+	// Record the run and create its logger, which is passed through the crawler
+	run, err := StartRun(ctx, r.Store, r.LogOptions, config, runID, opts)
+	if err != nil {
+		log.Printf("Failed to start run: %v", err)
+		return fmt.Errorf("failed to start run: %w", err)
+	}
+	ctx = run.Context(ctx)
+	logger := run.Logger
+
+	logger.Info("Starting crawler",
+		"sitemap_url", config.SitemapURL,
+		"map_url", config.MapURL,
+		"max_depth", config.MaxDepth,
+		"user_agent", config.UserAgent,
+		"crawl_interval", config.CrawlInterval,
+		"default_category", config.DefaultCategory,
+		"allowed_domains", config.AllowedDomains,
+		"profile", config.Profile,
+		"mode", opts.Mode,
+		"urls", len(opts.URLs),
+		"dry_run", opts.DryRun,
+		"replay", opts.Replay)
+	for i, filter := range config.URLFilters {
+		logger.Info("URL filter", "position", i+1, "rule", filter.String())
+	}
+
+	// Set default MapURL if not provided
+	if config.MapURL == "" {
+		config.MapURL = DefaultMapURL(config.SitemapURL)
+		logger.Info("Set default Map URL", "map_url", config.MapURL)
+	}
+
+	crawlerConfig := RunConfig(*config, *opts)
+	crawlerConfig.Logger = logger.Logger
+	crawlerConfig.RunID = run.ID
+	crawlerConfig.Events = r.Events
+	crawlerConfig.AssetStore = r.AssetStore
+	crawlerConfig.AssetHosts = r.AssetHosts
+	crawlerConfig.Notifier = r.Notifier
+	crawlerConfig.PageCache = r.PageCache
+	if opts.Replay && r.PageCache == nil {
+		logger.Warn("No page cache is configured, fetching every page of the replay")
+	}
+	// Workers store what they fetch, so dry runs fetch their pages here
+	if !opts.DryRun {
+		crawlerConfig.Distributed = r.Distributed
+	}
+	crawlerInstance := NewCrawler(r.Store, crawlerConfig)
+
+	// Update status to Running
+	config.Status = models.ConfigRunning
+	if err := r.updateConfig(ctx, config, opts); err != nil {
+		logger.Error("Failed to update crawler status", "error", err)
+		run.Finish(ctx, config, nil, err)
+		return fmt.Errorf("failed to update crawler status: %w", err)
+	}
+
+	// Workers build their crawlers from the config as the run started, so edits made during
+	// the run apply to the next one on every process alike
+	if crawlerConfig.Distributed != nil {
+		if err := r.Store.SetRunConfig(ctx, run.ID, config); err != nil {
+			config.Status = models.ConfigError
+			config.Errors = append(config.Errors, err.Error())
+			logger.Error("Failed to store the run's config for workers", "error", err)
+			run.Finish(ctx, config, nil, err)
+			r.updateConfig(ctx, config, opts)
+			return fmt.Errorf("failed to store the run's config: %w", err)
+		}
+	}
+
+	logger.Info("Beginning category structure mapping")
+	categoryStructure, err := crawlerInstance.MapCategoryStructure(ctx)
+	if err != nil {
+		config.Status = models.ConfigError
+		config.Errors = append(config.Errors, err.Error())
+		logger.Error("Failed to map category structure", "error", err)
+		run.Finish(ctx, config, nil, err)
+		r.updateConfig(ctx, config, opts)
+		return fmt.Errorf("failed to map category structure: %w", err)
+	}
+	logger.Info("Starting crawl process")
+	err = crawlerInstance.Crawl(ctx, categoryStructure)
+	now := time.Now()
+
+	summary := crawlerInstance.Summary()
+	if opts.DryRun {
+		r.saveReport(ctx, crawlerInstance, config, run)
+	}
+
+	if err != nil {
+		config.Status = models.ConfigError
+		config.Errors = append(config.Errors, err.Error())
+		logger.Error("Crawl failed", "error", err)
+	} else {
+		config.Status = models.ConfigCompleted
+		config.LastRun = &now
+	}
+
+	config.IsFirstRun = false
+	config.UpdatedAt = now
+
+	logger.Info("Crawler execution finished", "status", config.Status)
+	for _, err := range config.Errors {
+		logger.Error("Error encountered during crawl", "error", err)
+	}
+	run.Finish(ctx, config, &summary, err)
+
+	if updateErr := r.updateConfig(ctx, config, opts); updateErr != nil {
+		log.Printf("Error updating crawler status for %s: %v", config.Product, updateErr)
+	}
+
+	if err != nil {
+		return fmt.Errorf("crawl failed: %w", err)
+	}
+
+	return nil
+}
+
+// updateConfig stores the config's status and run details. The scheduler moves the next run on
+// when it queues the config. Dry runs don't touch the config, and configs that are not stored,
+// such as one read from a file, have nothing to update.
+func (r *Runner) updateConfig(ctx context.Context, config *models.CrawlerConfig, opts *models.RunOptions) error {
+	if opts.DryRun || config.ID == uuid.Nil {
+		return nil
+	}
+	return r.Store.UpdateCrawlerConfigRun(ctx, config)
+}
+
+// saveReport stores what a dry run found on its run, so it outlives the crawler.
+func (r *Runner) saveReport(ctx context.Context, c *Crawler, config *models.CrawlerConfig, run *Run) {
+	logger := run.Logger
+	report := c.DryRunReport()
+	report.Product = config.Product
+	logger.Info("Dry run report",
+		"new", len(report.New),
+		"updated", len(report.Updated),
+		"unchanged", len(report.Unchanged),
+		"deleted", len(report.Deleted),
+		"failures", len(report.Failures),
+		"unmatched_categories", len(report.UnmatchedCategories))
+	if err := r.Store.SetRunReport(ctx, run.ID, report); err != nil {
+		logger.Error("Failed to store dry run report", "error", err)
+	}
+}
+
+// DefaultMapURL returns the map URL of a config that has none: the home page of a MadCap Flare
+// site, next to its sitemap.
+func DefaultMapURL(sitemapURL string) string {
+	return strings.Replace(sitemapURL, "Sitemap.xml", "0HOME/Home.htm", 1)
+}
@@ -0,0 +1,69 @@
+// internal/crawler/tombstone.go
+package crawler
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"github.com/google/uuid"
+	"github.com/romangod6/kb-crawler/internal/models"
+)
+
+// markSaved records that an article was stored under a URL during this crawl.
+func (c *Crawler) markSaved(articleURL string) {
+	c.queueMu.Lock()
+	defer c.queueMu.Unlock()
+	c.saved[articleURL] = true
+}
+
+// tombstoneMissing soft-deletes the config's articles whose pages vanished from the source, as
+// found by missingArticles. It returns the number of tombstoned articles.
+func (c *Crawler) tombstoneMissing(ctx context.Context) (int, error) {
+	refs, err := c.store.ListActiveArticleRefs(ctx, c.config.ConfigID)
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for reason, missing := range c.missingArticles(refs) {
+		ids := make([]uuid.UUID, 0, len(missing))
+		for _, ref := range missing {
+			ids = append(ids, ref.ID)
+		}
+		changes, err := c.store.TombstoneArticles(ctx, ids, reason)
+		if err != nil {
+			return count, err
+		}
+		count += len(changes)
+		c.notify(changes...)
+	}
+	return count, nil
+}
+
+// missingArticles returns the stored articles whose pages vanished from the source, by reason:
+// those answering 404 or 410 this crawl, and those that are no longer in the sitemap and were
+// not reached by following links either. Articles saved during this crawl are always kept, as
+// are pages that failed for other reasons.
+func (c *Crawler) missingArticles(refs []*models.ArticleRef) map[string][]*models.ArticleRef {
+	c.queueMu.Lock()
+	defer c.queueMu.Unlock()
+	c.statusMu.Lock()
+	defer c.statusMu.Unlock()
+
+	missing := make(map[string][]*models.ArticleRef)
+	for _, ref := range refs {
+		if c.saved[ref.URL] {
+			continue
+		}
+
+		switch status := c.fetchStatus[ref.URL]; {
+		case status == http.StatusNotFound || status == http.StatusGone:
+			reason := "http_" + strconv.Itoa(status)
+			missing[reason] = append(missing[reason], ref)
+		case !c.sitemapURLs[ref.URL] && !c.queued[ref.URL] && !c.rejected[ref.URL]:
+			missing["not_in_sitemap"] = append(missing["not_in_sitemap"], ref)
+		}
+	}
+	return missing
+}
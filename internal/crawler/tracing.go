@@ -0,0 +1,76 @@
+package crawler
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gocolly/colly/v2"
+	"github.com/romangod6/kb-crawler/internal/tracing"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("github.com/romangod6/kb-crawler/internal/crawler")
+
+// startPageSpan starts the span covering one page, from its request until it has been scraped
+// or failed. Spans are kept by request ID because colly shares a request's context with the
+// links it visits. The span's trace context rides on the request headers down to the
+// transport, which strips it before the request leaves.
+func (c *Crawler) startPageSpan(ctx context.Context, r *colly.Request) {
+	ctx, span := tracer.Start(ctx, "crawl.page", trace.WithAttributes(
+		semconv.URLFull(r.URL.String()),
+		attribute.Int("crawl.depth", r.Depth),
+		attribute.String("crawl.run_id", c.config.RunID.String()),
+		attribute.String("crawl.config_id", c.config.ConfigID.String())))
+	c.pageSpans.Store(r.ID, span)
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(*r.Headers))
+}
+
+// pageSpan returns the span of a page, or a span that records nothing.
+func (c *Crawler) pageSpan(r *colly.Request) trace.Span {
+	if span, ok := c.pageSpans.Load(r.ID); ok {
+		return span.(trace.Span)
+	}
+	return trace.SpanFromContext(context.Background())
+}
+
+// pageContext returns a context carrying the page's span, for the work done on the page.
+func (c *Crawler) pageContext(r *colly.Request) context.Context {
+	return trace.ContextWithSpan(context.Background(), c.pageSpan(r))
+}
+
+// endPageSpan ends the span of a page, recording err when the page failed.
+func (c *Crawler) endPageSpan(r *colly.Request, err error) {
+	span, ok := c.pageSpans.LoadAndDelete(r.ID)
+	if !ok {
+		return
+	}
+	s := span.(trace.Span)
+	tracing.RecordError(s, err)
+	s.End()
+}
+
+// startFetchSpan starts the span of one HTTP fetch under the page span whose trace context is
+// in the request's headers, and returns the request without those headers.
+func startFetchSpan(req *http.Request) (*http.Request, trace.Span) {
+	propagator := otel.GetTextMapPropagator()
+	ctx := propagator.Extract(context.Background(), propagation.HeaderCarrier(req.Header))
+	if !trace.SpanContextFromContext(ctx).IsValid() {
+		return req, trace.SpanFromContext(ctx)
+	}
+	req = req.Clone(req.Context())
+	for _, field := range propagator.Fields() {
+		req.Header.Del(field)
+	}
+
+	_, span := tracer.Start(ctx, "http.fetch",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.HTTPRequestMethodKey.String(req.Method),
+			semconv.URLFull(req.URL.String()),
+			semconv.ServerAddress(req.URL.Host)))
+	return req, span
+}
@@ -0,0 +1,199 @@
+package crawler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/romangod6/kb-crawler/internal/models"
+	"github.com/romangod6/kb-crawler/internal/scheduler"
+)
+
+// reachabilityTimeout bounds each request of the optional reachability check.
+const reachabilityTimeout = 10 * time.Second
+
+// FieldErrors maps crawler config fields, by their JSON names, to what is wrong with them.
+type FieldErrors map[string]string
+
+// Error lists the field errors in field order.
+func (e FieldErrors) Error() string {
+	fields := make([]string, 0, len(e))
+	for field := range e {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	messages := make([]string, 0, len(fields))
+	for _, field := range fields {
+		messages = append(messages, field+": "+e[field])
+	}
+	return strings.Join(messages, "; ")
+}
+
+// ValidateOptions selects the optional checks of ValidateConfig.
+type ValidateOptions struct {
+	// CheckReachability fetches the sitemap and map URLs and reports those that don't answer
+	// with a successful status
+	CheckReachability bool
+	// UserAgent is sent with reachability checks
+	UserAgent string
+}
+
+// ValidateConfig checks a crawler config before it is saved: required fields, URL syntax, the
+// schedule, that the allowed domains cover the sitemap and map URLs, that the extraction
+// profile exists, and that patterns and URL filters compile. It returns nil when the config is
+// valid.
+func ValidateConfig(ctx context.Context, config *models.CrawlerConfig, opts ValidateOptions) FieldErrors {
+	errs := FieldErrors{}
+
+	if strings.TrimSpace(config.Product) == "" {
+		errs["product"] = "is required"
+	}
+
+	sitemap, err := parseConfigURL(config.SitemapURL)
+	switch {
+	case config.SitemapURL == "":
+		errs["sitemapUrl"] = "is required"
+	case err != nil:
+		errs["sitemapUrl"] = err.Error()
+	}
+
+	var mapURL *url.URL
+	if config.MapURL != "" {
+		if mapURL, err = parseConfigURL(config.MapURL); err != nil {
+			errs["mapUrl"] = err.Error()
+		}
+	}
+
+	if config.CrawlInterval != "" {
+		if _, err := scheduler.Parse(config.CrawlInterval); err != nil {
+			errs["crawlInterval"] = err.Error()
+		}
+	}
+
+	if config.MaxDepth < 0 {
+		errs["maxDepth"] = "must not be negative"
+	}
+
+	validateDomains(config.AllowedDomains, sitemap, mapURL, errs)
+
+	if _, ok := GetProfile(config.Profile); !ok {
+		errs["profile"] = fmt.Sprintf("unknown profile %q; known profiles are %s", config.Profile, strings.Join(ProfileNames(), ", "))
+	}
+
+	if config.DuplicatePolicy != "" && config.DuplicatePolicy != models.DuplicatePolicyLink {
+		errs["duplicatePolicy"] = fmt.Sprintf("unknown duplicate policy %q", config.DuplicatePolicy)
+	}
+	if _, err := CompilePatterns(config.IncludePatterns); err != nil {
+		errs["includePatterns"] = err.Error()
+	}
+	if _, err := CompilePatterns(config.ExcludePatterns); err != nil {
+		errs["excludePatterns"] = err.Error()
+	}
+	if _, err := NewURLFilterSet(config.URLFilters, nil, nil); err != nil {
+		errs["urlFilters"] = err.Error()
+	}
+
+	// Only URLs that passed the checks above are fetched
+	if opts.CheckReachability {
+		targets := map[string]string{}
+		if _, bad := errs["sitemapUrl"]; !bad && sitemap != nil {
+			targets["sitemapUrl"] = config.SitemapURL
+		}
+		if _, bad := errs["mapUrl"]; !bad && mapURL != nil {
+			targets["mapUrl"] = config.MapURL
+		}
+		for field, message := range checkReachable(ctx, targets, opts.UserAgent) {
+			errs[field] = message
+		}
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs
+}
+
+// parseConfigURL parses a URL of a crawler config, which must be absolute http(s).
+func parseConfigURL(raw string) (*url.URL, error) {
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("must be an absolute http or https URL")
+	}
+	return u, nil
+}
+
+// validateDomains checks that allowed domains are bare host names and, when there are any,
+// that they include the hosts of the sitemap and map URLs, which would be refused otherwise.
+func validateDomains(domains []string, sitemap, mapURL *url.URL, errs FieldErrors) {
+	if len(domains) == 0 {
+		return
+	}
+
+	allowed := make(map[string]bool, len(domains))
+	for _, domain := range domains {
+		if domain == "" || strings.ContainsAny(domain, "/:?#@ ") {
+			errs["allowedDomains"] = fmt.Sprintf("%q is not a host name; give hosts without scheme, port or path", domain)
+			return
+		}
+		allowed[strings.ToLower(domain)] = true
+	}
+
+	var missing []string
+	for _, u := range []*url.URL{sitemap, mapURL} {
+		if u != nil && !allowed[strings.ToLower(u.Hostname())] {
+			missing = append(missing, u.Hostname())
+		}
+	}
+	if len(missing) > 0 {
+		errs["allowedDomains"] = fmt.Sprintf("must include %s, the host of the sitemap or map URL", strings.Join(missing, " and "))
+	}
+}
+
+// checkReachable fetches each URL and reports the fields whose URL failed or answered with an
+// error status.
+func checkReachable(ctx context.Context, targets map[string]string, userAgent string) FieldErrors {
+	client := &http.Client{Timeout: reachabilityTimeout}
+	errs := FieldErrors{}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+
+	for field, target := range targets {
+		wg.Add(1)
+		go func(field, target string) {
+			defer wg.Done()
+
+			message := ""
+			req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+			if err == nil {
+				if userAgent != "" {
+					req.Header.Set("User-Agent", userAgent)
+				}
+				var resp *http.Response
+				if resp, err = client.Do(req); err == nil {
+					resp.Body.Close()
+					if resp.StatusCode >= 400 {
+						message = "is not reachable: " + resp.Status
+					}
+				}
+			}
+			if err != nil {
+				message = "is not reachable: " + err.Error()
+			}
+
+			if message != "" {
+				mu.Lock()
+				errs[field] = message
+				mu.Unlock()
+			}
+		}(field, target)
+	}
+
+	wg.Wait()
+	return errs
+}
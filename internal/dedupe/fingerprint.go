@@ -0,0 +1,105 @@
+// Package dedupe fingerprints article text to find articles published more than once, either
+// word for word or with small edits.
+package dedupe
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"hash/fnv"
+	"math/bits"
+	"strings"
+	"unicode"
+
+	"github.com/romangod6/kb-crawler/internal/textdiff"
+)
+
+// shingleSize is the number of consecutive words hashed together for SimHash. Shingles keep
+// some word order, so articles that share a vocabulary but not sentences stay apart.
+const shingleSize = 3
+
+// DefaultMaxDistance is the largest SimHash Hamming distance at which two articles count as
+// near-duplicates.
+const DefaultMaxDistance = 3
+
+// Fingerprint identifies an article's text. TextHash matches exact duplicates after
+// normalization; SimHash matches near-duplicates.
+type Fingerprint struct {
+	TextHash string
+	SimHash  uint64
+}
+
+// IsZero reports whether the fingerprint is of an empty text.
+func (f Fingerprint) IsZero() bool {
+	return f.TextHash == ""
+}
+
+// Compute fingerprints an HTML article body. Markup, case, punctuation and whitespace are
+// ignored, so the same article under different templates gets the same fingerprint.
+func Compute(body string) Fingerprint {
+	words := normalize(textdiff.PlainText(body))
+	if len(words) == 0 {
+		return Fingerprint{}
+	}
+
+	sum := sha256.Sum256([]byte(strings.Join(words, " ")))
+	return Fingerprint{
+		TextHash: hex.EncodeToString(sum[:]),
+		SimHash:  simHash(words),
+	}
+}
+
+// mix spreads FNV's output over all 64 bits (the splitmix64 finalizer); shingles that differ
+// only at the end otherwise hash to values sharing many bits.
+func mix(h uint64) uint64 {
+	h ^= h >> 30
+	h *= 0xbf58476d1ce4e5b9
+	h ^= h >> 27
+	h *= 0x94d049bb133111eb
+	h ^= h >> 31
+	return h
+}
+
+// Distance returns the Hamming distance between two SimHashes.
+func Distance(a, b uint64) int {
+	return bits.OnesCount64(a ^ b)
+}
+
+// normalize lowercases a text and splits it into words, dropping punctuation.
+func normalize(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+}
+
+// simHash sums the hashes of the text's word shingles bit by bit; each bit of the result is
+// set when more shingles had it set than not.
+func simHash(words []string) uint64 {
+	var weights [64]int
+	add := func(shingle string) {
+		h := fnv.New64a()
+		h.Write([]byte(shingle))
+		sum := mix(h.Sum64())
+		for bit := 0; bit < 64; bit++ {
+			if sum&(1<<bit) != 0 {
+				weights[bit]++
+			} else {
+				weights[bit]--
+			}
+		}
+	}
+
+	if len(words) < shingleSize {
+		add(strings.Join(words, " "))
+	}
+	for i := 0; i+shingleSize <= len(words); i++ {
+		add(strings.Join(words[i:i+shingleSize], " "))
+	}
+
+	var hash uint64
+	for bit, weight := range weights {
+		if weight > 0 {
+			hash |= 1 << bit
+		}
+	}
+	return hash
+}
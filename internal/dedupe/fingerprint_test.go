@@ -0,0 +1,128 @@
+package dedupe
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+const article = `<p>To reset your password, open the sign-in page and select Forgot password.
+Enter the email address of your account and we will send you a link. The link expires after
+one hour; request a new one if it has expired. Choose a password of at least twelve characters
+that you have not used before, then sign in with it on every device.</p>
+<p>If the email doesn't arrive within a few minutes, check your spam or junk folder and make sure
+that messages from our support address are allowed. Accounts managed by your organization can't
+reset their own password; ask your administrator to send you a reset link from the admin console
+instead. After a reset, every session on other devices is signed out, and apps connected with the
+old password must be reconnected. Two-step verification stays turned on, so keep your phone or
+security key at hand when you sign in again.</p>
+<p>For security reasons our support team can't tell you your current password or set a new one for
+you. If you no longer have access to the email address of your account, contact support with your
+account name and a recent invoice number so that we can verify who you are.</p>`
+
+func TestDistance(t *testing.T) {
+	tests := []struct {
+		a, b uint64
+		want int
+	}{
+		{a: 0, b: 0, want: 0},
+		{a: 0b1011, b: 0b1011, want: 0},
+		{a: 0b1011, b: 0b0011, want: 1},
+		{a: 0, b: 0xff, want: 8},
+		{a: 0, b: ^uint64(0), want: 64},
+		{a: 1 << 63, b: 1, want: 2},
+	}
+	for _, tt := range tests {
+		if got := Distance(tt.a, tt.b); got != tt.want {
+			t.Errorf("Distance(%#x, %#x) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+		if got := Distance(tt.b, tt.a); got != tt.want {
+			t.Errorf("Distance(%#x, %#x) = %d, want %d", tt.b, tt.a, got, tt.want)
+		}
+	}
+}
+
+func TestComputeSimHashDistance(t *testing.T) {
+	tests := []struct {
+		name      string
+		other     string
+		sameText  bool
+		duplicate bool
+	}{
+		{
+			name:      "identical",
+			other:     article,
+			sameText:  true,
+			duplicate: true,
+		},
+		{
+			name:      "different markup, case and whitespace",
+			other:     "<div><h2>" + strings.ToUpper(article[3:40]) + "</h2>\n\n" + article[40:len(article)-4] + "</div>",
+			sameText:  true,
+			duplicate: true,
+		},
+		{
+			name:      "one word replaced",
+			other:     strings.Replace(article, "Two-step", "Multi-factor", 1),
+			duplicate: true,
+		},
+		{
+			name: "unrelated article",
+			other: `<p>Invoices are issued on the first day of each month and list every subscription on
+the account. Download them as PDF from the billing page, or have them sent to a finance contact by
+adding their address under notification settings. Taxes are shown per line item.</p>`,
+		},
+	}
+
+	fp := Compute(article)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			other := Compute(tt.other)
+			if got := other.TextHash == fp.TextHash; got != tt.sameText {
+				t.Errorf("same text hash = %v, want %v", got, tt.sameText)
+			}
+			distance := Distance(fp.SimHash, other.SimHash)
+			if got := distance <= DefaultMaxDistance; got != tt.duplicate {
+				t.Errorf("SimHash distance %d within %d = %v, want %v", distance, DefaultMaxDistance, got, tt.duplicate)
+			}
+		})
+	}
+}
+
+func TestComputeEmpty(t *testing.T) {
+	for _, body := range []string{"", "<p></p>", "<p> ... !</p>"} {
+		if fp := Compute(body); !fp.IsZero() {
+			t.Errorf("Compute(%q) = %+v, want a zero fingerprint", body, fp)
+		}
+	}
+}
+
+func TestIndexMatch(t *testing.T) {
+	canonical, nearCopy := uuid.New(), uuid.New()
+	index := NewIndex(DefaultMaxDistance)
+	index.Add(canonical, "https://kb.example.com/a", Compute(article))
+	index.Add(nearCopy, "https://kb.example.com/b", Compute(strings.Replace(article, "Two-step", "Multi-factor", 1)))
+
+	tests := []struct {
+		name   string
+		url    string
+		body   string
+		want   uuid.UUID
+		wantOK bool
+	}{
+		{name: "exact copy", url: "https://kb.example.com/c", body: article, want: canonical, wantOK: true},
+		{name: "own URL", url: "https://kb.example.com/a", body: article, want: nearCopy, wantOK: true},
+		{name: "words dropped", url: "https://kb.example.com/c", body: strings.Replace(article, "spam or junk", "junk", 1), want: canonical, wantOK: true},
+		{name: "unrelated", url: "https://kb.example.com/c", body: "<p>Billing questions go to the finance team.</p>", wantOK: false},
+		{name: "empty", url: "https://kb.example.com/c", body: "", wantOK: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := index.Match(tt.url, Compute(tt.body))
+			if ok != tt.wantOK || got != tt.want {
+				t.Errorf("Match() = %v, %v, want %v, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
@@ -0,0 +1,201 @@
+package dedupe
+
+import (
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+// bands splits a SimHash into maxDistance+1 bit ranges. Two hashes within maxDistance bits of
+// each other differ in at most maxDistance ranges, so they agree on at least one, and only
+// hashes sharing a range need comparing.
+type bands struct {
+	starts []uint
+	widths []uint
+}
+
+type bandKey struct {
+	band  int
+	value uint64
+}
+
+func newBands(maxDistance int) bands {
+	n := min(max(maxDistance+1, 1), 64)
+	b := bands{}
+	start := uint(0)
+	for i := 0; i < n; i++ {
+		width := uint(64 / n)
+		if i == n-1 {
+			width = 64 - start
+		}
+		b.starts = append(b.starts, start)
+		b.widths = append(b.widths, width)
+		start += width
+	}
+	return b
+}
+
+func (b bands) keys(hash uint64) []bandKey {
+	keys := make([]bandKey, len(b.starts))
+	for i, start := range b.starts {
+		mask := uint64(1)<<b.widths[i] - 1
+		if b.widths[i] == 64 {
+			mask = ^uint64(0)
+		}
+		keys[i] = bandKey{band: i, value: hash >> start & mask}
+	}
+	return keys
+}
+
+// Index finds the earliest added article that a new article duplicates. It is safe for
+// concurrent use.
+type Index struct {
+	maxDistance int
+	bands       bands
+
+	mu      sync.Mutex
+	entries []indexEntry
+	byURL   map[string]int
+	byHash  map[string][]int
+	byBand  map[bandKey][]int
+}
+
+type indexEntry struct {
+	id  uuid.UUID
+	url string
+	fp  Fingerprint
+}
+
+// NewIndex creates an index that matches near-duplicates up to maxDistance bits apart.
+func NewIndex(maxDistance int) *Index {
+	return &Index{
+		maxDistance: maxDistance,
+		bands:       newBands(maxDistance),
+		byURL:       make(map[string]int),
+		byHash:      make(map[string][]int),
+		byBand:      make(map[bandKey][]int),
+	}
+}
+
+// Add records an article as a candidate canonical. Adding a URL again replaces its fingerprint.
+func (i *Index) Add(id uuid.UUID, url string, fp Fingerprint) {
+	if fp.IsZero() {
+		return
+	}
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	pos, ok := i.byURL[url]
+	if ok {
+		i.entries[pos] = indexEntry{id: id, url: url, fp: fp}
+	} else {
+		pos = len(i.entries)
+		i.entries = append(i.entries, indexEntry{id: id, url: url, fp: fp})
+		i.byURL[url] = pos
+	}
+
+	// Stale positions left by a replaced fingerprint are filtered out in Match
+	i.byHash[fp.TextHash] = append(i.byHash[fp.TextHash], pos)
+	for _, key := range i.bands.keys(fp.SimHash) {
+		i.byBand[key] = append(i.byBand[key], pos)
+	}
+}
+
+// Match returns the article an article at url duplicates: the earliest one with the same text,
+// otherwise the closest near-duplicate. An article never matches its own URL.
+func (i *Index) Match(url string, fp Fingerprint) (uuid.UUID, bool) {
+	if fp.IsZero() {
+		return uuid.Nil, false
+	}
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	for _, pos := range i.byHash[fp.TextHash] {
+		entry := i.entries[pos]
+		if entry.url != url && entry.fp.TextHash == fp.TextHash {
+			return entry.id, true
+		}
+	}
+
+	best, bestDistance := -1, i.maxDistance+1
+	for _, key := range i.bands.keys(fp.SimHash) {
+		for _, pos := range i.byBand[key] {
+			entry := i.entries[pos]
+			if entry.url == url {
+				continue
+			}
+			distance := Distance(entry.fp.SimHash, fp.SimHash)
+			if distance < bestDistance || (distance == bestDistance && pos < best) {
+				best, bestDistance = pos, distance
+			}
+		}
+	}
+	if best < 0 {
+		return uuid.Nil, false
+	}
+	return i.entries[best].id, true
+}
+
+// Clusters groups fingerprints that are exact or near-duplicates of each other, directly or
+// through other members, and returns the groups of two or more as indexes into fps.
+func Clusters(fps []Fingerprint, maxDistance int) [][]int {
+	parent := make([]int, len(fps))
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+	union := func(a, b int) {
+		if ra, rb := find(a), find(b); ra != rb {
+			parent[max(ra, rb)] = min(ra, rb)
+		}
+	}
+
+	b := newBands(maxDistance)
+	byHash := make(map[string]int)
+	byBand := make(map[bandKey][]int)
+	for i, fp := range fps {
+		if fp.IsZero() {
+			continue
+		}
+		if first, ok := byHash[fp.TextHash]; ok {
+			union(first, i)
+			continue
+		}
+		byHash[fp.TextHash] = i
+
+		for _, key := range b.keys(fp.SimHash) {
+			for _, j := range byBand[key] {
+				if Distance(fps[j].SimHash, fp.SimHash) <= maxDistance {
+					union(j, i)
+				}
+			}
+			byBand[key] = append(byBand[key], i)
+		}
+	}
+
+	groups := make(map[int][]int)
+	var roots []int
+	for i := range fps {
+		root := find(i)
+		if _, ok := groups[root]; !ok {
+			roots = append(roots, root)
+		}
+		groups[root] = append(groups[root], i)
+	}
+
+	var clusters [][]int
+	for _, root := range roots {
+		if len(groups[root]) > 1 {
+			clusters = append(clusters, groups[root])
+		}
+	}
+	return clusters
+}
@@ -0,0 +1,83 @@
+package dedupe
+
+import (
+	"sort"
+
+	"github.com/google/uuid"
+	"github.com/romangod6/kb-crawler/internal/models"
+)
+
+// Report groups articles into duplicate clusters, largest first. Articles linked to a canonical
+// article are always in its cluster. The canonical article of a cluster is the one the others
+// link to, otherwise the earliest created.
+func Report(articles []*models.ArticleFingerprint, maxDistance int) []*models.DuplicateCluster {
+	fps := make([]Fingerprint, len(articles))
+	for i, article := range articles {
+		fps[i] = Fingerprint{TextHash: article.TextHash, SimHash: uint64(article.SimHash)}
+	}
+
+	// Linked duplicates are grouped by their canonical article's fingerprint, so they stay in its
+	// cluster even when maxDistance is tighter than when they were linked
+	byID := make(map[uuid.UUID]int, len(articles))
+	for i, article := range articles {
+		byID[article.ID] = i
+	}
+	grouping := append([]Fingerprint(nil), fps...)
+	for i, article := range articles {
+		if article.DuplicateOf == nil {
+			continue
+		}
+		if canonical, ok := byID[*article.DuplicateOf]; ok {
+			grouping[i] = fps[canonical]
+		}
+	}
+
+	var clusters []*models.DuplicateCluster
+	for _, members := range Clusters(grouping, maxDistance) {
+		canonical := members[0]
+		for _, i := range members {
+			if articles[i].DuplicateOf != nil {
+				if target, ok := byID[*articles[i].DuplicateOf]; ok {
+					canonical = target
+					break
+				}
+			}
+			if articles[i].CreatedAt.Before(articles[canonical].CreatedAt) {
+				canonical = i
+			}
+		}
+
+		cluster := &models.DuplicateCluster{
+			CanonicalID: articles[canonical].ID,
+			Exact:       true,
+		}
+		for _, i := range members {
+			distance := Distance(fps[i].SimHash, fps[canonical].SimHash)
+			if fps[i].TextHash != fps[canonical].TextHash {
+				cluster.Exact = false
+			} else {
+				distance = 0
+			}
+			cluster.Articles = append(cluster.Articles, &models.DuplicateArticle{
+				ArticleFingerprint: articles[i],
+				Distance:           distance,
+			})
+		}
+
+		// Canonical article first, then the closest copies
+		sort.SliceStable(cluster.Articles, func(a, b int) bool {
+			aCanonical := cluster.Articles[a].ID == cluster.CanonicalID
+			bCanonical := cluster.Articles[b].ID == cluster.CanonicalID
+			if aCanonical != bCanonical {
+				return aCanonical
+			}
+			return cluster.Articles[a].Distance < cluster.Articles[b].Distance
+		})
+		clusters = append(clusters, cluster)
+	}
+
+	sort.SliceStable(clusters, func(a, b int) bool {
+		return len(clusters[a].Articles) > len(clusters[b].Articles)
+	})
+	return clusters
+}
@@ -0,0 +1,106 @@
+// Package events carries live crawl progress from running crawlers to API subscribers.
+package events
+
+import (
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Event types.
+const (
+	MappingStarted  = "mapping.started"
+	MappingFinished = "mapping.finished"
+	URLQueued       = "url.queued"
+	URLRequested    = "url.requested"
+	URLFetched      = "url.fetched"
+	URLSaved        = "url.saved"
+	URLFailed       = "url.failed"
+	ProgressUpdate  = "progress"
+	RunFinished     = "run.finished"
+)
+
+// Event is one step of a crawl.
+type Event struct {
+	Type     string    `json:"type"`
+	ConfigID uuid.UUID `json:"configId"`
+	RunID    uuid.UUID `json:"runId"`
+	URL      string    `json:"url,omitempty"`
+	// StatusCode is the HTTP status of a fetched or failed URL
+	StatusCode int `json:"statusCode,omitempty"`
+	// Status is the final status of a finished run: "completed", "failed" or "cancelled"
+	Status   string    `json:"status,omitempty"`
+	Error    string    `json:"error,omitempty"`
+	Count    int       `json:"count,omitempty"` // categories mapped, for mapping.finished
+	Progress *Progress `json:"progress,omitempty"`
+	Time     time.Time `json:"time"`
+}
+
+// Progress counts a run's URLs so far. ETASeconds estimates the time left from the average
+// time per URL; it is omitted until the first URL is done.
+type Progress struct {
+	Queued     int     `json:"queued"`
+	Fetched    int     `json:"fetched"`
+	Saved      int     `json:"saved"`
+	Failed     int     `json:"failed"`
+	ETASeconds float64 `json:"etaSeconds,omitempty"`
+}
+
+// subscriberBuffer is how many events a subscriber may fall behind before events are dropped
+// for it.
+const subscriberBuffer = 256
+
+// Bus fans events out to the subscribers of each crawler config. Publishing never blocks: a
+// subscriber that falls behind misses events rather than slowing the crawl down.
+type Bus struct {
+	mu   sync.Mutex
+	subs map[uuid.UUID]map[chan Event]struct{}
+}
+
+// NewBus creates an event bus without subscribers.
+func NewBus() *Bus {
+	return &Bus{subs: make(map[uuid.UUID]map[chan Event]struct{})}
+}
+
+// Publish sends an event to the subscribers of its config.
+func (b *Bus) Publish(event Event) {
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for ch := range b.subs[event.ConfigID] {
+		select {
+		case ch <- event:
+		default:
+		}
+	}
+}
+
+// Subscribe returns the events of a config's crawls and a function that ends the
+// subscription.
+func (b *Bus) Subscribe(configID uuid.UUID) (<-chan Event, func()) {
+	ch := make(chan Event, subscriberBuffer)
+
+	b.mu.Lock()
+	if b.subs[configID] == nil {
+		b.subs[configID] = make(map[chan Event]struct{})
+	}
+	b.subs[configID][ch] = struct{}{}
+	b.mu.Unlock()
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			b.mu.Lock()
+			defer b.mu.Unlock()
+			delete(b.subs[configID], ch)
+			if len(b.subs[configID]) == 0 {
+				delete(b.subs, configID)
+			}
+		})
+	}
+}
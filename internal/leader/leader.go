@@ -0,0 +1,130 @@
+// Package leader elects one of the instances sharing a database to hold a role, such as
+// running the scheduler. The role is held as a lock in the database that is released when its
+// holder dies, so another instance takes over on its next campaign.
+package leader
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/romangod6/kb-crawler/internal/models"
+	"github.com/romangod6/kb-crawler/internal/storage"
+)
+
+const (
+	// campaignInterval is how often an instance that isn't the leader tries to take the role
+	campaignInterval = 10 * time.Second
+	// renewInterval is how often the leader confirms it still holds the role
+	renewInterval = 10 * time.Second
+)
+
+// Elector campaigns for a role on behalf of one instance.
+type Elector struct {
+	store storage.Store
+	name  string
+	id    string
+
+	mu sync.RWMutex
+	// leader is the role's record while this instance holds it
+	leader *models.Leader
+}
+
+// New creates an elector for the role name, campaigning as the instance id.
+func New(store storage.Store, name, id string) *Elector {
+	return &Elector{store: store, name: name, id: id}
+}
+
+// ID returns the instance the elector campaigns as.
+func (e *Elector) ID() string {
+	return e.id
+}
+
+// IsLeader reports whether this instance holds the role.
+func (e *Elector) IsLeader() bool {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.leader != nil
+}
+
+// Leader returns the role's current holder as recorded in the database, or nil if the role was
+// never held.
+func (e *Elector) Leader(ctx context.Context) (*models.Leader, error) {
+	return e.store.GetLeader(ctx, e.name)
+}
+
+// Run campaigns for the role until the context is cancelled. Whenever this instance takes the
+// role, lead runs with a context that is cancelled when the role is lost; the role is given up
+// once lead returns.
+func (e *Elector) Run(ctx context.Context, lead func(ctx context.Context)) {
+	ticker := time.NewTicker(campaignInterval)
+	defer ticker.Stop()
+
+	for {
+		leader, lock, err := e.store.TryLeadership(ctx, e.name, e.id)
+		if err != nil && ctx.Err() == nil {
+			slog.Warn("Failed to campaign for leadership", "role", e.name, "instance", e.id, "error", err)
+		}
+		if lock != nil {
+			e.hold(ctx, leader, lock, lead)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// hold runs lead while the role is renewed, and releases the role when either stops.
+func (e *Elector) hold(ctx context.Context, leader *models.Leader, lock storage.LeaderLock, lead func(ctx context.Context)) {
+	slog.Info("Elected leader", "role", e.name, "instance", e.id, "term", leader.Term)
+	e.setLeader(leader)
+	defer e.setLeader(nil)
+
+	leadCtx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		lead(leadCtx)
+	}()
+
+	ticker := time.NewTicker(renewInterval)
+	defer ticker.Stop()
+
+renew:
+	for {
+		select {
+		case <-ctx.Done():
+			break renew
+		case <-done:
+			break renew
+		case <-ticker.C:
+		}
+
+		if err := lock.Renew(ctx); err != nil {
+			if ctx.Err() == nil {
+				slog.Error("Lost leadership", "role", e.name, "instance", e.id, "term", leader.Term, "error", err)
+			}
+			break renew
+		}
+	}
+
+	cancel()
+	<-done
+
+	releaseCtx, releaseCancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+	defer releaseCancel()
+	if err := lock.Release(releaseCtx); err != nil {
+		slog.Warn("Failed to release leadership", "role", e.name, "instance", e.id, "error", err)
+	}
+	slog.Info("Stepped down as leader", "role", e.name, "instance", e.id, "term", leader.Term)
+}
+
+func (e *Elector) setLeader(leader *models.Leader) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.leader = leader
+}
@@ -0,0 +1,73 @@
+// Package metrics defines the crawler's Prometheus metrics. They are registered with the
+// default Prometheus registry, which /metrics serves along with the Go runtime and process
+// metrics.
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	// fetchBuckets cover page fetches from fast cached responses to the 30s request timeout.
+	fetchBuckets = []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30}
+	// runBuckets cover crawl runs from a few seconds to several hours.
+	runBuckets = []float64{10, 30, 60, 300, 900, 1800, 3600, 7200, 14400}
+	// apiBuckets cover API requests.
+	apiBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5}
+)
+
+// Crawler metrics.
+var (
+	PagesFetched = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "kbcrawler_pages_fetched_total",
+		Help: "Pages fetched by the crawler, by HTTP status code (\"error\" when there was no response) and host.",
+	}, []string{"status", "host"})
+	FetchDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "kbcrawler_fetch_duration_seconds",
+		Help:    "Time to fetch a page, from sending the request to reading the whole body, by host.",
+		Buckets: fetchBuckets,
+	}, []string{"host"})
+	FetchBytes = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "kbcrawler_fetch_bytes_total",
+		Help: "Bytes of page bodies downloaded, by host.",
+	}, []string{"host"})
+	Articles = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "kbcrawler_articles_total",
+		Help: "Articles saved, by result: created, updated or unchanged.",
+	}, []string{"result"})
+	ParseFailures = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "kbcrawler_parse_failures_total",
+		Help: "Pages that could not be turned into an article, by reason: parse_error, missing_content or no_category.",
+	}, []string{"reason"})
+	QueueDepth = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "kbcrawler_queue_depth",
+		Help: "URLs queued by a running crawl and not yet fetched or failed, by crawler config.",
+	}, []string{"config_id"})
+)
+
+// Run metrics.
+var (
+	ActiveRuns = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "kbcrawler_active_runs",
+		Help: "Crawl runs in progress.",
+	})
+	Runs = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "kbcrawler_runs_total",
+		Help: "Finished crawl runs, by status: completed, failed or cancelled.",
+	}, []string{"status"})
+	RunDuration = promauto.NewHistogram(prometheus.HistogramOpts{
+		Name:    "kbcrawler_run_duration_seconds",
+		Help:    "Duration of finished crawl runs.",
+		Buckets: runBuckets,
+	})
+)
+
+// API metrics.
+var (
+	APIRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "kbcrawler_api_request_duration_seconds",
+		Help:    "API request latency, by method, route pattern and status code.",
+		Buckets: apiBuckets,
+	}, []string{"method", "route", "status"})
+)
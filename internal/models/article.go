@@ -1,6 +1,11 @@
 package models
 
 import (
+	"crypto/sha256"
+	"database/sql/driver"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
 	"time"
 
 	"github.com/google/uuid"
@@ -15,3 +20,66 @@ func NewArticle() *Article {
 		UpdatedAt: now,
 	}
 }
+
+// ContentHash returns the hex SHA-256 of an article body, used to tell revisions apart.
+func ContentHash(body string) string {
+	sum := sha256.Sum256([]byte(body))
+	return hex.EncodeToString(sum[:])
+}
+
+// BuildSectionTree nests a position-ordered list of sections under their parents and
+// fills in a deep link for each section based on the article URL.
+func BuildSectionTree(articleURL string, sections []*ArticleSection) []*ArticleSection {
+	byID := make(map[uuid.UUID]*ArticleSection, len(sections))
+	for _, section := range sections {
+		section.URL = articleURL + "#" + section.Anchor
+		section.Children = nil
+		byID[section.ID] = section
+	}
+
+	roots := make([]*ArticleSection, 0)
+	for _, section := range sections {
+		if section.ParentID != nil {
+			if parent, ok := byID[*section.ParentID]; ok {
+				parent.Children = append(parent.Children, section)
+				continue
+			}
+		}
+		roots = append(roots, section)
+	}
+
+	return roots
+}
+
+// Value stores the OpenGraph properties as JSON.
+func (o OpenGraph) Value() (driver.Value, error) {
+	return json.Marshal(o)
+}
+
+// Scan reads OpenGraph properties stored as JSON.
+func (o *OpenGraph) Scan(src interface{}) error {
+	return scanJSON(src, o)
+}
+
+// Value stores the structured data as JSON.
+func (d StructuredData) Value() (driver.Value, error) {
+	return json.Marshal(d)
+}
+
+// Scan reads structured data stored as JSON.
+func (d *StructuredData) Scan(src interface{}) error {
+	return scanJSON(src, d)
+}
+
+func scanJSON(src interface{}, dest interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		return nil
+	case []byte:
+		return json.Unmarshal(v, dest)
+	case string:
+		return json.Unmarshal([]byte(v), dest)
+	default:
+		return fmt.Errorf("cannot scan %T into %T", src, dest)
+	}
+}
@@ -0,0 +1,77 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ChangeType is the kind of an article change.
+type ChangeType string
+
+const (
+	ChangeCreated ChangeType = "created"
+	ChangeUpdated ChangeType = "updated"
+	ChangeDeleted ChangeType = "deleted"
+)
+
+// ArticleChange is an entry in the append-only change log. Seq increases monotonically, so
+// consumers can resume from the last sequence number they processed.
+type ArticleChange struct {
+	Seq         int64      `json:"seq"`
+	ArticleID   uuid.UUID  `json:"article_id"`
+	ConfigID    *uuid.UUID `json:"config_id,omitempty"`
+	URL         string     `json:"url"`
+	Type        ChangeType `json:"type"`
+	Version     int        `json:"version,omitempty"`
+	ContentHash string     `json:"content_hash,omitempty"`
+	Reason      string     `json:"reason,omitempty"` // why a deleted article was tombstoned
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+// Event is the webhook event name for the change, such as "article.updated".
+func (c *ArticleChange) Event() string {
+	return "article." + string(c.Type)
+}
+
+// Webhook is a registered endpoint that receives signed change notifications.
+type Webhook struct {
+	ID     uuid.UUID `json:"id"`
+	URL    string    `json:"url"`
+	Secret string    `json:"secret,omitempty"` // HMAC-SHA256 key, only returned when the webhook is created
+	// Events limits deliveries to these events, such as "article.deleted"; empty means all
+	Events    []string  `json:"events"`
+	Active    bool      `json:"active"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Webhook delivery statuses. Deliveries that fail too often are dead-lettered and are only
+// retried on request.
+const (
+	DeliveryPending   = "pending"
+	DeliveryDelivered = "delivered"
+	DeliveryDead      = "dead"
+)
+
+// WebhookDelivery is one attempt-tracked POST of a change to a webhook.
+type WebhookDelivery struct {
+	ID             uuid.UUID       `json:"id"`
+	WebhookID      uuid.UUID       `json:"webhook_id"`
+	ChangeSeq      int64           `json:"change_seq"`
+	Event          string          `json:"event"`
+	Payload        json.RawMessage `json:"payload"`
+	Status         string          `json:"status"`
+	Attempts       int             `json:"attempts"`
+	LastError      string          `json:"last_error,omitempty"`
+	ResponseStatus int             `json:"response_status,omitempty"`
+	NextAttemptAt  time.Time       `json:"next_attempt_at"`
+	DeliveredAt    *time.Time      `json:"delivered_at,omitempty"`
+	CreatedAt      time.Time       `json:"created_at"`
+	UpdatedAt      time.Time       `json:"updated_at"`
+
+	// URL and Secret are the target webhook's, filled in when deliveries are claimed
+	URL    string `json:"-"`
+	Secret string `json:"-"`
+}
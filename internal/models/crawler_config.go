@@ -0,0 +1,65 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+)
+
+// URLFilter is one include or exclude rule applied to URLs before they are queued.
+type URLFilter struct {
+	Action  string `json:"action"` // "include" or "exclude"
+	Type    string `json:"type"`   // "glob" or "regex"
+	Pattern string `json:"pattern"`
+}
+
+// String describes the rule for logs and run summaries.
+func (f URLFilter) String() string {
+	return fmt.Sprintf("%s %s %q", f.Action, f.Type, f.Pattern)
+}
+
+// URLFilters is an ordered list of URL filter rules; the first rule that matches a URL decides.
+type URLFilters []URLFilter
+
+// RunSummary describes the outcome of a crawler config's latest run.
+type RunSummary struct {
+	SitemapURLs  int           `json:"sitemapUrls"`
+	QueuedURLs   int           `json:"queuedUrls"`
+	FilteredURLs int           `json:"filteredUrls"`
+	Filtered     []FilterCount `json:"filtered,omitempty"`
+	// UnmodifiedURLs counts the sitemap URLs an incremental run skipped
+	UnmodifiedURLs int `json:"unmodifiedUrls,omitempty"`
+	// TombstonedArticles counts the articles soft-deleted because their pages vanished
+	TombstonedArticles int `json:"tombstonedArticles"`
+	// LinkedDuplicates counts the articles stored as links to a canonical copy
+	LinkedDuplicates int `json:"linkedDuplicates"`
+}
+
+// FilterCount is the number of URLs a filter rule kept out of a run.
+type FilterCount struct {
+	Rule  string `json:"rule"`
+	Count int    `json:"count"`
+}
+
+// Value stores the filter rules as JSON.
+func (f URLFilters) Value() (driver.Value, error) {
+	if f == nil {
+		return nil, nil
+	}
+	return json.Marshal([]URLFilter(f))
+}
+
+// Scan reads filter rules stored as JSON.
+func (f *URLFilters) Scan(src interface{}) error {
+	return scanJSON(src, f)
+}
+
+// Value stores the run summary as JSON.
+func (s RunSummary) Value() (driver.Value, error) {
+	return json.Marshal(s)
+}
+
+// Scan reads a run summary stored as JSON.
+func (s *RunSummary) Scan(src interface{}) error {
+	return scanJSON(src, s)
+}
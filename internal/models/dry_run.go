@@ -0,0 +1,78 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DryRunReport is what a dry run found: how its config's articles would change had the run
+// stored what it extracted, and the pages and category paths it could not make sense of.
+type DryRunReport struct {
+	RunID    uuid.UUID  `json:"runId"`
+	ConfigID *uuid.UUID `json:"configId,omitempty"`
+	Product  string     `json:"product"`
+	Profile  string     `json:"profile"`
+
+	New       []DryRunArticle `json:"new"`
+	Updated   []DryRunArticle `json:"updated"`
+	Unchanged []DryRunArticle `json:"unchanged"`
+	// Deleted are stored articles a real run would tombstone
+	Deleted  []DryRunArticle `json:"deleted"`
+	Failures []DryRunFailure `json:"failures"`
+	// UnmatchedCategories are category paths resolved from pages that are not in the mapped
+	// category structure; their pages fall back to the default category
+	UnmatchedCategories []UnmatchedCategory `json:"unmatchedCategories"`
+
+	// FetchedPages counts the pages fetched from their site, ReplayedPages those served from
+	// the page cache
+	FetchedPages  int `json:"fetchedPages"`
+	ReplayedPages int `json:"replayedPages"`
+
+	StartedAt  time.Time `json:"startedAt"`
+	FinishedAt time.Time `json:"finishedAt"`
+}
+
+// DryRunArticle is an article a dry run would create, update, leave alone or tombstone.
+type DryRunArticle struct {
+	URL      string `json:"url"`
+	Title    string `json:"title,omitempty"`
+	Category string `json:"category,omitempty"`
+	Reason   string `json:"reason,omitempty"` // why a deleted article would be tombstoned
+}
+
+// Stages at which a dry run can fail to turn a page into an article.
+const (
+	FailureFetch    = "fetch"
+	FailureParse    = "parse"
+	FailureContent  = "content"
+	FailureCategory = "category"
+)
+
+// DryRunFailure is a page a dry run could not turn into an article.
+type DryRunFailure struct {
+	URL        string `json:"url"`
+	Stage      string `json:"stage"`
+	StatusCode int    `json:"statusCode,omitempty"`
+	Error      string `json:"error"`
+}
+
+// UnmatchedCategory is a category path that pages resolved to but the category map lacks.
+type UnmatchedCategory struct {
+	Path  string `json:"path"`
+	Pages int    `json:"pages"`
+	// URLs are the first few pages that resolved to the path
+	URLs []string `json:"urls"`
+}
+
+// Value stores a dry run report as JSON.
+func (r DryRunReport) Value() (driver.Value, error) {
+	return json.Marshal(r)
+}
+
+// Scan reads a dry run report stored as JSON.
+func (r *DryRunReport) Scan(src interface{}) error {
+	return scanJSON(src, r)
+}
@@ -0,0 +1,38 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DuplicatePolicyLink stores an article that duplicates an existing one as a link to it
+// instead of a second copy of the content.
+const DuplicatePolicyLink = "link"
+
+// ArticleFingerprint is the part of an article duplicate detection needs.
+type ArticleFingerprint struct {
+	ID          uuid.UUID  `json:"id"`
+	ConfigID    *uuid.UUID `json:"config_id,omitempty"`
+	Name        string     `json:"name"`
+	URL         string     `json:"url"`
+	TextHash    string     `json:"text_hash"`
+	SimHash     int64      `json:"-"`
+	DuplicateOf *uuid.UUID `json:"duplicate_of,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+// DuplicateCluster is a group of articles with the same or nearly the same text.
+type DuplicateCluster struct {
+	CanonicalID uuid.UUID `json:"canonical_id"`
+	// Exact is true when every member has the same normalized text
+	Exact    bool                `json:"exact"`
+	Articles []*DuplicateArticle `json:"articles"`
+}
+
+// DuplicateArticle is a member of a duplicate cluster.
+type DuplicateArticle struct {
+	*ArticleFingerprint
+	// Distance is the SimHash distance to the canonical article's text, 0 for exact copies
+	Distance int `json:"distance"`
+}
@@ -0,0 +1,54 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Crawl job statuses. A config has at most one queued or running job at a time.
+const (
+	JobQueued    = "queued"
+	JobRunning   = "running"
+	JobCompleted = "completed"
+	JobFailed    = "failed"
+	JobCancelled = "cancelled"
+)
+
+// What submitted a crawl job.
+const (
+	JobTriggerSchedule = "schedule"
+	JobTriggerAPI      = "api"
+	JobTriggerCLI      = "cli"
+)
+
+// Crawl job priorities; higher runs first. Crawls someone asked for go ahead of scheduled ones.
+const (
+	JobPriorityScheduled = 0
+	JobPriorityManual    = 10
+)
+
+// CrawlJob is a request to crawl a config, kept in the job queue until a worker has run it.
+type CrawlJob struct {
+	ID       uuid.UUID `json:"id"`
+	ConfigID uuid.UUID `json:"configId"`
+	Product  string    `json:"product"`
+	Trigger  string    `json:"trigger"`
+	Priority int       `json:"priority"`
+	Status   string    `json:"status"`
+	// Attempts counts claims; a job is claimed again when its worker stops renewing the lease
+	Attempts int         `json:"attempts"`
+	Options  *RunOptions `json:"options,omitempty"`
+	// RunID is assigned when the job is queued; a reclaimed job records a new run
+	RunID      *uuid.UUID `json:"runId,omitempty"`
+	Error      string     `json:"error,omitempty"`
+	LeaseUntil *time.Time `json:"leaseUntil,omitempty"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	StartedAt  *time.Time `json:"startedAt,omitempty"`
+	FinishedAt *time.Time `json:"finishedAt,omitempty"`
+}
+
+// Active reports whether the job is still waiting or running.
+func (j *CrawlJob) Active() bool {
+	return j.Status == JobQueued || j.Status == JobRunning
+}
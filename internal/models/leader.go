@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// Leader records which instance holds a leadership role, such as running the scheduler. Term
+// increases each time the role changes hands, so a stale leader can be told apart from the
+// current one.
+type Leader struct {
+	Name       string    `json:"name"`
+	Holder     string    `json:"holder"`
+	Term       int64     `json:"term"`
+	AcquiredAt time.Time `json:"acquiredAt"`
+	// RenewedAt is when the holder last confirmed it still holds the role
+	RenewedAt time.Time `json:"renewedAt"`
+}
@@ -19,14 +19,113 @@ type Category struct {
 type Article struct {
 	ID         uuid.UUID        `json:"id"`
 	CategoryID uuid.UUID        `json:"category_id"`
+	ConfigID   *uuid.UUID       `json:"config_id,omitempty"` // crawler config that last saved the article
 	Name       string           `json:"name"`
 	Body       string           `json:"body"`
 	URL        string           `json:"url"`
 	Tags       []string         `json:"tags"`
 	Author     string           `json:"author"`
 	Metadata   *json.RawMessage `json:"metadata,omitempty"`
-	CreatedAt  time.Time        `json:"created_at"`
-	UpdatedAt  time.Time        `json:"updated_at"`
+	// ContentConfidence scores how cleanly the body was extracted, from 0 (likely page chrome) to 1.
+	// It is nil for articles saved before extraction was scored.
+	ContentConfidence *float64        `json:"content_confidence,omitempty"`
+	CanonicalURL      string          `json:"canonical_url,omitempty"`
+	Language          string          `json:"language,omitempty"`
+	Description       string          `json:"description,omitempty"`
+	PublishedAt       *time.Time      `json:"published_at,omitempty"`
+	ModifiedAt        *time.Time      `json:"modified_at,omitempty"`
+	OpenGraph         *OpenGraph      `json:"open_graph,omitempty"`
+	StructuredData    *StructuredData `json:"structured_data,omitempty"`
+	// InSitemap is false for pages found only by following links in discovery mode
+	InSitemap bool `json:"in_sitemap"`
+	// DeletedAt is set when the page vanished from the source; the article is kept as a tombstone
+	DeletedAt     *time.Time `json:"deleted_at,omitempty"`
+	DeletedReason string     `json:"deleted_reason,omitempty"` // "not_in_sitemap" or "http_<status>"
+	// TextHash and SimHash fingerprint the normalized text for duplicate detection
+	TextHash string `json:"text_hash,omitempty"`
+	SimHash  int64  `json:"-"`
+	// DuplicateOf links a duplicate to its canonical article; the duplicate's body is not stored
+	DuplicateOf *uuid.UUID `json:"duplicate_of,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+// ArticleRef identifies an article by URL without loading its content.
+type ArticleRef struct {
+	ID           uuid.UUID `json:"id"`
+	URL          string    `json:"url"`
+	CanonicalURL string    `json:"canonical_url,omitempty"`
+	// ContentHash is the hash of the latest revision, when the query loads it
+	ContentHash string    `json:"content_hash,omitempty"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// ArticleVersion is a stored revision of an article's content. A new revision is recorded
+// only when the content hash changes.
+type ArticleVersion struct {
+	ID          uuid.UUID `json:"id"`
+	ArticleID   uuid.UUID `json:"article_id"`
+	Version     int       `json:"version"`
+	ContentHash string    `json:"content_hash"`
+	Name        string    `json:"name"`
+	Body        string    `json:"body,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// ArticleSection is one heading-delimited part of an article's body.
+type ArticleSection struct {
+	ID        uuid.UUID         `json:"id"`
+	ArticleID uuid.UUID         `json:"article_id"`
+	ParentID  *uuid.UUID        `json:"parent_id,omitempty"`
+	Level     int               `json:"level"`
+	Title     string            `json:"title"`
+	Anchor    string            `json:"anchor"`
+	Position  int               `json:"position"`
+	Content   string            `json:"content,omitempty"`
+	URL       string            `json:"url,omitempty"`
+	Children  []*ArticleSection `json:"children,omitempty"`
+	CreatedAt time.Time         `json:"created_at"`
+}
+
+// Asset is a file referenced by one or more articles: an image, a downloadable document or
+// installer, or an embedded video. Downloaded assets are kept in the blob store by SHA256.
+type Asset struct {
+	ID           uuid.UUID  `json:"id"`
+	URL          string     `json:"url"`
+	Kind         string     `json:"kind"` // "image", "document", "archive", "installer", "video"
+	MIMEType     string     `json:"mime_type,omitempty"`
+	AltText      string     `json:"alt_text,omitempty"` // per article, set when listed for an article
+	SHA256       string     `json:"sha256,omitempty"`
+	Size         int64      `json:"size,omitempty"`
+	DownloadedAt *time.Time `json:"downloaded_at,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
+
+// ArticleLink is an outbound link found in an article's content.
+type ArticleLink struct {
+	ID              uuid.UUID  `json:"id"`
+	SourceArticleID uuid.UUID  `json:"source_article_id"`
+	SourceURL       string     `json:"source_url,omitempty"`
+	SourceName      string     `json:"source_name,omitempty"`
+	TargetURL       string     `json:"target_url"`
+	AnchorText      string     `json:"anchor_text"`
+	Internal        bool       `json:"internal"`
+	TargetArticleID *uuid.UUID `json:"target_article_id,omitempty"`
+	Position        int        `json:"position"`
+	StatusCode      int        `json:"status_code,omitempty"`
+	Broken          bool       `json:"broken"`
+	BrokenReason    string     `json:"broken_reason,omitempty"` // "not_in_sitemap" or "http_<status>"
+	CheckedAt       *time.Time `json:"checked_at,omitempty"`
+	CreatedAt       time.Time  `json:"created_at"`
+}
+
+// LinkStatus is the result of checking one internal link target.
+type LinkStatus struct {
+	URL        string
+	StatusCode int
+	Broken     bool
+	Reason     string
 }
 
 type Tag struct {
@@ -40,22 +139,78 @@ type ArticleTag struct {
 	TagID     uuid.UUID `json:"tag_id"`
 }
 
+// Lifecycle statuses of a crawler config. Runs move a config through "Queued", "Running" and
+// "Completed" or "Error".
+const (
+	ConfigDraft     = "Draft"
+	ConfigStopped   = "Stopped"
+	ConfigScheduled = "Scheduled"
+	ConfigQueued    = "Queued"
+	ConfigRunning   = "Running"
+	ConfigCompleted = "Completed"
+	ConfigError     = "Error"
+)
+
 type CrawlerConfig struct {
-	ID              uuid.UUID  `json:"id"`
-	Product         string     `json:"product"`
-	SitemapURL      string     `json:"sitemapUrl"`
-	MapURL          string     `json:"mapUrl"`
-	UserAgent       string     `json:"userAgent"`
-	CrawlInterval   string     `json:"crawlInterval"`
-	MaxDepth        int        `json:"maxDepth"`
-	DefaultCategory string     `json:"defaultCategory"`
-	AllowedDomains  []string   `json:"allowedDomains"`
-	Status          string     `json:"status"` // "Running", "Stopped", "Error", "Completed", "Scheduled"
-	IsFirstRun      bool       `json:"isFirstRun"`
-	LastRun         *time.Time `json:"lastRun,omitempty"`
-	NextRun         *time.Time `json:"nextRun,omitempty"`
-	Errors          []string   `json:"errors,omitempty"`
-	Logs            []string   `json:"logs,omitempty"`
-	CreatedAt       time.Time  `json:"createdAt"`
-	UpdatedAt       time.Time  `json:"updatedAt"`
+	ID              uuid.UUID   `json:"id"`
+	Product         string      `json:"product"`
+	SitemapURL      string      `json:"sitemapUrl"`
+	MapURL          string      `json:"mapUrl"`
+	UserAgent       string      `json:"userAgent"`
+	CrawlInterval   string      `json:"crawlInterval"`
+	MaxDepth        int         `json:"maxDepth"`
+	DefaultCategory string      `json:"defaultCategory"`
+	AllowedDomains  []string    `json:"allowedDomains"`
+	Profile         string      `json:"profile,omitempty"` // extraction profile name, empty for the default
+	DownloadAssets  bool        `json:"downloadAssets"`
+	FollowLinks     bool        `json:"followLinks"`               // discovery mode: follow in-domain links up to MaxDepth
+	IncludePatterns []string    `json:"includePatterns,omitempty"` // regexes a followed link must match, any of
+	ExcludePatterns []string    `json:"excludePatterns,omitempty"` // regexes that stop a link from being followed
+	URLFilters      URLFilters  `json:"urlFilters,omitempty"`      // ordered include/exclude rules for every queued URL
+	DuplicatePolicy string      `json:"duplicatePolicy,omitempty"` // "link" stores duplicates as links to a canonical article
+	Status          string      `json:"status"`                    // "Draft", "Stopped", "Scheduled", "Queued", "Running", "Completed", "Error"
+	Enabled         bool        `json:"enabled"`                   // on the crawl schedule; new configs are drafts until enabled
+	ActiveJob       string      `json:"activeJob,omitempty"`       // "queued" or "running" while a job of the config is live
+	IsFirstRun      bool        `json:"isFirstRun"`
+	LastRun         *time.Time  `json:"lastRun,omitempty"`
+	NextRun         *time.Time  `json:"nextRun,omitempty"`
+	LastRunSummary  *RunSummary `json:"lastRunSummary,omitempty"`
+	LastRunID       *uuid.UUID  `json:"lastRunId,omitempty"`
+	Errors          []string    `json:"errors,omitempty"`
+	Logs            []string    `json:"logs,omitempty"` // tail of the latest run's log
+	CreatedAt       time.Time   `json:"createdAt"`
+	UpdatedAt       time.Time   `json:"updatedAt"`
+}
+
+// OpenGraph holds the OpenGraph and Twitter card properties of a page.
+type OpenGraph struct {
+	Title       string `json:"title,omitempty"`
+	Description string `json:"description,omitempty"`
+	Type        string `json:"type,omitempty"`
+	URL         string `json:"url,omitempty"`
+	Image       string `json:"image,omitempty"`
+	SiteName    string `json:"site_name,omitempty"`
+	TwitterCard string `json:"twitter_card,omitempty"`
+	TwitterSite string `json:"twitter_site,omitempty"`
+}
+
+// StructuredData holds the parts of a page's JSON-LD that are useful for KB articles.
+type StructuredData struct {
+	Types       []string    `json:"types,omitempty"`
+	Headline    string      `json:"headline,omitempty"`
+	Breadcrumbs []string    `json:"breadcrumbs,omitempty"`
+	FAQ         []FAQEntry  `json:"faq,omitempty"`
+	HowToSteps  []HowToStep `json:"howto_steps,omitempty"`
+}
+
+// FAQEntry is one question and answer from an FAQPage.
+type FAQEntry struct {
+	Question string `json:"question"`
+	Answer   string `json:"answer"`
+}
+
+// HowToStep is one step of a HowTo.
+type HowToStep struct {
+	Name string `json:"name,omitempty"`
+	Text string `json:"text"`
 }
@@ -0,0 +1,61 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Crawl run statuses.
+const (
+	RunRunning   = "running"
+	RunCompleted = "completed"
+	RunFailed    = "failed"
+	RunCancelled = "cancelled"
+)
+
+// CrawlRun is one crawl of a crawler config. Its log is kept in a file of its own.
+type CrawlRun struct {
+	ID         uuid.UUID   `json:"id"`
+	ConfigID   *uuid.UUID  `json:"configId,omitempty"`
+	Product    string      `json:"product"`
+	Status     string      `json:"status"`
+	Options    *RunOptions `json:"options,omitempty"`
+	Summary    *RunSummary `json:"summary,omitempty"`
+	Error      string      `json:"error,omitempty"`
+	LogPath    string      `json:"-"`
+	StartedAt  time.Time   `json:"startedAt"`
+	FinishedAt *time.Time  `json:"finishedAt,omitempty"`
+}
+
+// Crawl modes of a run.
+const (
+	// RunModeFull fetches every sitemap URL
+	RunModeFull = "full"
+	// RunModeIncremental skips stored pages whose sitemap lastmod is not after the last run
+	RunModeIncremental = "incremental"
+)
+
+// RunOptions override how a manually triggered run crawls its config.
+type RunOptions struct {
+	Mode string `json:"mode,omitempty"` // RunModeFull when empty
+	// URLs crawls only these pages instead of the sitemap's
+	URLs []string `json:"urls,omitempty"`
+	// DryRun fetches and extracts pages without storing anything, and reports what would
+	// have changed
+	DryRun bool `json:"dryRun,omitempty"`
+	// Replay serves a dry run's pages from the page cache where it has them
+	Replay bool `json:"replay,omitempty"`
+}
+
+// Value stores run options as JSON.
+func (o RunOptions) Value() (driver.Value, error) {
+	return json.Marshal(o)
+}
+
+// Scan reads run options stored as JSON.
+func (o *RunOptions) Scan(src interface{}) error {
+	return scanJSON(src, o)
+}
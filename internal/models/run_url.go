@@ -0,0 +1,51 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Statuses of a URL in a distributed crawl run.
+const (
+	RunURLPending = "pending"
+	RunURLLeased  = "leased"
+	RunURLDone    = "done"
+	RunURLFailed  = "failed"
+)
+
+// RunURL is a page of a distributed crawl run. The coordinator queues the sitemap URLs, workers
+// claim them in batches under a lease and record what they found.
+type RunURL struct {
+	RunID     uuid.UUID `json:"runId"`
+	ConfigID  uuid.UUID `json:"configId"` // filled in when URLs are claimed
+	URL       string    `json:"url"`
+	Depth     int       `json:"depth"`
+	InSitemap bool      `json:"inSitemap"`
+	Status    string    `json:"status"`
+	// Worker holds the lease on a claimed URL until LeaseUntil; it renews the lease while it
+	// works, and the URL is claimed again once the lease runs out
+	Worker     string     `json:"worker,omitempty"`
+	LeaseUntil *time.Time `json:"leaseUntil,omitempty"`
+	Attempts   int        `json:"attempts"`
+	StatusCode int        `json:"statusCode,omitempty"`
+	// SavedURL is the URL the page's article was stored under, its normalized canonical URL
+	SavedURL string `json:"savedUrl,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+// RunURLProgress counts a distributed run's URLs. Leased URLs whose lease ran out after the
+// last attempt count as failed.
+type RunURLProgress struct {
+	Pending int `json:"pending"`
+	Leased  int `json:"leased"`
+	Done    int `json:"done"`
+	Failed  int `json:"failed"`
+	// Live counts the leased URLs whose lease hasn't run out, held by workers that are alive
+	Live int `json:"live"`
+}
+
+// Finished reports whether no URL is left to fetch.
+func (p *RunURLProgress) Finished() bool {
+	return p.Pending == 0 && p.Leased == 0
+}
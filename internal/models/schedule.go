@@ -0,0 +1,27 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ScheduleEntry is a crawler config's place in the crawl schedule.
+type ScheduleEntry struct {
+	ConfigID uuid.UUID `json:"configId"`
+	Product  string    `json:"product"`
+	Schedule string    `json:"schedule"`       // the config's crawlInterval, or the default schedule
+	Kind     string    `json:"kind,omitempty"` // "interval" or "cron"
+	// NextRun is when the schedule is next due; RunAt adds the config's jitter to it
+	NextRun       *time.Time `json:"nextRun,omitempty"`
+	RunAt         *time.Time `json:"runAt,omitempty"`
+	JitterSeconds float64    `json:"jitterSeconds"`
+	LastRun       *time.Time `json:"lastRun,omitempty"`
+	Status        string     `json:"status"`
+	Enabled       bool       `json:"enabled"` // disabled configs are not scheduled
+	Running       bool       `json:"running"`
+	// Missed is set when the run is overdue by more than the grace period, usually because
+	// the service was down when it was due
+	Missed bool   `json:"missed"`
+	Error  string `json:"error,omitempty"` // why the schedule can't be parsed
+}
@@ -0,0 +1,285 @@
+// Package queue runs crawls from the durable job queue in the database. The scheduler and the
+// API submit jobs; each process running a Queue claims them with SKIP LOCKED, so a config is
+// never crawled twice at once and no more than the configured number of crawls run in total.
+package queue
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/romangod6/kb-crawler/internal/models"
+	"github.com/romangod6/kb-crawler/internal/storage"
+	"github.com/romangod6/kb-crawler/internal/tracing"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	pollInterval = 15 * time.Second
+	// jobLease is how long a claimed job stays with its worker without a renewal. Jobs of a
+	// worker that died are claimed again once their lease runs out.
+	jobLease      = 2 * time.Minute
+	renewInterval = jobLease / 4
+)
+
+var tracer = otel.Tracer("github.com/romangod6/kb-crawler/internal/queue")
+
+// RunFunc runs one crawl of a config with the given overrides, recording it as the run runID.
+type RunFunc func(ctx context.Context, config *models.CrawlerConfig, runID uuid.UUID, opts *models.RunOptions) error
+
+// Queue submits crawl jobs and runs the ones it claims.
+type Queue struct {
+	store         storage.Store
+	run           RunFunc
+	maxConcurrent int
+	slots         chan struct{}
+	wake          chan struct{}
+	// renewInterval is how often running jobs renew their leases
+	renewInterval time.Duration
+}
+
+// New creates a queue that runs jobs with run, at most maxConcurrent at a time across every
+// process sharing the database.
+func New(store storage.Store, run RunFunc, maxConcurrent int) *Queue {
+	if maxConcurrent < 1 {
+		maxConcurrent = 1
+	}
+	return &Queue{
+		store:         store,
+		run:           run,
+		maxConcurrent: maxConcurrent,
+		slots:         make(chan struct{}, maxConcurrent),
+		wake:          make(chan struct{}, 1),
+		renewInterval: renewInterval,
+	}
+}
+
+// Submit queues a crawl of config with optional overrides and returns the config's active job,
+// which carries the ID its run will have, and whether it was added. When the config is already
+// queued or running, no new job is added and the existing one is returned.
+func (q *Queue) Submit(ctx context.Context, config *models.CrawlerConfig, trigger string, priority int,
+	opts *models.RunOptions) (*models.CrawlJob, bool, error) {
+	runID := uuid.New()
+	job := &models.CrawlJob{
+		ID:        uuid.New(),
+		ConfigID:  config.ID,
+		Product:   config.Product,
+		Trigger:   trigger,
+		Priority:  priority,
+		Status:    models.JobQueued,
+		Options:   opts,
+		RunID:     &runID,
+		CreatedAt: time.Now(),
+	}
+
+	active, err := q.store.EnqueueCrawlJob(ctx, job)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to enqueue crawl job: %w", err)
+	}
+
+	if active.ID == job.ID {
+		slog.Info("Queued crawl job", "job_id", job.ID.String(), "config_id", config.ID.String(),
+			"product", config.Product, "trigger", trigger, "priority", priority)
+		q.notify()
+	} else {
+		slog.Info("Crawl already queued or running", "job_id", active.ID.String(),
+			"config_id", config.ID.String(), "product", config.Product, "status", active.Status, "trigger", trigger)
+	}
+	return active, active.ID == job.ID, nil
+}
+
+// ErrConfigBusy is returned by RunNow when the config already has a queued or running job.
+var ErrConfigBusy = errors.New("crawler config already has a queued or running crawl job")
+
+// ErrQueueFull is returned by RunNow when the maximum number of crawls is already running.
+var ErrQueueFull = errors.New("the maximum number of concurrent crawls is already running")
+
+// RunNow crawls config in this process right away, as a job that is running from the start,
+// and returns the finished job with the run's error. The job holds the config's place in the
+// queue and renews its lease like a claimed one, so the config is not crawled twice at once.
+// When the config already has a job, or the crawl limit is reached, nothing runs and the
+// active job, if any, is returned with ErrConfigBusy or ErrQueueFull.
+func (q *Queue) RunNow(ctx context.Context, config *models.CrawlerConfig, trigger string,
+	opts *models.RunOptions) (*models.CrawlJob, error) {
+	runID := uuid.New()
+	job := &models.CrawlJob{
+		ID:        uuid.New(),
+		ConfigID:  config.ID,
+		Product:   config.Product,
+		Trigger:   trigger,
+		Priority:  models.JobPriorityManual,
+		Status:    models.JobRunning,
+		Options:   opts,
+		RunID:     &runID,
+		CreatedAt: time.Now(),
+	}
+
+	q.slots <- struct{}{}
+	active, err := q.store.StartCrawlJob(ctx, job, q.maxConcurrent, jobLease)
+	switch {
+	case err != nil:
+		<-q.slots
+		return nil, fmt.Errorf("failed to start crawl job: %w", err)
+	case active == nil:
+		<-q.slots
+		return nil, ErrQueueFull
+	case active.ID != job.ID:
+		<-q.slots
+		return active, ErrConfigBusy
+	}
+
+	slog.Info("Started crawl job", "job_id", active.ID.String(), "config_id", config.ID.String(),
+		"product", config.Product, "trigger", trigger)
+	return active, q.execute(ctx, active)
+}
+
+func (q *Queue) notify() {
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Run claims and runs jobs until the context is cancelled, immediately after Submit queues one
+// or a job finishes, and periodically for jobs queued by other processes. Jobs still running
+// when it returns are cancelled through ctx.
+func (q *Queue) Run(ctx context.Context) {
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		q.claim(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-q.wake:
+		}
+	}
+}
+
+// claim starts jobs while this process has free slots and the queue has work.
+func (q *Queue) claim(ctx context.Context) {
+	for ctx.Err() == nil {
+		select {
+		case q.slots <- struct{}{}:
+		default:
+			return
+		}
+
+		job, err := q.store.ClaimCrawlJob(ctx, q.maxConcurrent, jobLease)
+		if err != nil {
+			slog.Error("Failed to claim crawl job", "error", err)
+		}
+		if job == nil {
+			<-q.slots
+			return
+		}
+
+		go q.execute(ctx, job)
+	}
+}
+
+// execute runs a claimed job, renewing its lease while the crawl runs, records the outcome and
+// returns the run's error.
+func (q *Queue) execute(ctx context.Context, job *models.CrawlJob) error {
+	defer func() {
+		<-q.slots
+		q.notify()
+	}()
+
+	logger := slog.With("job_id", job.ID.String(), "config_id", job.ConfigID.String(), "product", job.Product)
+	if job.Attempts > 1 {
+		logger.Warn("Reclaimed crawl job after its lease expired", "attempt", job.Attempts)
+	}
+
+	// The run gets a trace of its own, linked to this span
+	ctx, span := tracer.Start(ctx, "crawl.job", trace.WithAttributes(
+		attribute.String("crawl.job_id", job.ID.String()),
+		attribute.String("crawl.config_id", job.ConfigID.String()),
+		attribute.String("crawl.trigger", job.Trigger),
+		attribute.Int("crawl.attempt", job.Attempts)))
+	defer span.End()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var lost atomic.Bool
+	go q.renew(ctx, job, cancel, &lost, logger)
+
+	var runErr error
+	config, err := q.store.GetCrawlerConfig(ctx, job.ConfigID)
+	switch {
+	case err != nil:
+		runErr = fmt.Errorf("failed to fetch crawler config: %w", err)
+	case config == nil:
+		runErr = fmt.Errorf("crawler config %s no longer exists", job.ConfigID)
+	default:
+		// A reclaimed job may have left its first run behind, so each attempt records its own
+		if job.RunID == nil || job.Attempts > 1 {
+			runID := uuid.New()
+			job.RunID = &runID
+		}
+		runErr = q.run(ctx, config, *job.RunID, job.Options)
+	}
+
+	now := time.Now()
+	job.FinishedAt = &now
+	switch {
+	case runErr == nil:
+		job.Status = models.JobCompleted
+	case errors.Is(runErr, context.Canceled):
+		job.Status = models.JobCancelled
+		job.Error = runErr.Error()
+	default:
+		job.Status = models.JobFailed
+		job.Error = runErr.Error()
+	}
+	span.SetAttributes(attribute.String("crawl.job_status", job.Status))
+	tracing.RecordError(span, runErr)
+
+	// The job belongs to another worker now
+	if lost.Load() {
+		return runErr
+	}
+	if err := q.store.FinishCrawlJob(context.WithoutCancel(ctx), job); err != nil {
+		logger.Error("Failed to record crawl job outcome", "status", job.Status, "error", err)
+		return runErr
+	}
+	logger.Info("Crawl job finished", "status", job.Status)
+	return runErr
+}
+
+// renew extends the job's lease until ctx is done. If the job is no longer ours, because the
+// lease ran out and another worker claimed it, the run is cancelled.
+func (q *Queue) renew(ctx context.Context, job *models.CrawlJob, cancel context.CancelFunc, lost *atomic.Bool,
+	logger *slog.Logger) {
+	ticker := time.NewTicker(q.renewInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		err := q.store.RenewCrawlJobLease(ctx, job.ID, jobLease)
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			logger.Error("Lost the crawl job's lease; cancelling the run")
+			lost.Store(true)
+			cancel()
+			return
+		case err != nil && ctx.Err() == nil:
+			logger.Warn("Failed to renew crawl job lease", "error", err)
+		}
+	}
+}
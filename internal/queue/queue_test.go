@@ -0,0 +1,159 @@
+package queue
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/romangod6/kb-crawler/internal/models"
+	"github.com/romangod6/kb-crawler/internal/storage"
+)
+
+// fakeStore serves one crawler config and records finished jobs. Methods the queue's execute
+// doesn't use panic through the nil embedded Store.
+type fakeStore struct {
+	storage.Store
+	config   *models.CrawlerConfig
+	renewErr error
+
+	mu       sync.Mutex
+	renewals int
+	finished []*models.CrawlJob
+}
+
+func (s *fakeStore) GetCrawlerConfig(ctx context.Context, id uuid.UUID) (*models.CrawlerConfig, error) {
+	if s.config == nil || s.config.ID != id {
+		return nil, nil
+	}
+	return s.config, nil
+}
+
+func (s *fakeStore) RenewCrawlJobLease(ctx context.Context, id uuid.UUID, lease time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.renewals++
+	return s.renewErr
+}
+
+func (s *fakeStore) FinishCrawlJob(ctx context.Context, job *models.CrawlJob) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.finished = append(s.finished, job)
+	return nil
+}
+
+func TestExecute(t *testing.T) {
+	config := &models.CrawlerConfig{ID: uuid.New(), Product: "kb"}
+	failure := errors.New("site unreachable")
+
+	tests := []struct {
+		name       string
+		configID   uuid.UUID
+		runErr     error
+		wantStatus string
+		wantError  bool
+	}{
+		{name: "completed", configID: config.ID, wantStatus: models.JobCompleted},
+		{name: "run failed", configID: config.ID, runErr: failure, wantStatus: models.JobFailed, wantError: true},
+		{name: "run cancelled", configID: config.ID, runErr: context.Canceled, wantStatus: models.JobCancelled, wantError: true},
+		{name: "config deleted", configID: uuid.New(), wantStatus: models.JobFailed, wantError: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &fakeStore{config: config}
+			var ran bool
+			run := func(ctx context.Context, config *models.CrawlerConfig, runID uuid.UUID, opts *models.RunOptions) error {
+				ran = true
+				return tt.runErr
+			}
+			q := New(store, run, 1)
+			runID := uuid.New()
+			job := &models.CrawlJob{ID: uuid.New(), ConfigID: tt.configID, RunID: &runID, Attempts: 1}
+
+			q.slots <- struct{}{}
+			err := q.execute(context.Background(), job)
+
+			if (err != nil) != tt.wantError {
+				t.Errorf("execute() error = %v, want error = %v", err, tt.wantError)
+			}
+			if ran != (tt.configID == config.ID) {
+				t.Errorf("ran = %v, want it to run only with its config", ran)
+			}
+			if len(store.finished) != 1 || store.finished[0].Status != tt.wantStatus {
+				t.Fatalf("finished jobs = %v, want one %s", store.finished, tt.wantStatus)
+			}
+			if job.FinishedAt == nil || (job.Error != "") != tt.wantError {
+				t.Errorf("FinishedAt, Error = %v, %q, want the outcome recorded", job.FinishedAt, job.Error)
+			}
+			if *job.RunID != runID {
+				t.Errorf("RunID = %s, want the job's own %s", job.RunID, runID)
+			}
+			if len(q.slots) != 0 {
+				t.Error("the job's slot was not released")
+			}
+		})
+	}
+}
+
+func TestExecuteReclaimedJobGetsNewRun(t *testing.T) {
+	config := &models.CrawlerConfig{ID: uuid.New(), Product: "kb"}
+	store := &fakeStore{config: config}
+	var ranAs uuid.UUID
+	run := func(ctx context.Context, config *models.CrawlerConfig, runID uuid.UUID, opts *models.RunOptions) error {
+		ranAs = runID
+		return nil
+	}
+	q := New(store, run, 1)
+	firstRun := uuid.New()
+	job := &models.CrawlJob{ID: uuid.New(), ConfigID: config.ID, RunID: &firstRun, Attempts: 2}
+
+	q.slots <- struct{}{}
+	if err := q.execute(context.Background(), job); err != nil {
+		t.Fatalf("execute() error = %v", err)
+	}
+	if ranAs == firstRun || *job.RunID != ranAs {
+		t.Errorf("ran as %s with job RunID %s, want a new run in place of %s", ranAs, job.RunID, firstRun)
+	}
+}
+
+func TestExecuteLostLease(t *testing.T) {
+	config := &models.CrawlerConfig{ID: uuid.New(), Product: "kb"}
+	store := &fakeStore{config: config, renewErr: sql.ErrNoRows}
+	run := func(ctx context.Context, config *models.CrawlerConfig, runID uuid.UUID, opts *models.RunOptions) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	q := New(store, run, 1)
+	q.renewInterval = time.Millisecond
+	runID := uuid.New()
+	job := &models.CrawlJob{ID: uuid.New(), ConfigID: config.ID, RunID: &runID, Attempts: 1}
+
+	q.slots <- struct{}{}
+	done := make(chan error, 1)
+	go func() { done <- q.execute(context.Background(), job) }()
+
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("execute() error = %v, want the run cancelled", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("the run was not cancelled after the lease was lost")
+	}
+
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	if store.renewals == 0 {
+		t.Error("the lease was never renewed")
+	}
+	if len(store.finished) != 0 {
+		t.Errorf("finished jobs = %v, want the outcome left to the job's new owner", store.finished)
+	}
+	if len(q.slots) != 0 {
+		t.Error("the job's slot was not released")
+	}
+}
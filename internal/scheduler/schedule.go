@@ -0,0 +1,259 @@
+// Package scheduler runs crawler configs on their own schedules. A schedule is either an
+// interval such as "6h" or a cron expression such as "0 2 * * 1-5" (weekdays at 02:00 UTC).
+package scheduler
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule computes when a crawler config is next due.
+type Schedule interface {
+	// Next returns the first time the schedule fires after t.
+	Next(t time.Time) time.Time
+	// Kind is "interval" or "cron".
+	Kind() string
+}
+
+// Parse parses a crawl schedule: a Go duration ("90m", "24h"), "@every <duration>", a cron
+// descriptor (@hourly, @daily, @midnight, @weekly, @monthly, @yearly, @annually) or a five
+// field cron expression (minute hour day-of-month month day-of-week). Cron schedules run in
+// UTC unless the expression starts with TZ=<zone> or CRON_TZ=<zone>.
+func Parse(spec string) (Schedule, error) {
+	spec = strings.TrimSpace(spec)
+	if spec == "" {
+		return nil, fmt.Errorf("empty schedule")
+	}
+
+	if d, err := time.ParseDuration(spec); err == nil {
+		return newInterval(d)
+	}
+	if rest, ok := strings.CutPrefix(spec, "@every "); ok {
+		d, err := time.ParseDuration(strings.TrimSpace(rest))
+		if err != nil {
+			return nil, fmt.Errorf("invalid @every duration: %w", err)
+		}
+		return newInterval(d)
+	}
+
+	return parseCron(spec)
+}
+
+// interval fires a fixed duration after the previous run.
+type interval time.Duration
+
+func newInterval(d time.Duration) (Schedule, error) {
+	if d < time.Minute {
+		return nil, fmt.Errorf("interval %s is shorter than a minute", d)
+	}
+	return interval(d), nil
+}
+
+func (i interval) Next(t time.Time) time.Time { return t.Add(time.Duration(i)) }
+func (i interval) Kind() string               { return "interval" }
+
+// cron is a parsed five field cron expression. Each field is a bit set of allowed values.
+type cron struct {
+	minute, hour, dom, month, dow uint64
+	// domStar and dowStar record a day field starting with *: when both day fields are
+	// restricted a day matches either of them, as in classic cron
+	domStar, dowStar bool
+	loc              *time.Location
+}
+
+var cronDescriptors = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+type cronField struct {
+	name     string
+	min, max int
+	names    map[string]int
+}
+
+var (
+	minuteField = cronField{name: "minute", min: 0, max: 59}
+	hourField   = cronField{name: "hour", min: 0, max: 23}
+	domField    = cronField{name: "day of month", min: 1, max: 31}
+	monthField  = cronField{name: "month", min: 1, max: 12, names: map[string]int{
+		"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+		"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+	}}
+	// Sunday is 0 or 7
+	dowField = cronField{name: "day of week", min: 0, max: 7, names: map[string]int{
+		"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+	}}
+)
+
+func parseCron(spec string) (Schedule, error) {
+	loc := time.UTC
+	if strings.HasPrefix(spec, "TZ=") || strings.HasPrefix(spec, "CRON_TZ=") {
+		zone, rest, _ := strings.Cut(spec, " ")
+		_, name, _ := strings.Cut(zone, "=")
+		l, err := time.LoadLocation(name)
+		if err != nil {
+			return nil, fmt.Errorf("invalid time zone %q: %w", name, err)
+		}
+		loc, spec = l, strings.TrimSpace(rest)
+	}
+
+	if strings.HasPrefix(spec, "@") {
+		expanded, ok := cronDescriptors[strings.ToLower(spec)]
+		if !ok {
+			return nil, fmt.Errorf("unknown schedule descriptor %q", spec)
+		}
+		spec = expanded
+	}
+
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("schedule %q is neither a duration nor a five field cron expression", spec)
+	}
+
+	// As in Vixie cron, a day field counts as unrestricted when it starts with *, so "*/2"
+	// narrows the days the other day field matches rather than adding to them
+	c := &cron{loc: loc, domStar: strings.HasPrefix(fields[2], "*"), dowStar: strings.HasPrefix(fields[4], "*")}
+	var err error
+	if c.minute, err = minuteField.parse(fields[0]); err != nil {
+		return nil, err
+	}
+	if c.hour, err = hourField.parse(fields[1]); err != nil {
+		return nil, err
+	}
+	if c.dom, err = domField.parse(fields[2]); err != nil {
+		return nil, err
+	}
+	if c.month, err = monthField.parse(fields[3]); err != nil {
+		return nil, err
+	}
+	if c.dow, err = dowField.parse(fields[4]); err != nil {
+		return nil, err
+	}
+	if c.dow&(1<<7) != 0 {
+		c.dow |= 1 // 7 is Sunday too
+	}
+	return c, nil
+}
+
+// parse parses a comma-separated list of *, values, ranges and steps into a bit set.
+func (f cronField) parse(expr string) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(expr, ",") {
+		rangePart, stepPart, hasStep := strings.Cut(part, "/")
+
+		step := 1
+		if hasStep {
+			n, err := strconv.Atoi(stepPart)
+			if err != nil || n <= 0 {
+				return 0, fmt.Errorf("invalid step %q in %s field", stepPart, f.name)
+			}
+			step = n
+		}
+
+		lo, hi := f.min, f.max
+		switch {
+		case rangePart == "*":
+		case strings.Contains(rangePart, "-"):
+			a, b, _ := strings.Cut(rangePart, "-")
+			var err error
+			if lo, err = f.value(a); err != nil {
+				return 0, err
+			}
+			if hi, err = f.value(b); err != nil {
+				return 0, err
+			}
+			if lo > hi {
+				return 0, fmt.Errorf("invalid range %q in %s field", rangePart, f.name)
+			}
+		default:
+			v, err := f.value(rangePart)
+			if err != nil {
+				return 0, err
+			}
+			lo = v
+			if !hasStep {
+				hi = v
+			}
+		}
+
+		for v := lo; v <= hi; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+	return bits, nil
+}
+
+func (f cronField) value(s string) (int, error) {
+	if v, ok := f.names[strings.ToLower(s)]; ok {
+		return v, nil
+	}
+	v, err := strconv.Atoi(s)
+	if err != nil || v < f.min || v > f.max {
+		return 0, fmt.Errorf("invalid value %q in %s field (%d-%d)", s, f.name, f.min, f.max)
+	}
+	return v, nil
+}
+
+func (c *cron) Kind() string { return "cron" }
+
+// Next returns the first matching minute after t, searching up to five years ahead. It returns
+// the zero time for expressions that never match, such as 30 February. Times skipped when
+// daylight saving time starts don't fire, and times repeated when it ends fire only once.
+func (c *cron) Next(t time.Time) time.Time {
+	t = t.In(c.loc).Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(5, 0, 0)
+
+	for t.Before(limit) {
+		if c.month&(1<<uint(t.Month())) == 0 {
+			t = wallTime(t.Year(), t.Month()+1, 1, 0, c.loc)
+			continue
+		}
+		if !c.dayMatches(t) {
+			t = wallTime(t.Year(), t.Month(), t.Day()+1, 0, c.loc)
+			continue
+		}
+		if c.hour&(1<<uint(t.Hour())) == 0 {
+			t = wallTime(t.Year(), t.Month(), t.Day(), t.Hour()+1, c.loc)
+			continue
+		}
+		if c.minute&(1<<uint(t.Minute())) == 0 {
+			if t.Minute() == 59 {
+				// Step by the wall clock so that the hour repeated when daylight saving time
+				// ends isn't searched again
+				t = wallTime(t.Year(), t.Month(), t.Day(), t.Hour()+1, c.loc)
+			} else {
+				t = t.Add(time.Minute)
+			}
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
+
+// wallTime returns the start of the given hour in loc, normalizing out-of-range values like
+// time.Date. time.Date maps a time skipped when daylight saving time starts to an instant
+// before the change, which would stall Next; wallTime returns the first instant after it.
+func wallTime(year int, month time.Month, day, hour int, loc *time.Location) time.Time {
+	t := time.Date(year, month, day, hour, 0, 0, 0, loc)
+	want := time.Date(year, month, day, hour, 0, 0, 0, time.UTC)
+	got := time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), 0, 0, time.UTC)
+	return t.Add(want.Sub(got))
+}
+
+func (c *cron) dayMatches(t time.Time) bool {
+	dom := c.dom&(1<<uint(t.Day())) != 0
+	dow := c.dow&(1<<uint(t.Weekday())) != 0
+	if c.domStar || c.dowStar {
+		return dom && dow
+	}
+	return dom || dow
+}
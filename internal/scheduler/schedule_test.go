@@ -0,0 +1,296 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		spec    string
+		kind    string
+		wantErr bool
+	}{
+		{spec: "90m", kind: "interval"},
+		{spec: "1m", kind: "interval"},
+		{spec: "@every 6h", kind: "interval"},
+		{spec: "0 2 * * 1-5", kind: "cron"},
+		{spec: "@daily", kind: "cron"},
+		{spec: "TZ=Europe/Berlin @hourly", kind: "cron"},
+		{spec: "CRON_TZ=America/New_York 0 9 * jan-mar MON", kind: "cron"},
+		{spec: "", wantErr: true},
+		{spec: "30s", wantErr: true},
+		{spec: "@every soon", wantErr: true},
+		{spec: "@fortnightly", wantErr: true},
+		{spec: "0 2 * *", wantErr: true},
+		{spec: "60 * * * *", wantErr: true},
+		{spec: "* 24 * * *", wantErr: true},
+		{spec: "* * 0 * *", wantErr: true},
+		{spec: "* * * 13 *", wantErr: true},
+		{spec: "* * * * 8", wantErr: true},
+		{spec: "* * * foo *", wantErr: true},
+		{spec: "5-1 * * * *", wantErr: true},
+		{spec: "*/0 * * * *", wantErr: true},
+		{spec: "TZ=Mars/Olympus 0 0 * * *", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			schedule, err := Parse(tt.spec)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("Parse(%q) succeeded, want an error", tt.spec)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Parse(%q): %v", tt.spec, err)
+			}
+			if got := schedule.Kind(); got != tt.kind {
+				t.Errorf("Kind() = %q, want %q", got, tt.kind)
+			}
+		})
+	}
+}
+
+func TestIntervalNext(t *testing.T) {
+	schedule, err := Parse("90m")
+	if err != nil {
+		t.Fatal(err)
+	}
+	from := time.Date(2026, 10, 16, 10, 7, 30, 0, time.UTC)
+	if got, want := schedule.Next(from), from.Add(90*time.Minute); !got.Equal(want) {
+		t.Errorf("Next(%v) = %v, want %v", from, got, want)
+	}
+}
+
+func TestCronNext(t *testing.T) {
+	// 16 October 2026 is a Friday
+	tests := []struct {
+		name string
+		spec string
+		from time.Time
+		want time.Time
+	}{
+		{
+			name: "later the same day",
+			spec: "0 2 * * 1-5",
+			from: utc(2026, 10, 16, 1, 59),
+			want: utc(2026, 10, 16, 2, 0),
+		},
+		{
+			name: "weekdays skip the weekend",
+			spec: "0 2 * * 1-5",
+			from: utc(2026, 10, 16, 3, 0),
+			want: utc(2026, 10, 19, 2, 0),
+		},
+		{
+			name: "strictly after a matching time",
+			spec: "0 * * * *",
+			from: utc(2026, 10, 16, 10, 0),
+			want: utc(2026, 10, 16, 11, 0),
+		},
+		{
+			name: "seconds are ignored",
+			spec: "*/15 * * * *",
+			from: time.Date(2026, 10, 16, 10, 7, 30, 0, time.UTC),
+			want: utc(2026, 10, 16, 10, 15),
+		},
+		{
+			name: "list and step",
+			spec: "5,10-20/5 * * * *",
+			from: utc(2026, 10, 16, 10, 11),
+			want: utc(2026, 10, 16, 10, 15),
+		},
+		{
+			name: "names",
+			spec: "0 9 * * mon-fri",
+			from: utc(2026, 10, 17, 0, 0),
+			want: utc(2026, 10, 19, 9, 0),
+		},
+		{
+			name: "month names",
+			spec: "30 4 1 jan,jul *",
+			from: utc(2026, 10, 16, 0, 0),
+			want: utc(2027, 1, 1, 4, 30),
+		},
+		{
+			name: "Sunday as 7",
+			spec: "0 0 * * 7",
+			from: utc(2026, 10, 16, 0, 0),
+			want: utc(2026, 10, 18, 0, 0),
+		},
+		{
+			name: "weekly",
+			spec: "@weekly",
+			from: utc(2026, 10, 16, 0, 0),
+			want: utc(2026, 10, 18, 0, 0),
+		},
+		{
+			name: "monthly",
+			spec: "@monthly",
+			from: utc(2026, 10, 16, 0, 0),
+			want: utc(2026, 11, 1, 0, 0),
+		},
+		{
+			name: "yearly",
+			spec: "@yearly",
+			from: utc(2026, 10, 16, 0, 0),
+			want: utc(2027, 1, 1, 0, 0),
+		},
+		{
+			name: "29 February",
+			spec: "0 0 29 2 *",
+			from: utc(2026, 10, 16, 0, 0),
+			want: utc(2028, 2, 29, 0, 0),
+		},
+		{
+			name: "30 February never matches",
+			spec: "0 0 30 2 *",
+			from: utc(2026, 10, 16, 0, 0),
+			want: time.Time{},
+		},
+
+		// Day of month and day of week
+		{
+			name: "both day fields restricted match either: Friday",
+			spec: "0 0 13 * 5",
+			from: utc(2026, 10, 1, 0, 0),
+			want: utc(2026, 10, 2, 0, 0),
+		},
+		{
+			name: "both day fields restricted match either: the 13th",
+			spec: "0 0 13 * 5",
+			from: utc(2026, 10, 10, 0, 0),
+			want: utc(2026, 10, 13, 0, 0),
+		},
+		{
+			name: "only day of month restricted",
+			spec: "0 0 13 * *",
+			from: utc(2026, 10, 1, 0, 0),
+			want: utc(2026, 10, 13, 0, 0),
+		},
+		{
+			name: "only day of week restricted",
+			spec: "0 0 * * 5",
+			from: utc(2026, 10, 10, 0, 0),
+			want: utc(2026, 10, 16, 0, 0),
+		},
+		{
+			name: "both day fields restricted with a step",
+			spec: "0 0 1-31/10 * 0",
+			from: utc(2026, 10, 1, 0, 0),
+			want: utc(2026, 10, 4, 0, 0),
+		},
+		{
+			name: "starred day of month step",
+			spec: "0 0 */10 * 0",
+			from: utc(2026, 10, 1, 0, 0),
+			want: utc(2026, 10, 11, 0, 0),
+		},
+		{
+			name: "starred day of week step",
+			spec: "0 0 13 * */2",
+			from: utc(2026, 10, 1, 0, 0),
+			want: utc(2026, 10, 13, 0, 0),
+		},
+
+		// Time zones
+		{
+			name: "TZ prefix",
+			spec: "TZ=Europe/Berlin 0 9 * * *",
+			from: utc(2026, 10, 16, 6, 0),
+			want: utc(2026, 10, 16, 7, 0),
+		},
+		{
+			name: "CRON_TZ prefix with a half hour offset",
+			spec: "CRON_TZ=Asia/Kolkata @daily",
+			from: utc(2026, 10, 16, 0, 0),
+			want: utc(2026, 10, 16, 18, 30),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schedule, err := Parse(tt.spec)
+			if err != nil {
+				t.Fatalf("Parse(%q): %v", tt.spec, err)
+			}
+			if got := schedule.Next(tt.from); !got.Equal(tt.want) {
+				t.Errorf("Next(%v) = %v, want %v", tt.from, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCronNextDaylightSaving(t *testing.T) {
+	// In 2026 New York moves from EST (UTC-5) to EDT (UTC-4) at 02:00 on 8 March and back at
+	// 02:00 on 1 November; Santiago moves from UTC-4 to UTC-3 at midnight on 6 September
+	tests := []struct {
+		name string
+		spec string
+		from time.Time
+		want []time.Time
+	}{
+		{
+			name: "skipped time doesn't fire",
+			spec: "TZ=America/New_York 30 2 * * *",
+			from: utc(2026, 3, 7, 17, 0),
+			want: []time.Time{utc(2026, 3, 9, 6, 30), utc(2026, 3, 10, 6, 30)},
+		},
+		{
+			name: "hourly across the skipped hour",
+			spec: "TZ=America/New_York 0 * * * *",
+			from: utc(2026, 3, 8, 5, 30),
+			want: []time.Time{utc(2026, 3, 8, 6, 0), utc(2026, 3, 8, 7, 0), utc(2026, 3, 8, 8, 0)},
+		},
+		{
+			name: "repeated time fires once",
+			spec: "TZ=America/New_York 30 1 * * *",
+			from: utc(2026, 10, 31, 16, 0),
+			want: []time.Time{utc(2026, 11, 1, 5, 30), utc(2026, 11, 2, 6, 30)},
+		},
+		{
+			name: "hourly across the repeated hour",
+			spec: "TZ=America/New_York 0 * * * *",
+			from: utc(2026, 11, 1, 4, 30),
+			want: []time.Time{utc(2026, 11, 1, 5, 0), utc(2026, 11, 1, 7, 0), utc(2026, 11, 1, 8, 0)},
+		},
+		{
+			name: "minutes across the repeated hour",
+			spec: "TZ=America/New_York */30 * * * *",
+			from: utc(2026, 11, 1, 5, 45),
+			want: []time.Time{utc(2026, 11, 1, 7, 0), utc(2026, 11, 1, 7, 30)},
+		},
+		{
+			name: "skipped midnight",
+			spec: "TZ=America/Santiago @daily",
+			from: utc(2026, 9, 5, 16, 0),
+			want: []time.Time{utc(2026, 9, 7, 3, 0), utc(2026, 9, 8, 3, 0)},
+		},
+		{
+			name: "day after a skipped midnight",
+			spec: "TZ=America/Santiago 30 9 * * *",
+			from: utc(2026, 9, 5, 16, 0),
+			want: []time.Time{utc(2026, 9, 6, 12, 30), utc(2026, 9, 7, 12, 30)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schedule, err := Parse(tt.spec)
+			if err != nil {
+				t.Fatalf("Parse(%q): %v", tt.spec, err)
+			}
+			from := tt.from
+			for _, want := range tt.want {
+				got := schedule.Next(from)
+				if !got.Equal(want) {
+					t.Fatalf("Next(%v) = %v, want %v", from.UTC(), got.UTC(), want)
+				}
+				from = got
+			}
+		})
+	}
+}
+
+func utc(year int, month time.Month, day, hour, min int) time.Time {
+	return time.Date(year, month, day, hour, min, 0, 0, time.UTC)
+}
@@ -0,0 +1,246 @@
+package scheduler
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"hash/fnv"
+	"log/slog"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/romangod6/kb-crawler/internal/models"
+	"github.com/romangod6/kb-crawler/internal/storage"
+	"github.com/romangod6/kb-crawler/internal/tracing"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("github.com/romangod6/kb-crawler/internal/scheduler")
+
+// Missed-run policies, applied to runs that were due more than the grace period ago.
+const (
+	// MissedRunOnce runs a config once as soon as possible, however many runs it missed
+	MissedRunOnce = "once"
+	// MissedRunSkip skips the missed runs and waits for the next scheduled time
+	MissedRunSkip = "skip"
+)
+
+// Options configures a Scheduler.
+type Options struct {
+	// Tick is how often schedules are checked
+	Tick time.Duration
+	// Jitter is the largest random delay added to a due time, so configs sharing a schedule
+	// don't all start at once. Each config's delay is stable for a given due time.
+	Jitter time.Duration
+	// MissedRunPolicy is MissedRunOnce or MissedRunSkip
+	MissedRunPolicy string
+	// MissedRunGrace is how late a run may start before it counts as missed
+	MissedRunGrace time.Duration
+	// DefaultSchedule applies to configs without a crawlInterval of their own
+	DefaultSchedule string
+}
+
+// SubmitFunc queues a crawl of a config. A config that is already queued or running is not
+// queued again.
+type SubmitFunc func(ctx context.Context, config *models.CrawlerConfig) error
+
+// Scheduler submits crawler configs to the job queue when their schedules are due.
+type Scheduler struct {
+	store  storage.Store
+	submit SubmitFunc
+	opts   Options
+}
+
+// New creates a scheduler that queues due configs with submit.
+func New(store storage.Store, submit SubmitFunc, opts Options) *Scheduler {
+	if opts.Tick <= 0 {
+		opts.Tick = 30 * time.Second
+	}
+	return &Scheduler{store: store, submit: submit, opts: opts}
+}
+
+// NextRun returns when a schedule is next due after t, or nil when it can't be parsed or
+// never fires again.
+func NextRun(spec string, t time.Time) *time.Time {
+	schedule, err := Parse(spec)
+	if err != nil {
+		return nil
+	}
+	return nextAfter(schedule, t)
+}
+
+// Run checks the schedules every tick until ctx is done.
+func (s *Scheduler) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.opts.Tick)
+	defer ticker.Stop()
+
+	for {
+		s.tick(ctx)
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (s *Scheduler) tick(ctx context.Context) {
+	configs, err := s.store.ListCrawlerConfigs(ctx)
+	if err != nil {
+		slog.Error("Failed to fetch crawler configs for scheduling", "error", err)
+		return
+	}
+
+	now := time.Now()
+	for _, config := range configs {
+		entry := s.plan(config, now)
+		if entry.Error != "" || entry.RunAt == nil || entry.RunAt.After(now) {
+			continue
+		}
+
+		logger := slog.With("config_id", config.ID.String(), "product", config.Product)
+		schedule, _ := Parse(s.spec(config))
+
+		// Move the next run on before queueing, so the config isn't queued again on the next
+		// tick. Only the next run is written, and only while it is still the one planned from,
+		// so two leaders in a failover window don't both queue the config and an edit, enable
+		// or disable made meanwhile wins. A config that is still running from its last due
+		// time is not queued twice.
+		nextRun := nextAfter(schedule, now)
+		advanced, err := s.store.AdvanceCrawlerConfigNextRun(ctx, config.ID, config.NextRun, nextRun)
+		if err != nil {
+			logger.Error("Failed to save next run", "error", err)
+			continue
+		}
+		if !advanced {
+			logger.Info("Next run changed meanwhile; leaving it to the next tick", "due", entry.RunAt)
+			continue
+		}
+
+		if entry.Missed && s.opts.MissedRunPolicy == MissedRunSkip {
+			logger.Info("Skipping missed run", "due", entry.RunAt, "next_run", nextRun)
+			continue
+		}
+
+		if entry.Missed {
+			logger.Info("Queueing missed run", "due", entry.RunAt)
+		}
+		s.queue(ctx, config, entry.Missed)
+	}
+}
+
+// spec returns the config's schedule, or the default when it has none.
+func (s *Scheduler) spec(config *models.CrawlerConfig) string {
+	if config.CrawlInterval == "" {
+		return s.opts.DefaultSchedule
+	}
+	return config.CrawlInterval
+}
+
+// queue submits a due config to the job queue.
+func (s *Scheduler) queue(ctx context.Context, config *models.CrawlerConfig, missed bool) {
+	ctx, span := tracer.Start(ctx, "crawl.schedule", trace.WithAttributes(
+		attribute.String("crawl.config_id", config.ID.String()),
+		attribute.String("crawl.schedule", s.spec(config)),
+		attribute.Bool("crawl.missed", missed)))
+	defer span.End()
+
+	if err := s.submit(ctx, config); err != nil {
+		tracing.RecordError(span, err)
+		slog.Error("Failed to queue scheduled crawl", "config_id", config.ID.String(), "product", config.Product,
+			"error", err)
+	}
+}
+
+// nextAfter returns the schedule's next time after t, or nil when it never fires again.
+func nextAfter(schedule Schedule, t time.Time) *time.Time {
+	next := schedule.Next(t)
+	if next.IsZero() {
+		return nil
+	}
+	return &next
+}
+
+// plan works out when a config runs next. A config that has never run is due now, without
+// jitter; disabled configs are never due.
+func (s *Scheduler) plan(config *models.CrawlerConfig, now time.Time) *models.ScheduleEntry {
+	entry := &models.ScheduleEntry{
+		ConfigID: config.ID,
+		Product:  config.Product,
+		Schedule: s.spec(config),
+		LastRun:  config.LastRun,
+		Status:   config.Status,
+		Enabled:  config.Enabled,
+		Running:  config.ActiveJob == models.JobRunning,
+	}
+	if !config.Enabled {
+		return entry
+	}
+
+	schedule, err := Parse(entry.Schedule)
+	if err != nil {
+		entry.Error = err.Error()
+		return entry
+	}
+	entry.Kind = schedule.Kind()
+
+	due := config.NextRun
+	if due == nil && config.LastRun != nil {
+		due = nextAfter(schedule, *config.LastRun)
+	}
+	var jitter time.Duration
+	switch {
+	case due != nil:
+		jitter = s.jitter(config.ID, *due)
+	case config.LastRun == nil:
+		// Jitter derived from now would move on every tick and keep the run in the future
+		due = &now
+	default:
+		return entry
+	}
+	runAt := due.Add(jitter)
+	entry.NextRun = due
+	entry.RunAt = &runAt
+	entry.JitterSeconds = jitter.Seconds()
+	entry.Missed = now.Sub(runAt) > s.opts.MissedRunGrace
+	return entry
+}
+
+// jitter derives a config's delay for a due time from its ID, so it is the same on every tick
+// and in the schedule listing.
+func (s *Scheduler) jitter(id uuid.UUID, due time.Time) time.Duration {
+	if s.opts.Jitter <= 0 {
+		return 0
+	}
+	h := fnv.New64a()
+	h.Write(id[:])
+	binary.Write(h, binary.BigEndian, due.Unix())
+	return time.Duration(h.Sum64() % uint64(s.opts.Jitter))
+}
+
+// Upcoming returns every config's place in the schedule, soonest first. Configs that are
+// disabled or whose schedule can't be parsed come last.
+func (s *Scheduler) Upcoming(ctx context.Context) ([]*models.ScheduleEntry, error) {
+	configs, err := s.store.ListCrawlerConfigs(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch crawler configs: %w", err)
+	}
+
+	now := time.Now()
+	entries := make([]*models.ScheduleEntry, 0, len(configs))
+	for _, config := range configs {
+		entries = append(entries, s.plan(config, now))
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		a, b := entries[i].RunAt, entries[j].RunAt
+		if a == nil || b == nil {
+			return b == nil && a != nil
+		}
+		return a.Before(*b)
+	})
+	return entries, nil
+}
@@ -0,0 +1,174 @@
+package scheduler
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/romangod6/kb-crawler/internal/models"
+	"github.com/romangod6/kb-crawler/internal/storage"
+)
+
+// fakeStore serves crawler configs from memory. Methods the scheduler doesn't use panic
+// through the nil embedded Store.
+type fakeStore struct {
+	storage.Store
+	configs []*models.CrawlerConfig
+}
+
+func (s *fakeStore) ListCrawlerConfigs(ctx context.Context) ([]*models.CrawlerConfig, error) {
+	return s.configs, nil
+}
+
+func (s *fakeStore) AdvanceCrawlerConfigNextRun(ctx context.Context, id uuid.UUID, due, next *time.Time) (bool, error) {
+	for _, config := range s.configs {
+		if config.ID != id {
+			continue
+		}
+		if (due == nil) != (config.NextRun == nil) || (due != nil && !due.Equal(*config.NextRun)) {
+			return false, nil
+		}
+		config.NextRun = next
+		return true, nil
+	}
+	return false, nil
+}
+
+func TestPlan(t *testing.T) {
+	now := time.Now()
+	hourAgo, inAnHour, dayAgo := now.Add(-time.Hour), now.Add(time.Hour), now.Add(-24*time.Hour)
+	s := New(nil, nil, Options{Jitter: 2 * time.Minute, MissedRunGrace: 10 * time.Minute, DefaultSchedule: "6h"})
+
+	tests := []struct {
+		name       string
+		config     *models.CrawlerConfig
+		wantNext   *time.Time
+		wantMissed bool
+		wantError  bool
+	}{
+		{
+			name:     "never run",
+			config:   &models.CrawlerConfig{Enabled: true},
+			wantNext: &now,
+		},
+		{
+			name:     "next run planned",
+			config:   &models.CrawlerConfig{Enabled: true, NextRun: &inAnHour, LastRun: &dayAgo},
+			wantNext: &inAnHour,
+		},
+		{
+			name:       "next run worked out from the last run",
+			config:     &models.CrawlerConfig{Enabled: true, CrawlInterval: "1h", LastRun: &dayAgo},
+			wantNext:   ptr(dayAgo.Add(time.Hour)),
+			wantMissed: true,
+		},
+		{
+			name:   "disabled",
+			config: &models.CrawlerConfig{Enabled: false, NextRun: &hourAgo},
+		},
+		{
+			name:      "invalid schedule",
+			config:    &models.CrawlerConfig{Enabled: true, CrawlInterval: "sometimes"},
+			wantError: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.config.ID = uuid.New()
+			entry := s.plan(tt.config, now)
+
+			if got := entry.Error != ""; got != tt.wantError {
+				t.Fatalf("Error = %q, want error = %v", entry.Error, tt.wantError)
+			}
+			if tt.wantNext == nil {
+				if entry.NextRun != nil || entry.RunAt != nil {
+					t.Fatalf("NextRun, RunAt = %v, %v, want neither", entry.NextRun, entry.RunAt)
+				}
+				return
+			}
+			if entry.NextRun == nil || !entry.NextRun.Equal(*tt.wantNext) {
+				t.Errorf("NextRun = %v, want %v", entry.NextRun, tt.wantNext)
+			}
+			if jitter := entry.RunAt.Sub(*entry.NextRun); jitter < 0 || jitter >= s.opts.Jitter {
+				t.Errorf("RunAt is %s after NextRun, want less than %s", jitter, s.opts.Jitter)
+			}
+			if entry.Missed != tt.wantMissed {
+				t.Errorf("Missed = %v, want %v", entry.Missed, tt.wantMissed)
+			}
+		})
+	}
+}
+
+func TestPlanNeverRunIsDueNow(t *testing.T) {
+	s := New(nil, nil, Options{Jitter: 2 * time.Minute, DefaultSchedule: "6h"})
+	config := &models.CrawlerConfig{ID: uuid.New(), Enabled: true}
+
+	// Every tick plans from a different now; the run must not move along with it
+	for _, now := range []time.Time{time.Now(), time.Now().Add(30 * time.Second), time.Now().Add(time.Minute)} {
+		if entry := s.plan(config, now); entry.RunAt == nil || entry.RunAt.After(now) {
+			t.Fatalf("plan(%v).RunAt = %v, want due now", now, entry.RunAt)
+		}
+	}
+}
+
+func TestTick(t *testing.T) {
+	hourAgo, inAnHour := time.Now().Add(-time.Hour), time.Now().Add(time.Hour)
+	newConfig := &models.CrawlerConfig{ID: uuid.New(), Product: "new", Enabled: true}
+	dueConfig := &models.CrawlerConfig{ID: uuid.New(), Product: "due", Enabled: true, NextRun: &hourAgo, LastRun: &hourAgo}
+	laterConfig := &models.CrawlerConfig{ID: uuid.New(), Product: "later", Enabled: true, NextRun: &inAnHour}
+	disabledConfig := &models.CrawlerConfig{ID: uuid.New(), Product: "disabled", NextRun: &hourAgo}
+	store := &fakeStore{configs: []*models.CrawlerConfig{newConfig, dueConfig, laterConfig, disabledConfig}}
+
+	var submitted []string
+	submit := func(ctx context.Context, config *models.CrawlerConfig) error {
+		submitted = append(submitted, config.Product)
+		return nil
+	}
+	s := New(store, submit, Options{
+		Jitter:          2 * time.Minute,
+		MissedRunPolicy: MissedRunOnce,
+		MissedRunGrace:  10 * time.Minute,
+		DefaultSchedule: "6h",
+	})
+
+	s.tick(context.Background())
+	if want := []string{"new", "due"}; !slices.Equal(submitted, want) {
+		t.Fatalf("first tick queued %v, want %v", submitted, want)
+	}
+	for _, config := range []*models.CrawlerConfig{newConfig, dueConfig} {
+		if config.NextRun == nil || time.Until(*config.NextRun) < 5*time.Hour {
+			t.Errorf("%s: next run = %v, want about 6h from now", config.Product, config.NextRun)
+		}
+	}
+
+	submitted = nil
+	s.tick(context.Background())
+	if len(submitted) != 0 {
+		t.Errorf("second tick queued %v, want nothing", submitted)
+	}
+}
+
+func TestTickSkipsMissedRuns(t *testing.T) {
+	dayAgo := time.Now().Add(-24 * time.Hour)
+	config := &models.CrawlerConfig{ID: uuid.New(), Enabled: true, NextRun: &dayAgo, LastRun: &dayAgo}
+	store := &fakeStore{configs: []*models.CrawlerConfig{config}}
+
+	var submitted int
+	submit := func(ctx context.Context, config *models.CrawlerConfig) error {
+		submitted++
+		return nil
+	}
+	s := New(store, submit, Options{MissedRunPolicy: MissedRunSkip, MissedRunGrace: 10 * time.Minute, DefaultSchedule: "6h"})
+
+	s.tick(context.Background())
+	if submitted != 0 {
+		t.Errorf("queued %d runs, want the missed run skipped", submitted)
+	}
+	if config.NextRun == nil || !config.NextRun.After(time.Now()) {
+		t.Errorf("next run = %v, want it moved past now", config.NextRun)
+	}
+}
+
+func ptr(t time.Time) *time.Time { return &t }
@@ -3,7 +3,10 @@ package storage
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"fmt"
+	"hash/fnv"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/lib/pq"
@@ -46,6 +49,17 @@ func (s *PostgresStore) Initialize() error {
             tags TEXT[],
             author VARCHAR(255),
             metadata JSONB,
+            content_confidence REAL,
+            canonical_url VARCHAR(2048),
+            language VARCHAR(35),
+            description TEXT,
+            published_at TIMESTAMP,
+            modified_at TIMESTAMP,
+            open_graph JSONB,
+            structured_data JSONB,
+            in_sitemap BOOLEAN NOT NULL DEFAULT TRUE,
+            deleted_at TIMESTAMP,
+            deleted_reason TEXT,
             created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
             updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
         )`,
@@ -59,17 +73,219 @@ func (s *PostgresStore) Initialize() error {
             max_depth INTEGER NOT NULL,
             default_category TEXT NOT NULL,
             allowed_domains TEXT[],
+            profile TEXT NOT NULL DEFAULT '',
+            download_assets BOOLEAN NOT NULL DEFAULT FALSE,
+            follow_links BOOLEAN NOT NULL DEFAULT FALSE,
+            include_patterns TEXT[],
+            exclude_patterns TEXT[],
+            url_filters JSONB,
             status TEXT NOT NULL,
             last_run TIMESTAMP,
+            last_run_summary JSONB,
             errors TEXT[],
             logs TEXT[],
             created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
             updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
         )`,
+		`CREATE TABLE IF NOT EXISTS article_sections (
+            id UUID PRIMARY KEY,
+            article_id UUID NOT NULL REFERENCES articles(id) ON DELETE CASCADE,
+            parent_id UUID REFERENCES article_sections(id) ON DELETE CASCADE,
+            level INTEGER NOT NULL,
+            title TEXT NOT NULL,
+            anchor TEXT NOT NULL,
+            position INTEGER NOT NULL,
+            content TEXT,
+            created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+        )`,
+		`CREATE TABLE IF NOT EXISTS assets (
+            id UUID PRIMARY KEY,
+            url VARCHAR(2048) UNIQUE NOT NULL,
+            kind TEXT NOT NULL,
+            mime_type TEXT,
+            sha256 CHAR(64),
+            size BIGINT,
+            downloaded_at TIMESTAMP,
+            created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+            updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+        )`,
+		`CREATE TABLE IF NOT EXISTS article_assets (
+            article_id UUID NOT NULL REFERENCES articles(id) ON DELETE CASCADE,
+            asset_id UUID NOT NULL REFERENCES assets(id) ON DELETE CASCADE,
+            alt_text TEXT,
+            position INTEGER NOT NULL,
+            PRIMARY KEY (article_id, asset_id)
+        )`,
+		`CREATE TABLE IF NOT EXISTS article_links (
+            id UUID PRIMARY KEY,
+            source_article_id UUID NOT NULL REFERENCES articles(id) ON DELETE CASCADE,
+            target_url VARCHAR(2048) NOT NULL,
+            anchor_text TEXT,
+            is_internal BOOLEAN NOT NULL DEFAULT FALSE,
+            target_article_id UUID REFERENCES articles(id) ON DELETE SET NULL,
+            position INTEGER NOT NULL,
+            status_code INTEGER,
+            broken BOOLEAN NOT NULL DEFAULT FALSE,
+            broken_reason TEXT,
+            checked_at TIMESTAMP,
+            created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+        )`,
+		`CREATE TABLE IF NOT EXISTS article_versions (
+            id UUID PRIMARY KEY,
+            article_id UUID NOT NULL REFERENCES articles(id) ON DELETE CASCADE,
+            version INTEGER NOT NULL,
+            content_hash CHAR(64) NOT NULL,
+            name VARCHAR(255) NOT NULL,
+            body TEXT,
+            created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+            UNIQUE (article_id, version)
+        )`,
+		`CREATE TABLE IF NOT EXISTS article_changes (
+            seq BIGSERIAL PRIMARY KEY,
+            article_id UUID NOT NULL,
+            config_id UUID,
+            url VARCHAR(2048) NOT NULL,
+            change_type TEXT NOT NULL,
+            version INTEGER,
+            content_hash CHAR(64),
+            reason TEXT,
+            created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+        )`,
+		`CREATE TABLE IF NOT EXISTS webhooks (
+            id UUID PRIMARY KEY,
+            url TEXT NOT NULL,
+            secret TEXT NOT NULL,
+            events TEXT[],
+            active BOOLEAN NOT NULL DEFAULT TRUE,
+            created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+            updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+        )`,
+		`CREATE TABLE IF NOT EXISTS webhook_deliveries (
+            id UUID PRIMARY KEY,
+            webhook_id UUID NOT NULL REFERENCES webhooks(id) ON DELETE CASCADE,
+            change_seq BIGINT NOT NULL,
+            event TEXT NOT NULL,
+            payload JSONB NOT NULL,
+            status TEXT NOT NULL,
+            attempts INTEGER NOT NULL DEFAULT 0,
+            last_error TEXT,
+            response_status INTEGER,
+            next_attempt_at TIMESTAMP NOT NULL,
+            delivered_at TIMESTAMP,
+            created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+            updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+        )`,
+		`CREATE TABLE IF NOT EXISTS crawl_runs (
+            id UUID PRIMARY KEY,
+            config_id UUID REFERENCES crawler_configs(id) ON DELETE CASCADE,
+            product TEXT NOT NULL DEFAULT '',
+            status VARCHAR(20) NOT NULL,
+            summary JSONB,
+            error TEXT,
+            log_path TEXT NOT NULL DEFAULT '',
+            started_at TIMESTAMP NOT NULL,
+            finished_at TIMESTAMP
+        )`,
+		`CREATE TABLE IF NOT EXISTS crawl_jobs (
+            id UUID PRIMARY KEY,
+            config_id UUID NOT NULL REFERENCES crawler_configs(id) ON DELETE CASCADE,
+            product TEXT NOT NULL DEFAULT '',
+            trigger VARCHAR(20) NOT NULL,
+            priority INTEGER NOT NULL DEFAULT 0,
+            status VARCHAR(20) NOT NULL,
+            attempts INTEGER NOT NULL DEFAULT 0,
+            run_id UUID,
+            error TEXT,
+            lease_until TIMESTAMP,
+            created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+            started_at TIMESTAMP,
+            finished_at TIMESTAMP
+        )`,
+		`CREATE TABLE IF NOT EXISTS leaders (
+            name TEXT PRIMARY KEY,
+            holder TEXT NOT NULL,
+            term BIGINT NOT NULL,
+            acquired_at TIMESTAMP NOT NULL,
+            renewed_at TIMESTAMP NOT NULL
+        )`,
+		`CREATE TABLE IF NOT EXISTS crawl_run_urls (
+            run_id UUID NOT NULL REFERENCES crawl_runs(id) ON DELETE CASCADE,
+            url TEXT NOT NULL,
+            depth INTEGER NOT NULL DEFAULT 1,
+            in_sitemap BOOLEAN NOT NULL DEFAULT FALSE,
+            status VARCHAR(20) NOT NULL,
+            worker TEXT,
+            lease_until TIMESTAMP,
+            attempts INTEGER NOT NULL DEFAULT 0,
+            status_code INTEGER,
+            saved_url TEXT,
+            error TEXT,
+            queued_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+            PRIMARY KEY (run_id, url)
+        )`,
+		// Columns added after the initial schema, for databases created by older versions
+		// Articles saved before extraction was scored have no confidence
+		`ALTER TABLE articles ADD COLUMN IF NOT EXISTS content_confidence REAL`,
+		`ALTER TABLE articles ALTER COLUMN content_confidence DROP NOT NULL, ALTER COLUMN content_confidence DROP DEFAULT`,
+		`ALTER TABLE articles
+            ADD COLUMN IF NOT EXISTS canonical_url VARCHAR(2048),
+            ADD COLUMN IF NOT EXISTS language VARCHAR(35),
+            ADD COLUMN IF NOT EXISTS description TEXT,
+            ADD COLUMN IF NOT EXISTS published_at TIMESTAMP,
+            ADD COLUMN IF NOT EXISTS modified_at TIMESTAMP,
+            ADD COLUMN IF NOT EXISTS open_graph JSONB,
+            ADD COLUMN IF NOT EXISTS structured_data JSONB`,
+		`ALTER TABLE crawler_configs ADD COLUMN IF NOT EXISTS profile TEXT NOT NULL DEFAULT ''`,
+		`ALTER TABLE crawler_configs ADD COLUMN IF NOT EXISTS download_assets BOOLEAN NOT NULL DEFAULT FALSE`,
+		`ALTER TABLE articles ADD COLUMN IF NOT EXISTS config_id UUID REFERENCES crawler_configs(id) ON DELETE SET NULL`,
+		`ALTER TABLE articles ADD COLUMN IF NOT EXISTS in_sitemap BOOLEAN NOT NULL DEFAULT TRUE`,
+		`ALTER TABLE articles
+            ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP,
+            ADD COLUMN IF NOT EXISTS deleted_reason TEXT`,
+		`ALTER TABLE crawler_configs
+            ADD COLUMN IF NOT EXISTS follow_links BOOLEAN NOT NULL DEFAULT FALSE,
+            ADD COLUMN IF NOT EXISTS include_patterns TEXT[],
+            ADD COLUMN IF NOT EXISTS exclude_patterns TEXT[]`,
+		`ALTER TABLE crawler_configs
+            ADD COLUMN IF NOT EXISTS url_filters JSONB,
+            ADD COLUMN IF NOT EXISTS last_run_summary JSONB`,
+		`ALTER TABLE articles
+            ADD COLUMN IF NOT EXISTS text_hash CHAR(64),
+            ADD COLUMN IF NOT EXISTS simhash BIGINT,
+            ADD COLUMN IF NOT EXISTS duplicate_of UUID REFERENCES articles(id) ON DELETE SET NULL`,
+		`ALTER TABLE crawler_configs ADD COLUMN IF NOT EXISTS duplicate_policy TEXT NOT NULL DEFAULT ''`,
+		`ALTER TABLE crawler_configs ADD COLUMN IF NOT EXISTS last_run_id UUID`,
+		`ALTER TABLE crawler_configs
+            ADD COLUMN IF NOT EXISTS enabled BOOLEAN NOT NULL DEFAULT TRUE,
+            ADD COLUMN IF NOT EXISTS next_run TIMESTAMP`,
+		`ALTER TABLE crawl_runs ADD COLUMN IF NOT EXISTS categories JSONB`,
+		`ALTER TABLE crawl_runs ADD COLUMN IF NOT EXISTS options JSONB`,
+		`ALTER TABLE crawl_runs ADD COLUMN IF NOT EXISTS report JSONB`,
+		`ALTER TABLE crawl_runs ADD COLUMN IF NOT EXISTS config JSONB`,
+		`ALTER TABLE crawl_jobs ADD COLUMN IF NOT EXISTS options JSONB`,
 		`CREATE INDEX IF NOT EXISTS idx_articles_category_id ON articles(category_id)`,
 		`CREATE INDEX IF NOT EXISTS idx_articles_url ON articles(url)`,
 		`CREATE INDEX IF NOT EXISTS idx_articles_tags ON articles USING GIN(tags)`,
 		`CREATE INDEX IF NOT EXISTS idx_articles_body_fts ON articles USING GIN (to_tsvector('english', body))`,
+		`CREATE INDEX IF NOT EXISTS idx_article_sections_article_id ON article_sections(article_id, position)`,
+		`CREATE INDEX IF NOT EXISTS idx_articles_content_confidence ON articles(content_confidence)`,
+		`CREATE INDEX IF NOT EXISTS idx_article_assets_asset_id ON article_assets(asset_id)`,
+		`CREATE INDEX IF NOT EXISTS idx_article_links_source ON article_links(source_article_id, position)`,
+		`CREATE INDEX IF NOT EXISTS idx_article_links_target_url ON article_links(target_url)`,
+		`CREATE INDEX IF NOT EXISTS idx_article_links_target_article ON article_links(target_article_id)`,
+		`CREATE INDEX IF NOT EXISTS idx_articles_config_id ON articles(config_id)`,
+		`CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_due ON webhook_deliveries(next_attempt_at) WHERE status = 'pending'`,
+		`CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_webhook ON webhook_deliveries(webhook_id, created_at)`,
+		`CREATE INDEX IF NOT EXISTS idx_articles_deleted_at ON articles(deleted_at) WHERE deleted_at IS NOT NULL`,
+		`CREATE INDEX IF NOT EXISTS idx_articles_text_hash ON articles(text_hash)`,
+		`CREATE INDEX IF NOT EXISTS idx_articles_duplicate_of ON articles(duplicate_of)`,
+		`CREATE INDEX IF NOT EXISTS idx_crawl_runs_config ON crawl_runs(config_id, started_at)`,
+		// At most one queued or running job per config
+		`CREATE UNIQUE INDEX IF NOT EXISTS idx_crawl_jobs_active_config ON crawl_jobs(config_id) WHERE status IN ('queued', 'running')`,
+		`CREATE INDEX IF NOT EXISTS idx_crawl_jobs_queued ON crawl_jobs(priority DESC, created_at) WHERE status = 'queued'`,
+		`CREATE INDEX IF NOT EXISTS idx_crawl_jobs_created ON crawl_jobs(created_at)`,
+		`CREATE INDEX IF NOT EXISTS idx_crawl_run_urls_claimable ON crawl_run_urls(queued_at) WHERE status IN ('pending', 'leased')`,
+		`CREATE INDEX IF NOT EXISTS idx_crawl_run_urls_worker ON crawl_run_urls(worker) WHERE status = 'leased'`,
 	}
 
 	for _, query := range queries {
@@ -104,22 +320,58 @@ func (s *PostgresStore) CreateCategory(ctx context.Context, category *models.Cat
 	return err
 }
 
-// Existing methods for Article
-func (s *PostgresStore) CreateArticle(ctx context.Context, article *models.Article) error {
+// CreateArticle inserts or updates an article by URL. A revision is kept whenever the content
+// changes, and the change is appended to the change log and returned. It returns nil when an
+// existing article's content is unchanged, and for linked duplicates, whose empty body is not
+// a revision of their content.
+func (s *PostgresStore) CreateArticle(ctx context.Context, article *models.Article) (*models.ArticleChange, error) {
+	// The upsert itself tells whether the article is new (xmax = 0 on an inserted row), so two
+	// saves of the same new URL can't both count as created. A tombstoned article that comes
+	// back counts as created again.
 	query := `
-        INSERT INTO articles (id, category_id, name, body, url, tags, author, metadata, created_at, updated_at)
-        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+        WITH previous AS (SELECT deleted_at FROM articles WHERE url = $5)
+        INSERT INTO articles (
+            id, category_id, name, body, url, tags, author, metadata, content_confidence,
+            canonical_url, language, description, published_at, modified_at, open_graph, structured_data,
+            config_id, in_sitemap, text_hash, simhash, duplicate_of, created_at, updated_at
+        )
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18,
+                NULLIF($19, ''), $20, $21, $22, $23)
         ON CONFLICT (url) DO UPDATE SET
             category_id = EXCLUDED.category_id,
+            config_id = COALESCE(EXCLUDED.config_id, articles.config_id),
             name = EXCLUDED.name,
             body = EXCLUDED.body,
             tags = EXCLUDED.tags,
             author = EXCLUDED.author,
             metadata = EXCLUDED.metadata,
+            content_confidence = EXCLUDED.content_confidence,
+            canonical_url = EXCLUDED.canonical_url,
+            language = EXCLUDED.language,
+            description = EXCLUDED.description,
+            published_at = EXCLUDED.published_at,
+            modified_at = EXCLUDED.modified_at,
+            open_graph = EXCLUDED.open_graph,
+            structured_data = EXCLUDED.structured_data,
+            in_sitemap = EXCLUDED.in_sitemap,
+            text_hash = EXCLUDED.text_hash,
+            simhash = EXCLUDED.simhash,
+            duplicate_of = EXCLUDED.duplicate_of,
+            deleted_at = NULL,
+            deleted_reason = NULL,
             updated_at = CURRENT_TIMESTAMP
+        RETURNING id, xmax = 0, COALESCE((SELECT deleted_at IS NOT NULL FROM previous), FALSE)
     `
 
-	_, err := s.db.ExecContext(ctx, query,
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// On conflict the existing row keeps its ID, so read it back for callers that attach related rows
+	var inserted, wasDeleted bool
+	err = tx.QueryRowContext(ctx, query,
 		article.ID,
 		article.CategoryID,
 		article.Name,
@@ -128,36 +380,106 @@ func (s *PostgresStore) CreateArticle(ctx context.Context, article *models.Artic
 		pq.Array(article.Tags),
 		article.Author,
 		article.Metadata,
+		article.ContentConfidence,
+		article.CanonicalURL,
+		article.Language,
+		article.Description,
+		article.PublishedAt,
+		article.ModifiedAt,
+		article.OpenGraph,
+		article.StructuredData,
+		article.ConfigID,
+		article.InSitemap,
+		article.TextHash,
+		article.SimHash,
+		article.DuplicateOf,
 		article.CreatedAt,
 		article.UpdatedAt,
-	)
+	).Scan(&article.ID, &inserted, &wasDeleted)
+	if err != nil {
+		return nil, err
+	}
 
-	return err
+	if article.DuplicateOf != nil {
+		return nil, tx.Commit()
+	}
+
+	// Keep a revision whenever the content differs from the latest one. Revisions are numbered
+	// under the row lock the upsert took, held until commit, so concurrent saves of the same
+	// article can't both take the next number and trip UNIQUE(article_id, version).
+	versionQuery := `
+        INSERT INTO article_versions (id, article_id, version, content_hash, name, body, created_at)
+        SELECT $1::uuid, $2::uuid, COALESCE(MAX(v.version), 0) + 1, $3::text, $4::text, $5::text, CURRENT_TIMESTAMP
+        FROM article_versions v
+        WHERE v.article_id = $2::uuid
+        HAVING COALESCE((
+            SELECT content_hash::text FROM article_versions WHERE article_id = $2::uuid ORDER BY version DESC LIMIT 1
+        ), '') <> $3::text
+        RETURNING version
+    `
+
+	contentHash := models.ContentHash(article.Body)
+	var version int
+	err = tx.QueryRowContext(ctx, versionQuery,
+		uuid.New(),
+		article.ID,
+		contentHash,
+		article.Name,
+		article.Body,
+	).Scan(&version)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+
+	var change *models.ArticleChange
+	switch {
+	case inserted || wasDeleted:
+		change = &models.ArticleChange{Type: models.ChangeCreated}
+	case version > 0:
+		change = &models.ArticleChange{Type: models.ChangeUpdated}
+	}
+
+	if change != nil {
+		change.ArticleID = article.ID
+		change.ConfigID = article.ConfigID
+		change.URL = article.URL
+		change.Version = version
+		change.ContentHash = contentHash
+		if err := insertChange(ctx, tx, change); err != nil {
+			return nil, err
+		}
+	}
+
+	return change, tx.Commit()
+}
+
+// insertChange appends a change to the change log, filling in its sequence number and time.
+func insertChange(ctx context.Context, tx *sql.Tx, change *models.ArticleChange) error {
+	query := `
+        INSERT INTO article_changes (article_id, config_id, url, change_type, version, content_hash, reason)
+        VALUES ($1, $2, $3, $4, NULLIF($5, 0), NULLIF($6, ''), NULLIF($7, ''))
+        RETURNING seq, created_at
+    `
+
+	return tx.QueryRowContext(ctx, query,
+		change.ArticleID,
+		change.ConfigID,
+		change.URL,
+		change.Type,
+		change.Version,
+		change.ContentHash,
+		change.Reason,
+	).Scan(&change.Seq, &change.CreatedAt)
 }
 
 func (s *PostgresStore) GetArticle(ctx context.Context, id uuid.UUID) (*models.Article, error) {
 	query := `
-        SELECT id, category_id, name, body, url, tags, author, metadata, created_at, updated_at
+        SELECT ` + articleColumns + `
         FROM articles
         WHERE id = $1
     `
 
-	article := &models.Article{}
-	var tags []string
-
-	err := s.db.QueryRowContext(ctx, query, id).Scan(
-		&article.ID,
-		&article.CategoryID,
-		&article.Name,
-		&article.Body,
-		&article.URL,
-		pq.Array(&tags),
-		&article.Author,
-		&article.Metadata,
-		&article.CreatedAt,
-		&article.UpdatedAt,
-	)
-
+	article, err := scanArticle(s.db.QueryRowContext(ctx, query, id))
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
@@ -166,7 +488,6 @@ func (s *PostgresStore) GetArticle(ctx context.Context, id uuid.UUID) (*models.A
 		return nil, err
 	}
 
-	article.Tags = tags
 	return article, nil
 }
 
@@ -198,47 +519,16 @@ func (s *PostgresStore) GetCategory(ctx context.Context, id uuid.UUID) (*models.
 	return category, nil
 }
 
-func (s *PostgresStore) ListArticles(ctx context.Context, limit, offset int) ([]*models.Article, error) {
+func (s *PostgresStore) ListArticles(ctx context.Context, limit, offset int, includeDeleted bool) ([]*models.Article, error) {
 	query := `
-        SELECT id, category_id, name, body, url, tags, author, metadata, created_at, updated_at
+        SELECT ` + articleColumns + `
         FROM articles
+        WHERE ($3 OR deleted_at IS NULL)
         ORDER BY created_at DESC
         LIMIT $1 OFFSET $2
     `
 
-	rows, err := s.db.QueryContext(ctx, query, limit, offset)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-
-	var articles []*models.Article
-	for rows.Next() {
-		article := &models.Article{}
-		var tags []string
-
-		err := rows.Scan(
-			&article.ID,
-			&article.CategoryID,
-			&article.Name,
-			&article.Body,
-			&article.URL,
-			pq.Array(&tags),
-			&article.Author,
-			&article.Metadata,
-			&article.CreatedAt,
-			&article.UpdatedAt,
-		)
-
-		if err != nil {
-			return nil, err
-		}
-
-		article.Tags = tags
-		articles = append(articles, article)
-	}
-
-	return articles, nil
+	return s.queryArticles(ctx, query, limit, offset, includeDeleted)
 }
 
 func (s *PostgresStore) ListCategories(ctx context.Context) ([]*models.Category, error) {
@@ -276,167 +566,372 @@ func (s *PostgresStore) ListCategories(ctx context.Context) ([]*models.Category,
 	return categories, nil
 }
 
-func (s *PostgresStore) GetArticlesByCategory(ctx context.Context, categoryID uuid.UUID, limit, offset int) ([]*models.Article, error) {
+func (s *PostgresStore) GetArticlesByCategory(ctx context.Context, categoryID uuid.UUID, limit, offset int, includeDeleted bool) ([]*models.Article, error) {
 	query := `
-        SELECT id, category_id, name, body, url, tags, author, metadata, created_at, updated_at
+        SELECT ` + articleColumns + `
         FROM articles
-        WHERE category_id = $1
+        WHERE category_id = $1 AND ($4 OR deleted_at IS NULL)
         ORDER BY created_at DESC
         LIMIT $2 OFFSET $3
     `
 
-	rows, err := s.db.QueryContext(ctx, query, categoryID, limit, offset)
+	return s.queryArticles(ctx, query, categoryID, limit, offset, includeDeleted)
+}
+
+func (s *PostgresStore) SearchArticles(ctx context.Context, query string, limit, offset int, includeDeleted bool) ([]*models.Article, error) {
+	sqlQuery := `
+        SELECT ` + articleColumns + `
+        FROM articles
+        WHERE to_tsvector('english', body) @@ plainto_tsquery('english', $1) AND ($4 OR deleted_at IS NULL)
+        ORDER BY ts_rank(to_tsvector('english', body), plainto_tsquery('english', $1)) DESC
+        LIMIT $2 OFFSET $3
+    `
+
+	return s.queryArticles(ctx, sqlQuery, query, limit, offset, includeDeleted)
+}
+
+// ListLowConfidenceArticles returns articles whose content extraction scored at or below the threshold,
+// worst first. Articles saved before extraction was scored are left out.
+func (s *PostgresStore) ListLowConfidenceArticles(ctx context.Context, threshold float64, limit, offset int, includeDeleted bool) ([]*models.Article, error) {
+	query := `
+        SELECT ` + articleColumns + `
+        FROM articles
+        WHERE content_confidence IS NOT NULL AND content_confidence <= $1 AND ($4 OR deleted_at IS NULL)
+        ORDER BY content_confidence ASC, created_at DESC
+        LIMIT $2 OFFSET $3
+    `
+
+	return s.queryArticles(ctx, query, threshold, limit, offset, includeDeleted)
+}
+
+func (s *PostgresStore) ListArticleRefs(ctx context.Context) ([]*models.ArticleRef, error) {
+	query := `
+        SELECT id, url, COALESCE(canonical_url, ''), updated_at
+        FROM articles
+        ORDER BY url
+    `
+
+	rows, err := s.db.QueryContext(ctx, query)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	var articles []*models.Article
+	var refs []*models.ArticleRef
 	for rows.Next() {
-		article := &models.Article{}
-		var tags []string
+		ref := &models.ArticleRef{}
+		if err := rows.Scan(&ref.ID, &ref.URL, &ref.CanonicalURL, &ref.UpdatedAt); err != nil {
+			return nil, err
+		}
+		refs = append(refs, ref)
+	}
 
-		err := rows.Scan(
-			&article.ID,
-			&article.CategoryID,
-			&article.Name,
-			&article.Body,
-			&article.URL,
-			pq.Array(&tags),
-			&article.Author,
-			&article.Metadata,
-			&article.CreatedAt,
-			&article.UpdatedAt,
-		)
+	return refs, rows.Err()
+}
 
-		if err != nil {
-			return nil, err
+// MergeArticles merges the duplicate articles into the kept one and moves it to the given URL.
+// Their revisions, change log, assets and outbound links move to the kept article, as do the
+// section outline of the latest duplicate when the kept article has none, and links and
+// duplicates pointing at them point at it instead. The duplicates are then deleted. It fails
+// when an article outside the merge already has the URL.
+func (s *PostgresStore) MergeArticles(ctx context.Context, keepID uuid.UUID, duplicateIDs []uuid.UUID, url string) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Lock the kept article so no crawl adds a revision while its revisions are renumbered
+	err = tx.QueryRowContext(ctx, `SELECT TRUE FROM articles WHERE id = $1 FOR UPDATE`, keepID).Scan(new(bool))
+	if err != nil {
+		return err
+	}
+
+	// Move everything off the duplicates first, then delete them so the kept article can take
+	// over their URL
+	if len(duplicateIDs) > 0 {
+		duplicates := pq.Array(duplicateIDs)
+		statements := []struct {
+			query string
+			args  []interface{}
+		}{
+			// Revisions are renumbered by age across the merged articles, through negative
+			// numbers so UNIQUE(article_id, version) holds after every row. The kept article's
+			// latest revision stays the latest, so the next crawl compares against its content.
+			{`UPDATE article_versions v SET article_id = $1, version = -r.version
+             FROM (
+                 SELECT id, ROW_NUMBER() OVER (
+                     ORDER BY COALESCE(article_id = $1 AND version = (
+                         SELECT MAX(version) FROM article_versions WHERE article_id = $1
+                     ), FALSE), created_at, version, id
+                 ) AS version
+                 FROM article_versions
+                 WHERE article_id = $1 OR article_id = ANY($2)
+             ) r
+             WHERE v.id = r.id`, []interface{}{keepID, duplicates}},
+			{`UPDATE article_versions SET version = -version WHERE article_id = $1 AND version < 0`, []interface{}{keepID}},
+			{`UPDATE article_changes SET article_id = $1 WHERE article_id = ANY($2)`, []interface{}{keepID, duplicates}},
+			{`UPDATE article_sections SET article_id = $1
+             WHERE article_id = (
+                 SELECT a.id FROM articles a
+                 WHERE a.id = ANY($2) AND EXISTS (SELECT 1 FROM article_sections s WHERE s.article_id = a.id)
+                 ORDER BY a.updated_at DESC
+                 LIMIT 1
+             ) AND NOT EXISTS (SELECT 1 FROM article_sections WHERE article_id = $1)`, []interface{}{keepID, duplicates}},
+			{`INSERT INTO article_assets (article_id, asset_id, alt_text, position)
+             SELECT $1, d.asset_id, d.alt_text,
+                 (SELECT COALESCE(MAX(position), -1) FROM article_assets WHERE article_id = $1)
+                     + ROW_NUMBER() OVER (ORDER BY d.article_id, d.position)
+             FROM (
+                 SELECT DISTINCT ON (asset_id) article_id, asset_id, alt_text, position
+                 FROM article_assets
+                 WHERE article_id = ANY($2)
+                   AND asset_id NOT IN (SELECT asset_id FROM article_assets WHERE article_id = $1)
+                 ORDER BY asset_id, article_id, position
+             ) d`, []interface{}{keepID, duplicates}},
+			{`UPDATE article_links l SET source_article_id = $1, position = r.position
+             FROM (
+                 SELECT id, (SELECT COALESCE(MAX(position), -1) FROM article_links WHERE source_article_id = $1)
+                     + ROW_NUMBER() OVER (ORDER BY source_article_id, position) AS position
+                 FROM article_links
+                 WHERE source_article_id = ANY($2)
+             ) r
+             WHERE l.id = r.id`, []interface{}{keepID, duplicates}},
+			{`UPDATE article_links SET target_article_id = $1 WHERE target_article_id = ANY($2)`, []interface{}{keepID, duplicates}},
+			{`UPDATE articles SET duplicate_of = $1 WHERE duplicate_of = ANY($2) AND id <> $1`, []interface{}{keepID, duplicates}},
+			{`DELETE FROM articles WHERE id = ANY($1)`, []interface{}{duplicates}},
+		}
+		for _, statement := range statements {
+			if _, err := tx.ExecContext(ctx, statement.query, statement.args...); err != nil {
+				return err
+			}
 		}
+	}
 
-		article.Tags = tags
-		articles = append(articles, article)
+	var conflictID uuid.UUID
+	err = tx.QueryRowContext(ctx, `SELECT id FROM articles WHERE url = $1 AND id <> $2`, url, keepID).Scan(&conflictID)
+	if err == nil {
+		return fmt.Errorf("URL %s belongs to article %s, which is not part of the merge", url, conflictID)
+	}
+	if err != sql.ErrNoRows {
+		return err
 	}
 
-	return articles, nil
+	result, err := tx.ExecContext(ctx, `UPDATE articles SET url = $2, updated_at = CURRENT_TIMESTAMP WHERE id = $1`, keepID, url)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return sql.ErrNoRows
+	}
+
+	return tx.Commit()
 }
 
-func (s *PostgresStore) SearchArticles(ctx context.Context, query string, limit, offset int) ([]*models.Article, error) {
-	sqlQuery := `
-        SELECT id, category_id, name, body, url, tags, author, metadata, created_at, updated_at
+// ListArticleFingerprints returns the fingerprints of the articles that are not tombstoned,
+// oldest first.
+func (s *PostgresStore) ListArticleFingerprints(ctx context.Context) ([]*models.ArticleFingerprint, error) {
+	query := `
+        SELECT id, config_id, name, url, text_hash, COALESCE(simhash, 0), duplicate_of, created_at
         FROM articles
-        WHERE to_tsvector('english', body) @@ plainto_tsquery('english', $1)
-        ORDER BY ts_rank(to_tsvector('english', body), plainto_tsquery('english', $1)) DESC
-        LIMIT $2 OFFSET $3
+        WHERE deleted_at IS NULL AND text_hash IS NOT NULL
+        ORDER BY created_at, id
     `
 
-	rows, err := s.db.QueryContext(ctx, sqlQuery, query, limit, offset)
+	rows, err := s.db.QueryContext(ctx, query)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	var articles []*models.Article
+	var fingerprints []*models.ArticleFingerprint
+	for rows.Next() {
+		fp := &models.ArticleFingerprint{}
+		if err := rows.Scan(&fp.ID, &fp.ConfigID, &fp.Name, &fp.URL, &fp.TextHash, &fp.SimHash, &fp.DuplicateOf, &fp.CreatedAt); err != nil {
+			return nil, err
+		}
+		fingerprints = append(fingerprints, fp)
+	}
+
+	return fingerprints, rows.Err()
+}
+
+// ListActiveArticleRefs returns the articles of a crawler config that are not tombstoned,
+// with the content hash of their latest revision.
+func (s *PostgresStore) ListActiveArticleRefs(ctx context.Context, configID uuid.UUID) ([]*models.ArticleRef, error) {
+	query := `
+        SELECT a.id, a.url, COALESCE(a.canonical_url, ''), a.updated_at, COALESCE((
+            SELECT v.content_hash FROM article_versions v WHERE v.article_id = a.id ORDER BY v.version DESC LIMIT 1
+        ), '')
+        FROM articles a
+        WHERE a.config_id = $1 AND a.deleted_at IS NULL
+        ORDER BY a.url
+    `
+
+	rows, err := s.db.QueryContext(ctx, query, configID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var refs []*models.ArticleRef
 	for rows.Next() {
-		article := &models.Article{}
-		var tags []string
+		ref := &models.ArticleRef{}
+		if err := rows.Scan(&ref.ID, &ref.URL, &ref.CanonicalURL, &ref.UpdatedAt, &ref.ContentHash); err != nil {
+			return nil, err
+		}
+		refs = append(refs, ref)
+	}
+
+	return refs, rows.Err()
+}
+
+// TombstoneArticles soft-deletes articles that vanished from the source, recording why, and
+// returns the deletions appended to the change log. Articles that are already tombstoned keep
+// their original deletion time.
+func (s *PostgresStore) TombstoneArticles(ctx context.Context, ids []uuid.UUID, reason string) ([]*models.ArticleChange, error) {
+	query := `
+        WITH deleted AS (
+            UPDATE articles SET deleted_at = CURRENT_TIMESTAMP, deleted_reason = $2
+            WHERE id = ANY($1) AND deleted_at IS NULL
+            RETURNING id, config_id, url
+        )
+        INSERT INTO article_changes (article_id, config_id, url, change_type, reason)
+        SELECT id, config_id, url, $3, $2 FROM deleted
+        RETURNING ` + changeColumns + `
+    `
+
+	return s.queryChanges(ctx, query, pq.Array(ids), reason, models.ChangeDeleted)
+}
+
+// ListChanges returns change log entries after the given sequence number, oldest first.
+func (s *PostgresStore) ListChanges(ctx context.Context, since int64, limit int) ([]*models.ArticleChange, error) {
+	query := `
+        SELECT ` + changeColumns + `
+        FROM article_changes
+        WHERE seq > $1
+        ORDER BY seq
+        LIMIT $2
+    `
+
+	return s.queryChanges(ctx, query, since, limit)
+}
 
+func (s *PostgresStore) queryChanges(ctx context.Context, query string, args ...interface{}) ([]*models.ArticleChange, error) {
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var changes []*models.ArticleChange
+	for rows.Next() {
+		change := &models.ArticleChange{}
 		err := rows.Scan(
-			&article.ID,
-			&article.CategoryID,
-			&article.Name,
-			&article.Body,
-			&article.URL,
-			pq.Array(&tags),
-			&article.Author,
-			&article.Metadata,
-			&article.CreatedAt,
-			&article.UpdatedAt,
+			&change.Seq,
+			&change.ArticleID,
+			&change.ConfigID,
+			&change.URL,
+			&change.Type,
+			&change.Version,
+			&change.ContentHash,
+			&change.Reason,
+			&change.CreatedAt,
 		)
-
 		if err != nil {
 			return nil, err
 		}
+		changes = append(changes, change)
+	}
+
+	return changes, rows.Err()
+}
+
+// PurgeDeletedArticles permanently removes articles tombstoned before the cutoff.
+func (s *PostgresStore) PurgeDeletedArticles(ctx context.Context, before time.Time) (int64, error) {
+	result, err := s.db.ExecContext(ctx, `DELETE FROM articles WHERE deleted_at < $1`, before)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+func (s *PostgresStore) queryArticles(ctx context.Context, query string, args ...interface{}) ([]*models.Article, error) {
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
 
-		article.Tags = tags
+	var articles []*models.Article
+	for rows.Next() {
+		article, err := scanArticle(rows)
+		if err != nil {
+			return nil, err
+		}
 		articles = append(articles, article)
 	}
 
-	return articles, nil
+	return articles, rows.Err()
 }
 
-// New Crawler Config Methods
-func (s *PostgresStore) ListCrawlerConfigs(ctx context.Context) ([]*models.CrawlerConfig, error) {
+// ListArticleVersions returns the revisions of an article, newest first, without their bodies.
+func (s *PostgresStore) ListArticleVersions(ctx context.Context, articleID uuid.UUID) ([]*models.ArticleVersion, error) {
 	query := `
-        SELECT id, product, sitemap_url, map_url, user_agent, crawl_interval, max_depth,
-               default_category, allowed_domains, status, last_run, errors, logs,
-               created_at, updated_at
-        FROM crawler_configs
-        ORDER BY created_at DESC
+        SELECT id, article_id, version, content_hash, name, created_at
+        FROM article_versions
+        WHERE article_id = $1
+        ORDER BY version DESC
     `
 
-	rows, err := s.db.QueryContext(ctx, query)
+	rows, err := s.db.QueryContext(ctx, query, articleID)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	var configs []*models.CrawlerConfig
+	var versions []*models.ArticleVersion
 	for rows.Next() {
-		config := &models.CrawlerConfig{}
+		version := &models.ArticleVersion{}
 		err := rows.Scan(
-			&config.ID,
-			&config.Product,
-			&config.SitemapURL,
-			&config.MapURL,
-			&config.UserAgent,
-			&config.CrawlInterval,
-			&config.MaxDepth,
-			&config.DefaultCategory,
-			pq.Array(&config.AllowedDomains),
-			&config.Status,
-			&config.LastRun,
-			pq.Array(&config.Errors),
-			pq.Array(&config.Logs),
-			&config.CreatedAt,
-			&config.UpdatedAt,
+			&version.ID,
+			&version.ArticleID,
+			&version.Version,
+			&version.ContentHash,
+			&version.Name,
+			&version.CreatedAt,
 		)
 		if err != nil {
 			return nil, err
 		}
-		configs = append(configs, config)
+		versions = append(versions, version)
 	}
 
-	return configs, nil
+	return versions, rows.Err()
 }
 
-func (s *PostgresStore) GetCrawlerConfig(ctx context.Context, id uuid.UUID) (*models.CrawlerConfig, error) {
+func (s *PostgresStore) GetArticleVersion(ctx context.Context, articleID uuid.UUID, version int) (*models.ArticleVersion, error) {
 	query := `
-        SELECT id, product, sitemap_url, map_url, user_agent, crawl_interval, max_depth,
-               default_category, allowed_domains, status, last_run, errors, logs,
-               created_at, updated_at
-        FROM crawler_configs
-        WHERE id = $1
+        SELECT id, article_id, version, content_hash, name, COALESCE(body, ''), created_at
+        FROM article_versions
+        WHERE article_id = $1 AND version = $2
     `
 
-	config := &models.CrawlerConfig{}
-	err := s.db.QueryRowContext(ctx, query, id).Scan(
-		&config.ID,
-		&config.Product,
-		&config.SitemapURL,
-		&config.MapURL,
-		&config.UserAgent,
-		&config.CrawlInterval,
-		&config.MaxDepth,
-		&config.DefaultCategory,
-		pq.Array(&config.AllowedDomains),
-		&config.Status,
-		&config.LastRun,
-		pq.Array(&config.Errors),
-		pq.Array(&config.Logs),
-		&config.CreatedAt,
-		&config.UpdatedAt,
+	v := &models.ArticleVersion{}
+	err := s.db.QueryRowContext(ctx, query, articleID, version).Scan(
+		&v.ID,
+		&v.ArticleID,
+		&v.Version,
+		&v.ContentHash,
+		&v.Name,
+		&v.Body,
+		&v.CreatedAt,
 	)
-
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
@@ -444,16 +939,1377 @@ func (s *PostgresStore) GetCrawlerConfig(ctx context.Context, id uuid.UUID) (*mo
 		return nil, err
 	}
 
-	return config, nil
+	return v, nil
 }
 
-func (s *PostgresStore) CreateCrawlerConfig(ctx context.Context, config *models.CrawlerConfig) error {
+// ReplaceArticleSections swaps the stored outline of an article for the given sections.
+func (s *PostgresStore) ReplaceArticleSections(ctx context.Context, articleID uuid.UUID, sections []*models.ArticleSection) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM article_sections WHERE article_id = $1`, articleID); err != nil {
+		return err
+	}
+
 	query := `
-        INSERT INTO crawler_configs (
-            id, product, sitemap_url, map_url, user_agent, crawl_interval, max_depth,
-            default_category, allowed_domains, status, last_run, errors, logs,
-            created_at, updated_at
-        ) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)
+        INSERT INTO article_sections (id, article_id, parent_id, level, title, anchor, position, content, created_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+    `
+
+	for _, section := range sections {
+		_, err := tx.ExecContext(ctx, query,
+			section.ID,
+			articleID,
+			section.ParentID,
+			section.Level,
+			section.Title,
+			section.Anchor,
+			section.Position,
+			section.Content,
+			section.CreatedAt,
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (s *PostgresStore) GetArticleSections(ctx context.Context, articleID uuid.UUID) ([]*models.ArticleSection, error) {
+	query := `
+        SELECT id, article_id, parent_id, level, title, anchor, position, content, created_at
+        FROM article_sections
+        WHERE article_id = $1
+        ORDER BY position
+    `
+
+	rows, err := s.db.QueryContext(ctx, query, articleID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var sections []*models.ArticleSection
+	for rows.Next() {
+		section := &models.ArticleSection{}
+		err := rows.Scan(
+			&section.ID,
+			&section.ArticleID,
+			&section.ParentID,
+			&section.Level,
+			&section.Title,
+			&section.Anchor,
+			&section.Position,
+			&section.Content,
+			&section.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		sections = append(sections, section)
+	}
+
+	return sections, nil
+}
+
+// UpsertAsset records an asset by URL. When the URL is already known the existing ID and
+// download details are read back into the asset.
+func (s *PostgresStore) UpsertAsset(ctx context.Context, asset *models.Asset) error {
+	query := `
+        INSERT INTO assets (id, url, kind, mime_type, created_at, updated_at)
+        VALUES ($1, $2, $3, $4, $5, $6)
+        ON CONFLICT (url) DO UPDATE SET
+            kind = EXCLUDED.kind,
+            mime_type = COALESCE(assets.mime_type, EXCLUDED.mime_type),
+            updated_at = CURRENT_TIMESTAMP
+        RETURNING id, COALESCE(mime_type, ''), COALESCE(sha256, ''), COALESCE(size, 0), downloaded_at
+    `
+
+	return s.db.QueryRowContext(ctx, query,
+		asset.ID,
+		asset.URL,
+		asset.Kind,
+		asset.MIMEType,
+		asset.CreatedAt,
+		asset.UpdatedAt,
+	).Scan(&asset.ID, &asset.MIMEType, &asset.SHA256, &asset.Size, &asset.DownloadedAt)
+}
+
+// UpdateAssetBlob stores the blob hash, size and MIME type of a downloaded asset.
+func (s *PostgresStore) UpdateAssetBlob(ctx context.Context, asset *models.Asset) error {
+	query := `
+        UPDATE assets SET
+            sha256 = $2,
+            size = $3,
+            mime_type = $4,
+            downloaded_at = $5,
+            updated_at = CURRENT_TIMESTAMP
+        WHERE id = $1
+    `
+
+	_, err := s.db.ExecContext(ctx, query,
+		asset.ID,
+		asset.SHA256,
+		asset.Size,
+		asset.MIMEType,
+		asset.DownloadedAt,
+	)
+	return err
+}
+
+// ReplaceArticleAssets links an article to exactly the given assets, in order.
+func (s *PostgresStore) ReplaceArticleAssets(ctx context.Context, articleID uuid.UUID, assets []*models.Asset) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM article_assets WHERE article_id = $1`, articleID); err != nil {
+		return err
+	}
+
+	query := `
+        INSERT INTO article_assets (article_id, asset_id, alt_text, position)
+        VALUES ($1, $2, $3, $4)
+        ON CONFLICT (article_id, asset_id) DO NOTHING
+    `
+
+	for i, asset := range assets {
+		if _, err := tx.ExecContext(ctx, query, articleID, asset.ID, asset.AltText, i); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (s *PostgresStore) ListArticleAssets(ctx context.Context, articleID uuid.UUID) ([]*models.Asset, error) {
+	query := `
+        SELECT ` + assetColumns + `, COALESCE(aa.alt_text, '')
+        FROM article_assets aa
+        JOIN assets a ON a.id = aa.asset_id
+        WHERE aa.article_id = $1
+        ORDER BY aa.position
+    `
+
+	rows, err := s.db.QueryContext(ctx, query, articleID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var assets []*models.Asset
+	for rows.Next() {
+		asset := &models.Asset{}
+		if err := rows.Scan(append(assetFields(asset), &asset.AltText)...); err != nil {
+			return nil, err
+		}
+		assets = append(assets, asset)
+	}
+
+	return assets, rows.Err()
+}
+
+func (s *PostgresStore) GetAsset(ctx context.Context, id uuid.UUID) (*models.Asset, error) {
+	query := `
+        SELECT ` + assetColumns + `
+        FROM assets a
+        WHERE a.id = $1
+    `
+
+	asset := &models.Asset{}
+	err := s.db.QueryRowContext(ctx, query, id).Scan(assetFields(asset)...)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return asset, nil
+}
+
+// ReplaceArticleLinks swaps the stored outbound links of an article for the given links.
+// Link targets that are already stored as articles are resolved straight away.
+func (s *PostgresStore) ReplaceArticleLinks(ctx context.Context, articleID uuid.UUID, links []*models.ArticleLink) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM article_links WHERE source_article_id = $1`, articleID); err != nil {
+		return err
+	}
+
+	query := `
+        INSERT INTO article_links (id, source_article_id, target_url, anchor_text, is_internal, target_article_id, position, created_at)
+        VALUES ($1, $2, $3, $4, $5, (SELECT id FROM articles WHERE url = $3), $6, $7)
+    `
+
+	for _, link := range links {
+		_, err := tx.ExecContext(ctx, query,
+			link.ID,
+			articleID,
+			link.TargetURL,
+			link.AnchorText,
+			link.Internal,
+			link.Position,
+			link.CreatedAt,
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// ListArticleLinks returns the outbound links of an article in page order.
+func (s *PostgresStore) ListArticleLinks(ctx context.Context, articleID uuid.UUID) ([]*models.ArticleLink, error) {
+	query := `
+        SELECT ` + articleLinkColumns + `
+        FROM article_links l
+        JOIN articles a ON a.id = l.source_article_id
+        WHERE l.source_article_id = $1
+        ORDER BY l.position
+    `
+
+	return s.queryArticleLinks(ctx, query, articleID)
+}
+
+// ListBacklinks returns the links from other articles that point at the given article.
+func (s *PostgresStore) ListBacklinks(ctx context.Context, articleID uuid.UUID) ([]*models.ArticleLink, error) {
+	query := `
+        SELECT ` + articleLinkColumns + `
+        FROM article_links l
+        JOIN articles a ON a.id = l.source_article_id
+        WHERE l.target_article_id = $1 AND l.source_article_id <> $1
+        ORDER BY a.name, l.position
+    `
+
+	return s.queryArticleLinks(ctx, query, articleID)
+}
+
+// ListBrokenLinks returns the broken internal links found in the articles of a crawler config.
+func (s *PostgresStore) ListBrokenLinks(ctx context.Context, configID uuid.UUID) ([]*models.ArticleLink, error) {
+	query := `
+        SELECT ` + articleLinkColumns + `
+        FROM article_links l
+        JOIN articles a ON a.id = l.source_article_id
+        WHERE a.config_id = $1 AND l.is_internal AND l.broken
+        ORDER BY l.target_url, a.url
+    `
+
+	return s.queryArticleLinks(ctx, query, configID)
+}
+
+// ListInternalLinkTargets returns the distinct internal link targets of a crawler config's articles.
+func (s *PostgresStore) ListInternalLinkTargets(ctx context.Context, configID uuid.UUID) ([]string, error) {
+	query := `
+        SELECT DISTINCT l.target_url
+        FROM article_links l
+        JOIN articles a ON a.id = l.source_article_id
+        WHERE a.config_id = $1 AND l.is_internal
+        ORDER BY l.target_url
+    `
+
+	rows, err := s.db.QueryContext(ctx, query, configID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var targets []string
+	for rows.Next() {
+		var target string
+		if err := rows.Scan(&target); err != nil {
+			return nil, err
+		}
+		targets = append(targets, target)
+	}
+
+	return targets, rows.Err()
+}
+
+// UpdateLinkStatuses records the check result of each internal link target on every link of the
+// crawler config's articles that points at it.
+func (s *PostgresStore) UpdateLinkStatuses(ctx context.Context, configID uuid.UUID, statuses []models.LinkStatus) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `
+        UPDATE article_links l SET
+            status_code = NULLIF($3, 0),
+            broken = $4,
+            broken_reason = NULLIF($5, ''),
+            checked_at = CURRENT_TIMESTAMP
+        FROM articles a
+        WHERE a.id = l.source_article_id AND a.config_id = $1 AND l.is_internal AND l.target_url = $2
+    `
+
+	for _, status := range statuses {
+		if _, err := tx.ExecContext(ctx, query, configID, status.URL, status.StatusCode, status.Broken, status.Reason); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// ResolveLinkTargets points the links of a crawler config's articles at the articles stored
+// under their target URLs, picking up pages that were saved after the linking page.
+func (s *PostgresStore) ResolveLinkTargets(ctx context.Context, configID uuid.UUID) error {
+	query := `
+        UPDATE article_links l SET target_article_id = t.id
+        FROM articles a, articles t
+        WHERE a.id = l.source_article_id AND a.config_id = $1 AND t.url = l.target_url
+            AND l.target_article_id IS DISTINCT FROM t.id
+    `
+
+	_, err := s.db.ExecContext(ctx, query, configID)
+	return err
+}
+
+func (s *PostgresStore) queryArticleLinks(ctx context.Context, query string, args ...interface{}) ([]*models.ArticleLink, error) {
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var links []*models.ArticleLink
+	for rows.Next() {
+		link := &models.ArticleLink{}
+		err := rows.Scan(
+			&link.ID,
+			&link.SourceArticleID,
+			&link.SourceURL,
+			&link.SourceName,
+			&link.TargetURL,
+			&link.AnchorText,
+			&link.Internal,
+			&link.TargetArticleID,
+			&link.Position,
+			&link.StatusCode,
+			&link.Broken,
+			&link.BrokenReason,
+			&link.CheckedAt,
+			&link.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		links = append(links, link)
+	}
+
+	return links, rows.Err()
+}
+
+func (s *PostgresStore) CreateWebhook(ctx context.Context, webhook *models.Webhook) error {
+	query := `
+        INSERT INTO webhooks (id, url, secret, events, active, created_at, updated_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7)
+    `
+
+	_, err := s.db.ExecContext(ctx, query,
+		webhook.ID,
+		webhook.URL,
+		webhook.Secret,
+		pq.Array(webhook.Events),
+		webhook.Active,
+		webhook.CreatedAt,
+		webhook.UpdatedAt,
+	)
+	return err
+}
+
+// ListWebhooks returns the registered webhooks including their secrets, which callers must not
+// expose.
+func (s *PostgresStore) ListWebhooks(ctx context.Context) ([]*models.Webhook, error) {
+	query := `
+        SELECT id, url, secret, events, active, created_at, updated_at
+        FROM webhooks
+        ORDER BY created_at
+    `
+
+	rows, err := s.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var webhooks []*models.Webhook
+	for rows.Next() {
+		webhook := &models.Webhook{}
+		err := rows.Scan(
+			&webhook.ID,
+			&webhook.URL,
+			&webhook.Secret,
+			pq.Array(&webhook.Events),
+			&webhook.Active,
+			&webhook.CreatedAt,
+			&webhook.UpdatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		webhooks = append(webhooks, webhook)
+	}
+
+	return webhooks, rows.Err()
+}
+
+func (s *PostgresStore) DeleteWebhook(ctx context.Context, id uuid.UUID) error {
+	result, err := s.db.ExecContext(ctx, `DELETE FROM webhooks WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+func (s *PostgresStore) CreateWebhookDelivery(ctx context.Context, delivery *models.WebhookDelivery) error {
+	query := `
+        INSERT INTO webhook_deliveries (
+            id, webhook_id, change_seq, event, payload, status, attempts, next_attempt_at, created_at, updated_at
+        ) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+    `
+
+	_, err := s.db.ExecContext(ctx, query,
+		delivery.ID,
+		delivery.WebhookID,
+		delivery.ChangeSeq,
+		delivery.Event,
+		[]byte(delivery.Payload),
+		delivery.Status,
+		delivery.Attempts,
+		delivery.NextAttemptAt,
+		delivery.CreatedAt,
+		delivery.UpdatedAt,
+	)
+	return err
+}
+
+// ClaimDueWebhookDeliveries returns pending deliveries whose next attempt is due, with their
+// webhook's URL and secret. Claimed deliveries are pushed back by the lease so concurrent
+// dispatchers don't send them twice; recording the attempt sets the real next attempt time.
+func (s *PostgresStore) ClaimDueWebhookDeliveries(ctx context.Context, limit int, lease time.Duration) ([]*models.WebhookDelivery, error) {
+	query := `
+        WITH due AS (
+            SELECT id FROM webhook_deliveries
+            WHERE status = 'pending' AND next_attempt_at <= CURRENT_TIMESTAMP
+            ORDER BY next_attempt_at
+            LIMIT $1
+            FOR UPDATE SKIP LOCKED
+        )
+        UPDATE webhook_deliveries d SET next_attempt_at = CURRENT_TIMESTAMP + $2 * INTERVAL '1 second'
+        FROM due, webhooks w
+        WHERE d.id = due.id AND w.id = d.webhook_id
+        RETURNING ` + deliveryColumns + `, w.url, w.secret
+    `
+
+	rows, err := s.db.QueryContext(ctx, query, limit, lease.Seconds())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var deliveries []*models.WebhookDelivery
+	for rows.Next() {
+		delivery := &models.WebhookDelivery{}
+		if err := rows.Scan(append(deliveryFields(delivery), &delivery.URL, &delivery.Secret)...); err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, delivery)
+	}
+
+	return deliveries, rows.Err()
+}
+
+// UpdateWebhookDelivery records the outcome of a delivery attempt.
+func (s *PostgresStore) UpdateWebhookDelivery(ctx context.Context, delivery *models.WebhookDelivery) error {
+	query := `
+        UPDATE webhook_deliveries SET
+            status = $2,
+            attempts = $3,
+            last_error = NULLIF($4, ''),
+            response_status = NULLIF($5, 0),
+            next_attempt_at = $6,
+            delivered_at = $7,
+            updated_at = CURRENT_TIMESTAMP
+        WHERE id = $1
+    `
+
+	_, err := s.db.ExecContext(ctx, query,
+		delivery.ID,
+		delivery.Status,
+		delivery.Attempts,
+		delivery.LastError,
+		delivery.ResponseStatus,
+		delivery.NextAttemptAt,
+		delivery.DeliveredAt,
+	)
+	return err
+}
+
+// ListWebhookDeliveries returns a webhook's deliveries, newest first, optionally by status.
+func (s *PostgresStore) ListWebhookDeliveries(ctx context.Context, webhookID uuid.UUID, status string, limit, offset int) ([]*models.WebhookDelivery, error) {
+	query := `
+        SELECT ` + deliveryColumns + `
+        FROM webhook_deliveries d
+        WHERE d.webhook_id = $1 AND ($2 = '' OR d.status = $2)
+        ORDER BY d.created_at DESC
+        LIMIT $3 OFFSET $4
+    `
+
+	rows, err := s.db.QueryContext(ctx, query, webhookID, status, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var deliveries []*models.WebhookDelivery
+	for rows.Next() {
+		delivery := &models.WebhookDelivery{}
+		if err := rows.Scan(deliveryFields(delivery)...); err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, delivery)
+	}
+
+	return deliveries, rows.Err()
+}
+
+// RetryWebhookDelivery puts a dead-lettered delivery back in the queue with a fresh attempt count.
+func (s *PostgresStore) RetryWebhookDelivery(ctx context.Context, id uuid.UUID) error {
+	query := `
+        UPDATE webhook_deliveries SET
+            status = 'pending',
+            attempts = 0,
+            next_attempt_at = CURRENT_TIMESTAMP,
+            updated_at = CURRENT_TIMESTAMP
+        WHERE id = $1 AND status = 'dead'
+    `
+
+	result, err := s.db.ExecContext(ctx, query, id)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// New Crawler Config Methods
+// CreateCrawlRun records the start of a crawl run.
+func (s *PostgresStore) CreateCrawlRun(ctx context.Context, run *models.CrawlRun) error {
+	query := `
+        INSERT INTO crawl_runs (id, config_id, product, status, options, log_path, started_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7)
+    `
+
+	_, err := s.db.ExecContext(ctx, query, run.ID, run.ConfigID, run.Product, run.Status, run.Options, run.LogPath,
+		run.StartedAt)
+	return err
+}
+
+// FinishCrawlRun records the outcome of a crawl run.
+func (s *PostgresStore) FinishCrawlRun(ctx context.Context, run *models.CrawlRun) error {
+	query := `
+        UPDATE crawl_runs SET status = $2, summary = $3, error = NULLIF($4, ''), finished_at = $5
+        WHERE id = $1
+    `
+
+	result, err := s.db.ExecContext(ctx, query, run.ID, run.Status, run.Summary, run.Error, run.FinishedAt)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+func (s *PostgresStore) GetCrawlRun(ctx context.Context, id uuid.UUID) (*models.CrawlRun, error) {
+	query := `SELECT ` + crawlRunColumns + ` FROM crawl_runs WHERE id = $1`
+
+	run, err := scanCrawlRun(s.db.QueryRowContext(ctx, query, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return run, nil
+}
+
+// ListCrawlRuns returns crawl runs, newest first, optionally only those of one config.
+func (s *PostgresStore) ListCrawlRuns(ctx context.Context, configID *uuid.UUID, limit, offset int) ([]*models.CrawlRun, error) {
+	query := `
+        SELECT ` + crawlRunColumns + `
+        FROM crawl_runs
+        WHERE ($1::uuid IS NULL OR config_id = $1::uuid)
+        ORDER BY started_at DESC
+        LIMIT $2 OFFSET $3
+    `
+
+	rows, err := s.db.QueryContext(ctx, query, configID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var runs []*models.CrawlRun
+	for rows.Next() {
+		run, err := scanCrawlRun(rows)
+		if err != nil {
+			return nil, err
+		}
+		runs = append(runs, run)
+	}
+
+	return runs, rows.Err()
+}
+
+// SetRunReport stores the report of a dry run on its run.
+func (s *PostgresStore) SetRunReport(ctx context.Context, runID uuid.UUID, report *models.DryRunReport) error {
+	result, err := s.db.ExecContext(ctx, `UPDATE crawl_runs SET report = $2 WHERE id = $1`, runID, report)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// GetRunReport returns the report of a dry run, or nil when the run has none.
+func (s *PostgresStore) GetRunReport(ctx context.Context, runID uuid.UUID) (*models.DryRunReport, error) {
+	var data []byte
+	err := s.db.QueryRowContext(ctx, `SELECT report FROM crawl_runs WHERE id = $1`, runID).Scan(&data)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil || data == nil {
+		return nil, err
+	}
+
+	report := &models.DryRunReport{}
+	if err := report.Scan(data); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// SetRunCategories stores the category structure mapped for a distributed run, as category IDs
+// by category path, so workers resolve pages to the same categories.
+func (s *PostgresStore) SetRunCategories(ctx context.Context, runID uuid.UUID, categories map[string]uuid.UUID) error {
+	data, err := json.Marshal(categories)
+	if err != nil {
+		return err
+	}
+
+	result, err := s.db.ExecContext(ctx, `UPDATE crawl_runs SET categories = $2 WHERE id = $1`, runID, data)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// GetRunCategories returns the category structure stored for a distributed run, or nil when
+// the run has none.
+func (s *PostgresStore) GetRunCategories(ctx context.Context, runID uuid.UUID) (map[string]uuid.UUID, error) {
+	var data []byte
+	err := s.db.QueryRowContext(ctx, `SELECT categories FROM crawl_runs WHERE id = $1`, runID).Scan(&data)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil || data == nil {
+		return nil, err
+	}
+
+	var categories map[string]uuid.UUID
+	if err := json.Unmarshal(data, &categories); err != nil {
+		return nil, err
+	}
+	return categories, nil
+}
+
+// SetRunConfig stores the crawler config a distributed run started with, so its workers crawl
+// with the same settings however the config is edited during the run.
+func (s *PostgresStore) SetRunConfig(ctx context.Context, runID uuid.UUID, config *models.CrawlerConfig) error {
+	data, err := json.Marshal(config)
+	if err != nil {
+		return err
+	}
+
+	result, err := s.db.ExecContext(ctx, `UPDATE crawl_runs SET config = $2 WHERE id = $1`, runID, data)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// GetRunConfig returns the crawler config stored for a distributed run, or nil when the run has
+// none.
+func (s *PostgresStore) GetRunConfig(ctx context.Context, runID uuid.UUID) (*models.CrawlerConfig, error) {
+	var data []byte
+	err := s.db.QueryRowContext(ctx, `SELECT config FROM crawl_runs WHERE id = $1`, runID).Scan(&data)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil || data == nil {
+		return nil, err
+	}
+
+	config := &models.CrawlerConfig{}
+	if err := json.Unmarshal(data, config); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+// runURLBatch is how many URLs AddRunURLs inserts per statement.
+const runURLBatch = 1000
+
+// AddRunURLs queues URLs for the workers of a distributed run and returns how many were added.
+// URLs the run already has are skipped, so each page is fetched once however it was found.
+func (s *PostgresStore) AddRunURLs(ctx context.Context, runID uuid.UUID, urls []*models.RunURL) (int, error) {
+	query := `
+        INSERT INTO crawl_run_urls (run_id, url, depth, in_sitemap, status)
+        SELECT $1, u.url, u.depth, u.in_sitemap, 'pending'
+        FROM unnest($2::text[], $3::int[], $4::bool[]) AS u(url, depth, in_sitemap)
+        ON CONFLICT (run_id, url) DO NOTHING
+    `
+
+	added := 0
+	for start := 0; start < len(urls); start += runURLBatch {
+		batch := urls[start:min(start+runURLBatch, len(urls))]
+		pageURLs := make([]string, len(batch))
+		depths := make([]int64, len(batch))
+		inSitemap := make([]bool, len(batch))
+		for i, u := range batch {
+			pageURLs[i], depths[i], inSitemap[i] = u.URL, int64(u.Depth), u.InSitemap
+		}
+
+		result, err := s.db.ExecContext(ctx, query, runID, pq.Array(pageURLs), pq.Array(depths), pq.Array(inSitemap))
+		if err != nil {
+			return added, err
+		}
+		rows, err := result.RowsAffected()
+		if err != nil {
+			return added, err
+		}
+		added += int(rows)
+	}
+	return added, nil
+}
+
+// ClaimRunURLs leases up to limit URLs of running distributed runs to a worker, oldest first.
+// URLs whose lease ran out are claimed again until they have been tried maxAttempts times.
+func (s *PostgresStore) ClaimRunURLs(ctx context.Context, worker string, limit, maxAttempts int, lease time.Duration) ([]*models.RunURL, error) {
+	query := `
+        WITH next AS (
+            SELECT u.run_id AS next_run, u.url AS next_url
+            FROM crawl_run_urls u
+            JOIN crawl_runs r ON r.id = u.run_id AND r.status = 'running'
+            WHERE (u.status = 'pending' OR (u.status = 'leased' AND u.lease_until <= CURRENT_TIMESTAMP))
+              AND u.attempts < $3
+            ORDER BY u.queued_at
+            LIMIT $2
+            FOR UPDATE OF u SKIP LOCKED
+        )
+        UPDATE crawl_run_urls u SET
+            status = 'leased',
+            worker = $1,
+            attempts = u.attempts + 1,
+            lease_until = CURRENT_TIMESTAMP + $4 * INTERVAL '1 second'
+        FROM next, crawl_runs r
+        WHERE u.run_id = next.next_run AND u.url = next.next_url AND r.id = u.run_id
+        RETURNING ` + runURLColumns
+
+	return s.queryRunURLs(ctx, query, worker, limit, maxAttempts, lease.Seconds())
+}
+
+// RenewRunURLLeases extends the leases a worker holds and returns how many it still holds.
+func (s *PostgresStore) RenewRunURLLeases(ctx context.Context, worker string, lease time.Duration) (int64, error) {
+	query := `
+        UPDATE crawl_run_urls SET lease_until = CURRENT_TIMESTAMP + $2 * INTERVAL '1 second'
+        WHERE worker = $1 AND status = 'leased'
+    `
+
+	result, err := s.db.ExecContext(ctx, query, worker, lease.Seconds())
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// CompleteRunURLs records what a worker found for URLs it holds the lease on. URLs whose lease
+// passed to another worker in the meantime are left to that worker.
+func (s *PostgresStore) CompleteRunURLs(ctx context.Context, worker string, urls []*models.RunURL) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx, `
+        UPDATE crawl_run_urls SET
+            status = $4,
+            status_code = NULLIF($5, 0),
+            saved_url = NULLIF($6, ''),
+            error = NULLIF($7, ''),
+            lease_until = NULL
+        WHERE run_id = $1 AND url = $2 AND worker = $3 AND status = 'leased'
+    `)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, u := range urls {
+		if _, err := stmt.ExecContext(ctx, u.RunID, u.URL, worker, u.Status, u.StatusCode, u.SavedURL, u.Error); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// CancelRunURLs marks a distributed run's unfinished URLs as failed, so workers stop claiming
+// them, and returns how many there were.
+func (s *PostgresStore) CancelRunURLs(ctx context.Context, runID uuid.UUID, reason string) (int64, error) {
+	query := `
+        UPDATE crawl_run_urls SET status = 'failed', error = $2, lease_until = NULL
+        WHERE run_id = $1 AND status IN ('pending', 'leased')
+    `
+
+	result, err := s.db.ExecContext(ctx, query, runID, reason)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// GetRunURLProgress counts a distributed run's URLs by status. Leased URLs whose lease ran out
+// on the last of maxAttempts attempts count as failed.
+func (s *PostgresStore) GetRunURLProgress(ctx context.Context, runID uuid.UUID, maxAttempts int) (*models.RunURLProgress, error) {
+	query := `
+        SELECT
+            COUNT(*) FILTER (WHERE status = 'pending'),
+            COUNT(*) FILTER (WHERE status = 'leased' AND (lease_until > CURRENT_TIMESTAMP OR attempts < $2)),
+            COUNT(*) FILTER (WHERE status = 'done'),
+            COUNT(*) FILTER (WHERE status = 'failed'
+                OR (status = 'leased' AND lease_until <= CURRENT_TIMESTAMP AND attempts >= $2)),
+            COUNT(*) FILTER (WHERE status = 'leased' AND lease_until > CURRENT_TIMESTAMP)
+        FROM crawl_run_urls
+        WHERE run_id = $1
+    `
+
+	progress := &models.RunURLProgress{}
+	err := s.db.QueryRowContext(ctx, query, runID, maxAttempts).Scan(
+		&progress.Pending, &progress.Leased, &progress.Done, &progress.Failed, &progress.Live)
+	if err != nil {
+		return nil, err
+	}
+	return progress, nil
+}
+
+// ListRunURLs returns every URL of a distributed run.
+func (s *PostgresStore) ListRunURLs(ctx context.Context, runID uuid.UUID) ([]*models.RunURL, error) {
+	query := `
+        SELECT ` + runURLColumns + `
+        FROM crawl_run_urls u
+        JOIN crawl_runs r ON r.id = u.run_id
+        WHERE u.run_id = $1
+        ORDER BY u.queued_at
+    `
+
+	return s.queryRunURLs(ctx, query, runID)
+}
+
+func (s *PostgresStore) queryRunURLs(ctx context.Context, query string, args ...interface{}) ([]*models.RunURL, error) {
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var urls []*models.RunURL
+	for rows.Next() {
+		u, err := scanRunURL(rows)
+		if err != nil {
+			return nil, err
+		}
+		urls = append(urls, u)
+	}
+
+	return urls, rows.Err()
+}
+
+// EnqueueCrawlJob queues a crawl of the job's config and returns the config's active job. When
+// the config already has a queued or running job, that job is returned instead, with its
+// priority raised to the new job's if that is higher.
+func (s *PostgresStore) EnqueueCrawlJob(ctx context.Context, job *models.CrawlJob) (*models.CrawlJob, error) {
+	insert := `
+        INSERT INTO crawl_jobs (id, config_id, product, trigger, priority, status, options, run_id, created_at)
+        VALUES ($1, $2, $3, $4, $5, 'queued', $6, $7, $8)
+        ON CONFLICT (config_id) WHERE status IN ('queued', 'running') DO NOTHING
+        RETURNING ` + crawlJobColumns
+	existing := `
+        UPDATE crawl_jobs SET priority = GREATEST(priority, $2)
+        WHERE config_id = $1 AND status IN ('queued', 'running')
+        RETURNING ` + crawlJobColumns
+
+	// The active job can finish between the two statements, so try again a few times
+	for attempt := 0; attempt < 3; attempt++ {
+		queued, err := scanCrawlJob(s.db.QueryRowContext(ctx, insert,
+			job.ID, job.ConfigID, job.Product, job.Trigger, job.Priority, job.Options, job.RunID, job.CreatedAt))
+		if err != sql.ErrNoRows {
+			return queued, err
+		}
+
+		queued, err = scanCrawlJob(s.db.QueryRowContext(ctx, existing, job.ConfigID, job.Priority))
+		if err != sql.ErrNoRows {
+			return queued, err
+		}
+	}
+	return nil, fmt.Errorf("failed to enqueue crawl job for config %s", job.ConfigID)
+}
+
+// leaderLockKey returns the session advisory lock key of a leadership role.
+func leaderLockKey(name string) int64 {
+	h := fnv.New64a()
+	h.Write([]byte("kb-crawler:leader:" + name))
+	return int64(h.Sum64())
+}
+
+// TryLeadership takes a leadership role if no other instance holds it, and returns the role's
+// new term with the lock that holds it. The role is a session advisory lock on a connection
+// kept for the lock's lifetime, so Postgres releases it when the holder dies. It returns nil
+// and no lock when another instance holds the role.
+func (s *PostgresStore) TryLeadership(ctx context.Context, name, holder string) (*models.Leader, LeaderLock, error) {
+	conn, err := s.db.Conn(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	key := leaderLockKey(name)
+	var locked bool
+	if err := conn.QueryRowContext(ctx, `SELECT pg_try_advisory_lock($1)`, key).Scan(&locked); err != nil {
+		conn.Close()
+		return nil, nil, err
+	}
+	if !locked {
+		conn.Close()
+		return nil, nil, nil
+	}
+
+	query := `
+        INSERT INTO leaders (name, holder, term, acquired_at, renewed_at)
+        VALUES ($1, $2, 1, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
+        ON CONFLICT (name) DO UPDATE SET
+            holder = EXCLUDED.holder,
+            term = leaders.term + 1,
+            acquired_at = EXCLUDED.acquired_at,
+            renewed_at = EXCLUDED.renewed_at
+        RETURNING name, holder, term, acquired_at, renewed_at
+    `
+
+	leader := &models.Leader{}
+	err = conn.QueryRowContext(ctx, query, name, holder).Scan(
+		&leader.Name, &leader.Holder, &leader.Term, &leader.AcquiredAt, &leader.RenewedAt)
+	if err != nil {
+		conn.ExecContext(context.WithoutCancel(ctx), `SELECT pg_advisory_unlock($1)`, key)
+		conn.Close()
+		return nil, nil, err
+	}
+
+	return leader, &pgLeaderLock{conn: conn, key: key, leader: *leader}, nil
+}
+
+// GetLeader returns the last recorded holder of a leadership role, or nil if it was never
+// held. A holder that died keeps its record until the role is taken over.
+func (s *PostgresStore) GetLeader(ctx context.Context, name string) (*models.Leader, error) {
+	query := `SELECT name, holder, term, acquired_at, renewed_at FROM leaders WHERE name = $1`
+
+	leader := &models.Leader{}
+	err := s.db.QueryRowContext(ctx, query, name).Scan(
+		&leader.Name, &leader.Holder, &leader.Term, &leader.AcquiredAt, &leader.RenewedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return leader, nil
+}
+
+// pgLeaderLock is a leadership role held as a session advisory lock on conn.
+type pgLeaderLock struct {
+	conn   *sql.Conn
+	key    int64
+	leader models.Leader
+}
+
+// Renew records that the role is still held. It fails when the lock's connection is gone, and
+// with sql.ErrNoRows when the record shows another holder or term.
+func (l *pgLeaderLock) Renew(ctx context.Context) error {
+	query := `
+        UPDATE leaders SET renewed_at = CURRENT_TIMESTAMP
+        WHERE name = $1 AND holder = $2 AND term = $3
+    `
+
+	result, err := l.conn.ExecContext(ctx, query, l.leader.Name, l.leader.Holder, l.leader.Term)
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// Release unlocks the role and returns the connection to the pool.
+func (l *pgLeaderLock) Release(ctx context.Context) error {
+	defer l.conn.Close()
+	_, err := l.conn.ExecContext(ctx, `SELECT pg_advisory_unlock($1)`, l.key)
+	return err
+}
+
+// crawlJobClaimLock is the advisory lock key that serializes claims, so the running job count
+// can't change between counting and claiming.
+const crawlJobClaimLock = 0x6b62636a6f6273 // "kbcjobs"
+
+// ClaimCrawlJob marks the highest priority queued job as running under a lease and returns it.
+// Running jobs whose lease has expired, because their worker died, are claimed again. It
+// returns nil when nothing is queued or maxRunning jobs already hold a lease; zero means no
+// limit.
+func (s *PostgresStore) ClaimCrawlJob(ctx context.Context, maxRunning int, lease time.Duration) (*models.CrawlJob, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock($1)`, crawlJobClaimLock); err != nil {
+		return nil, err
+	}
+
+	if maxRunning > 0 {
+		var running int
+		err := tx.QueryRowContext(ctx, `
+            SELECT COUNT(*) FROM crawl_jobs
+            WHERE status = 'running' AND lease_until > CURRENT_TIMESTAMP
+        `).Scan(&running)
+		if err != nil {
+			return nil, err
+		}
+		if running >= maxRunning {
+			return nil, nil
+		}
+	}
+
+	query := `
+        WITH next AS (
+            SELECT id AS next_id FROM crawl_jobs
+            WHERE status = 'queued' OR (status = 'running' AND lease_until <= CURRENT_TIMESTAMP)
+            ORDER BY priority DESC, created_at
+            LIMIT 1
+            FOR UPDATE SKIP LOCKED
+        )
+        UPDATE crawl_jobs SET
+            status = 'running',
+            attempts = attempts + 1,
+            error = NULL,
+            lease_until = CURRENT_TIMESTAMP + $1 * INTERVAL '1 second',
+            started_at = CURRENT_TIMESTAMP
+        FROM next
+        WHERE id = next.next_id
+        RETURNING ` + crawlJobColumns
+
+	job, err := scanCrawlJob(tx.QueryRowContext(ctx, query, lease.Seconds()))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return job, tx.Commit()
+}
+
+// StartCrawlJob adds a job that is already running under a lease, for a crawl run right away
+// by the process that submits it. It returns the config's active job, which is the new one
+// when it was started; an existing queued or running job is returned unchanged. It returns nil
+// when maxRunning jobs already hold a lease; zero means no limit.
+func (s *PostgresStore) StartCrawlJob(ctx context.Context, job *models.CrawlJob, maxRunning int, lease time.Duration) (*models.CrawlJob, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock($1)`, crawlJobClaimLock); err != nil {
+		return nil, err
+	}
+
+	active, err := scanCrawlJob(tx.QueryRowContext(ctx, `
+        SELECT `+crawlJobColumns+` FROM crawl_jobs
+        WHERE config_id = $1 AND status IN ('queued', 'running')
+    `, job.ConfigID))
+	if err != sql.ErrNoRows {
+		return active, err
+	}
+
+	if maxRunning > 0 {
+		var running int
+		err := tx.QueryRowContext(ctx, `
+            SELECT COUNT(*) FROM crawl_jobs
+            WHERE status = 'running' AND lease_until > CURRENT_TIMESTAMP
+        `).Scan(&running)
+		if err != nil {
+			return nil, err
+		}
+		if running >= maxRunning {
+			return nil, nil
+		}
+	}
+
+	query := `
+        INSERT INTO crawl_jobs (id, config_id, product, trigger, priority, status, attempts, options, run_id,
+            lease_until, created_at, started_at)
+        VALUES ($1, $2, $3, $4, $5, 'running', 1, $6, $7,
+            CURRENT_TIMESTAMP + $8 * INTERVAL '1 second', $9, CURRENT_TIMESTAMP)
+        RETURNING ` + crawlJobColumns
+
+	started, err := scanCrawlJob(tx.QueryRowContext(ctx, query,
+		job.ID, job.ConfigID, job.Product, job.Trigger, job.Priority, job.Options, job.RunID,
+		lease.Seconds(), job.CreatedAt))
+	if err != nil {
+		return nil, err
+	}
+	return started, tx.Commit()
+}
+
+// RenewCrawlJobLease extends a running job's lease. It returns sql.ErrNoRows when the job is
+// no longer running.
+func (s *PostgresStore) RenewCrawlJobLease(ctx context.Context, id uuid.UUID, lease time.Duration) error {
+	query := `
+        UPDATE crawl_jobs SET lease_until = CURRENT_TIMESTAMP + $2 * INTERVAL '1 second'
+        WHERE id = $1 AND status = 'running'
+    `
+
+	result, err := s.db.ExecContext(ctx, query, id, lease.Seconds())
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// FinishCrawlJob records a running job's outcome and releases its lease.
+func (s *PostgresStore) FinishCrawlJob(ctx context.Context, job *models.CrawlJob) error {
+	query := `
+        UPDATE crawl_jobs SET status = $2, run_id = $3, error = NULLIF($4, ''), finished_at = $5, lease_until = NULL
+        WHERE id = $1 AND status = 'running'
+    `
+
+	result, err := s.db.ExecContext(ctx, query, job.ID, job.Status, job.RunID, job.Error, job.FinishedAt)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// CancelCrawlJob cancels a queued job. It returns sql.ErrNoRows when the job is not queued.
+func (s *PostgresStore) CancelCrawlJob(ctx context.Context, id uuid.UUID) error {
+	query := `
+        UPDATE crawl_jobs SET status = 'cancelled', finished_at = CURRENT_TIMESTAMP
+        WHERE id = $1 AND status = 'queued'
+    `
+
+	result, err := s.db.ExecContext(ctx, query, id)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+func (s *PostgresStore) GetCrawlJob(ctx context.Context, id uuid.UUID) (*models.CrawlJob, error) {
+	query := `SELECT ` + crawlJobColumns + ` FROM crawl_jobs WHERE id = $1`
+
+	job, err := scanCrawlJob(s.db.QueryRowContext(ctx, query, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+// ListCrawlJobs returns crawl jobs, optionally by status. Active jobs come first, in the order
+// they will be claimed, followed by finished jobs, newest first.
+func (s *PostgresStore) ListCrawlJobs(ctx context.Context, status string, limit, offset int) ([]*models.CrawlJob, error) {
+	query := `
+        SELECT ` + crawlJobColumns + `
+        FROM crawl_jobs
+        WHERE ($1 = '' OR status = $1)
+        ORDER BY status IN ('queued', 'running') DESC,
+                 CASE WHEN status IN ('queued', 'running') THEN priority END DESC,
+                 CASE WHEN status IN ('queued', 'running') THEN created_at END,
+                 created_at DESC
+        LIMIT $2 OFFSET $3
+    `
+
+	rows, err := s.db.QueryContext(ctx, query, status, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var jobs []*models.CrawlJob
+	for rows.Next() {
+		job, err := scanCrawlJob(rows)
+		if err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, job)
+	}
+
+	return jobs, rows.Err()
+}
+
+func (s *PostgresStore) ListCrawlerConfigs(ctx context.Context) ([]*models.CrawlerConfig, error) {
+	query := `
+        SELECT ` + crawlerConfigColumns + `
+        FROM crawler_configs
+        ORDER BY created_at DESC
+    `
+
+	rows, err := s.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var configs []*models.CrawlerConfig
+	for rows.Next() {
+		config, err := scanCrawlerConfig(rows)
+		if err != nil {
+			return nil, err
+		}
+		configs = append(configs, config)
+	}
+
+	return configs, nil
+}
+
+func (s *PostgresStore) GetCrawlerConfig(ctx context.Context, id uuid.UUID) (*models.CrawlerConfig, error) {
+	query := `
+        SELECT ` + crawlerConfigColumns + `
+        FROM crawler_configs
+        WHERE id = $1
+    `
+
+	config, err := scanCrawlerConfig(s.db.QueryRowContext(ctx, query, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return config, nil
+}
+
+func (s *PostgresStore) CreateCrawlerConfig(ctx context.Context, config *models.CrawlerConfig) error {
+	query := `
+        INSERT INTO crawler_configs (
+            id, product, sitemap_url, map_url, user_agent, crawl_interval, max_depth,
+            default_category, allowed_domains, profile, download_assets, follow_links, include_patterns,
+            exclude_patterns, url_filters, status, last_run, last_run_summary, errors, logs, created_at, updated_at,
+            duplicate_policy, last_run_id, enabled, next_run
+        ) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22, $23, $24,
+            $25, $26)
     `
 
 	_, err := s.db.ExecContext(ctx, query,
@@ -466,17 +2322,32 @@ func (s *PostgresStore) CreateCrawlerConfig(ctx context.Context, config *models.
 		config.MaxDepth,
 		config.DefaultCategory,
 		pq.Array(config.AllowedDomains),
+		config.Profile,
+		config.DownloadAssets,
+		config.FollowLinks,
+		pq.Array(config.IncludePatterns),
+		pq.Array(config.ExcludePatterns),
+		config.URLFilters,
 		config.Status,
 		config.LastRun,
+		config.LastRunSummary,
 		pq.Array(config.Errors),
 		pq.Array(config.Logs),
 		config.CreatedAt,
 		config.UpdatedAt,
+		config.DuplicatePolicy,
+		config.LastRunID,
+		config.Enabled,
+		config.NextRun,
 	)
 
 	return err
 }
 
+// UpdateCrawlerConfig saves a crawler config's settings. Its status and run history are only
+// changed through UpdateCrawlerConfigRun, and whether it is enabled through
+// SetCrawlerConfigEnabled, so an edit and a run finishing don't undo each other. The next run
+// is only saved along with a new schedule; otherwise the scheduler owns it.
 func (s *PostgresStore) UpdateCrawlerConfig(ctx context.Context, config *models.CrawlerConfig) error {
 	query := `
         UPDATE crawler_configs SET
@@ -488,10 +2359,14 @@ func (s *PostgresStore) UpdateCrawlerConfig(ctx context.Context, config *models.
             max_depth = $7,
             default_category = $8,
             allowed_domains = $9,
-            status = $10,
-            last_run = $11,
-            errors = $12,
-            logs = $13,
+            profile = $10,
+            download_assets = $11,
+            follow_links = $12,
+            include_patterns = $13,
+            exclude_patterns = $14,
+            url_filters = $15,
+            duplicate_policy = $16,
+            next_run = CASE WHEN crawl_interval IS DISTINCT FROM $6 THEN $17 ELSE next_run END,
             updated_at = CURRENT_TIMESTAMP
         WHERE id = $1
     `
@@ -506,10 +2381,89 @@ func (s *PostgresStore) UpdateCrawlerConfig(ctx context.Context, config *models.
 		config.MaxDepth,
 		config.DefaultCategory,
 		pq.Array(config.AllowedDomains),
+		config.Profile,
+		config.DownloadAssets,
+		config.FollowLinks,
+		pq.Array(config.IncludePatterns),
+		pq.Array(config.ExcludePatterns),
+		config.URLFilters,
+		config.DuplicatePolicy,
+		config.NextRun,
+	)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// AdvanceCrawlerConfigNextRun moves a crawler config's next run from due to next. It reports
+// false when the next run is no longer due, because another scheduler moved it on first or the
+// config was edited, enabled or disabled meanwhile.
+func (s *PostgresStore) AdvanceCrawlerConfigNextRun(ctx context.Context, id uuid.UUID, due, next *time.Time) (bool, error) {
+	query := `
+        UPDATE crawler_configs SET next_run = $3
+        WHERE id = $1 AND next_run IS NOT DISTINCT FROM $2
+    `
+
+	result, err := s.db.ExecContext(ctx, query, id, due, next)
+	if err != nil {
+		return false, err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rows > 0, nil
+}
+
+// MarkCrawlerConfigQueued sets a crawler config's status to Queued while its job is still
+// waiting. Once a worker has claimed the job, the run owns the status and it is left alone.
+func (s *PostgresStore) MarkCrawlerConfigQueued(ctx context.Context, id, jobID uuid.UUID) error {
+	query := `
+        UPDATE crawler_configs SET status = 'Queued', updated_at = CURRENT_TIMESTAMP
+        WHERE id = $1
+          AND status <> 'Running'
+          AND EXISTS (SELECT 1 FROM crawl_jobs WHERE id = $2 AND status = 'queued')
+    `
+
+	_, err := s.db.ExecContext(ctx, query, id, jobID)
+	return err
+}
+
+// UpdateCrawlerConfigRun saves the state a run leaves on its crawler config: the status, last
+// run, summary, errors, log tail and run ID. The settings are left as they are, so edits made
+// while a crawl runs are kept. It returns sql.ErrNoRows when the config does not exist.
+func (s *PostgresStore) UpdateCrawlerConfigRun(ctx context.Context, config *models.CrawlerConfig) error {
+	query := `
+        UPDATE crawler_configs SET
+            status = $2,
+            last_run = $3,
+            last_run_summary = $4,
+            errors = $5,
+            logs = $6,
+            last_run_id = $7,
+            updated_at = CURRENT_TIMESTAMP
+        WHERE id = $1
+    `
+
+	result, err := s.db.ExecContext(ctx, query,
+		config.ID,
 		config.Status,
 		config.LastRun,
+		config.LastRunSummary,
 		pq.Array(config.Errors),
 		pq.Array(config.Logs),
+		config.LastRunID,
 	)
 	if err != nil {
 		return err
@@ -522,7 +2476,35 @@ func (s *PostgresStore) UpdateCrawlerConfig(ctx context.Context, config *models.
 	if rows == 0 {
 		return sql.ErrNoRows
 	}
+	return nil
+}
+
+// SetCrawlerConfigEnabled puts a crawler config on the schedule or takes it off, with its new
+// status and next run. A config with a live job keeps its status until the run finishes. It
+// returns sql.ErrNoRows when the config does not exist.
+func (s *PostgresStore) SetCrawlerConfigEnabled(ctx context.Context, id uuid.UUID, enabled bool, status string,
+	nextRun *time.Time) error {
+	query := `
+        UPDATE crawler_configs SET
+            enabled = $2,
+            status = CASE WHEN ` + activeJobStatus + ` IS NULL THEN $3 ELSE status END,
+            next_run = $4,
+            updated_at = CURRENT_TIMESTAMP
+        WHERE id = $1
+    `
+
+	result, err := s.db.ExecContext(ctx, query, id, enabled, status, nextRun)
+	if err != nil {
+		return err
+	}
 
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return sql.ErrNoRows
+	}
 	return nil
 }
 
@@ -547,3 +2529,243 @@ func (s *PostgresStore) DeleteCrawlerConfig(ctx context.Context, id uuid.UUID) e
 func (s *PostgresStore) Close() error {
 	return s.db.Close()
 }
+
+// rowScanner is satisfied by both *sql.Row and *sql.Rows.
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// articleColumns lists the article columns in the order scanArticle reads them.
+const articleColumns = `id, category_id, name, body, url, tags, author, metadata, content_confidence,
+               canonical_url, language, description, published_at, modified_at, open_graph, structured_data,
+               config_id, in_sitemap, deleted_at, COALESCE(deleted_reason, ''), COALESCE(text_hash, ''),
+               COALESCE(simhash, 0), duplicate_of, created_at, updated_at`
+
+func scanArticle(row rowScanner) (*models.Article, error) {
+	article := &models.Article{}
+	var tags []string
+	var canonicalURL, language, description sql.NullString
+
+	err := row.Scan(
+		&article.ID,
+		&article.CategoryID,
+		&article.Name,
+		&article.Body,
+		&article.URL,
+		pq.Array(&tags),
+		&article.Author,
+		&article.Metadata,
+		&article.ContentConfidence,
+		&canonicalURL,
+		&language,
+		&description,
+		&article.PublishedAt,
+		&article.ModifiedAt,
+		&article.OpenGraph,
+		&article.StructuredData,
+		&article.ConfigID,
+		&article.InSitemap,
+		&article.DeletedAt,
+		&article.DeletedReason,
+		&article.TextHash,
+		&article.SimHash,
+		&article.DuplicateOf,
+		&article.CreatedAt,
+		&article.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	article.Tags = tags
+	article.CanonicalURL = canonicalURL.String
+	article.Language = language.String
+	article.Description = description.String
+	return article, nil
+}
+
+// assetColumns lists the asset columns, aliased as "a", in the order assetFields returns them.
+const assetColumns = `a.id, a.url, a.kind, COALESCE(a.mime_type, ''), COALESCE(a.sha256, ''), COALESCE(a.size, 0),
+               a.downloaded_at, a.created_at, a.updated_at`
+
+func assetFields(asset *models.Asset) []interface{} {
+	return []interface{}{
+		&asset.ID,
+		&asset.URL,
+		&asset.Kind,
+		&asset.MIMEType,
+		&asset.SHA256,
+		&asset.Size,
+		&asset.DownloadedAt,
+		&asset.CreatedAt,
+		&asset.UpdatedAt,
+	}
+}
+
+// articleLinkColumns lists the link columns, aliased as "l" and joined to the source article
+// as "a", in the order queryArticleLinks reads them.
+const articleLinkColumns = `l.id, l.source_article_id, a.url, a.name, l.target_url, COALESCE(l.anchor_text, ''),
+               l.is_internal, l.target_article_id, l.position, COALESCE(l.status_code, 0), l.broken,
+               COALESCE(l.broken_reason, ''), l.checked_at, l.created_at`
+
+// changeColumns lists the change log columns in the order queryChanges reads them.
+const changeColumns = `seq, article_id, config_id, url, change_type, COALESCE(version, 0), COALESCE(content_hash, ''),
+               COALESCE(reason, ''), created_at`
+
+// deliveryColumns lists the webhook delivery columns, aliased as "d", in the order
+// deliveryFields returns them.
+const deliveryColumns = `d.id, d.webhook_id, d.change_seq, d.event, d.payload, d.status, d.attempts,
+               COALESCE(d.last_error, ''), COALESCE(d.response_status, 0), d.next_attempt_at, d.delivered_at,
+               d.created_at, d.updated_at`
+
+func deliveryFields(delivery *models.WebhookDelivery) []interface{} {
+	return []interface{}{
+		&delivery.ID,
+		&delivery.WebhookID,
+		&delivery.ChangeSeq,
+		&delivery.Event,
+		&delivery.Payload,
+		&delivery.Status,
+		&delivery.Attempts,
+		&delivery.LastError,
+		&delivery.ResponseStatus,
+		&delivery.NextAttemptAt,
+		&delivery.DeliveredAt,
+		&delivery.CreatedAt,
+		&delivery.UpdatedAt,
+	}
+}
+
+// activeJobStatus selects the status of a crawler config's live job: queued, or running under a
+// lease that has not run out. It is NULL when no crawl of the config is queued or in progress,
+// including when the process running it died, whatever the config's status says.
+const activeJobStatus = `(SELECT j.status FROM crawl_jobs j
+               WHERE j.config_id = crawler_configs.id
+                 AND (j.status = 'queued' OR (j.status = 'running' AND j.lease_until > CURRENT_TIMESTAMP))
+               LIMIT 1)`
+
+// crawlerConfigColumns lists the crawler config columns in the order scanCrawlerConfig reads them.
+const crawlerConfigColumns = `id, product, sitemap_url, map_url, user_agent, crawl_interval, max_depth,
+               default_category, allowed_domains, profile, download_assets, follow_links, include_patterns,
+               exclude_patterns, url_filters, status, last_run, last_run_summary, errors, logs, created_at,
+               updated_at, duplicate_policy, last_run_id, enabled, next_run, COALESCE(` + activeJobStatus + `, '')`
+
+func scanCrawlerConfig(row rowScanner) (*models.CrawlerConfig, error) {
+	config := &models.CrawlerConfig{}
+	err := row.Scan(
+		&config.ID,
+		&config.Product,
+		&config.SitemapURL,
+		&config.MapURL,
+		&config.UserAgent,
+		&config.CrawlInterval,
+		&config.MaxDepth,
+		&config.DefaultCategory,
+		pq.Array(&config.AllowedDomains),
+		&config.Profile,
+		&config.DownloadAssets,
+		&config.FollowLinks,
+		pq.Array(&config.IncludePatterns),
+		pq.Array(&config.ExcludePatterns),
+		&config.URLFilters,
+		&config.Status,
+		&config.LastRun,
+		&config.LastRunSummary,
+		pq.Array(&config.Errors),
+		pq.Array(&config.Logs),
+		&config.CreatedAt,
+		&config.UpdatedAt,
+		&config.DuplicatePolicy,
+		&config.LastRunID,
+		&config.Enabled,
+		&config.NextRun,
+		&config.ActiveJob,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+// crawlRunColumns lists the crawl run columns in the order scanCrawlRun reads them.
+const crawlRunColumns = `id, config_id, product, status, options, summary, COALESCE(error, ''), log_path, started_at,
+               finished_at`
+
+func scanCrawlRun(row rowScanner) (*models.CrawlRun, error) {
+	run := &models.CrawlRun{}
+	err := row.Scan(
+		&run.ID,
+		&run.ConfigID,
+		&run.Product,
+		&run.Status,
+		&run.Options,
+		&run.Summary,
+		&run.Error,
+		&run.LogPath,
+		&run.StartedAt,
+		&run.FinishedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return run, nil
+}
+
+// crawlJobColumns lists the crawl job columns in the order scanCrawlJob reads them.
+const crawlJobColumns = `id, config_id, product, trigger, priority, status, attempts, options, run_id,
+               COALESCE(error, ''), lease_until, created_at, started_at, finished_at`
+
+func scanCrawlJob(row rowScanner) (*models.CrawlJob, error) {
+	job := &models.CrawlJob{}
+	err := row.Scan(
+		&job.ID,
+		&job.ConfigID,
+		&job.Product,
+		&job.Trigger,
+		&job.Priority,
+		&job.Status,
+		&job.Attempts,
+		&job.Options,
+		&job.RunID,
+		&job.Error,
+		&job.LeaseUntil,
+		&job.CreatedAt,
+		&job.StartedAt,
+		&job.FinishedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+// runURLColumns lists the run URL columns in the order scanRunURL reads them, with the run's
+// config ID from crawl_runs r.
+const runURLColumns = `u.run_id, u.url, u.depth, u.in_sitemap, u.status, COALESCE(u.worker, ''), u.lease_until,
+               u.attempts, COALESCE(u.status_code, 0), COALESCE(u.saved_url, ''), COALESCE(u.error, ''), r.config_id`
+
+func scanRunURL(row rowScanner) (*models.RunURL, error) {
+	u := &models.RunURL{}
+	var configID *uuid.UUID
+	err := row.Scan(
+		&u.RunID,
+		&u.URL,
+		&u.Depth,
+		&u.InSitemap,
+		&u.Status,
+		&u.Worker,
+		&u.LeaseUntil,
+		&u.Attempts,
+		&u.StatusCode,
+		&u.SavedURL,
+		&u.Error,
+		&configID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if configID != nil {
+		u.ConfigID = *configID
+	}
+	return u, nil
+}
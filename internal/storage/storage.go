@@ -2,6 +2,7 @@ package storage
 
 import (
 	"context"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/romangod6/kb-crawler/internal/models"
@@ -17,16 +18,104 @@ type Store interface {
 	ListCategories(ctx context.Context) ([]*models.Category, error)
 
 	// Article operations
-	CreateArticle(ctx context.Context, article *models.Article) error
+	CreateArticle(ctx context.Context, article *models.Article) (*models.ArticleChange, error)
 	GetArticle(ctx context.Context, id uuid.UUID) (*models.Article, error)
-	ListArticles(ctx context.Context, limit, offset int) ([]*models.Article, error)
-	SearchArticles(ctx context.Context, query string, limit, offset int) ([]*models.Article, error)
-	GetArticlesByCategory(ctx context.Context, categoryID uuid.UUID, limit, offset int) ([]*models.Article, error)
+	ListArticles(ctx context.Context, limit, offset int, includeDeleted bool) ([]*models.Article, error)
+	SearchArticles(ctx context.Context, query string, limit, offset int, includeDeleted bool) ([]*models.Article, error)
+	GetArticlesByCategory(ctx context.Context, categoryID uuid.UUID, limit, offset int, includeDeleted bool) ([]*models.Article, error)
+	ListLowConfidenceArticles(ctx context.Context, threshold float64, limit, offset int, includeDeleted bool) ([]*models.Article, error)
+	ListArticleRefs(ctx context.Context) ([]*models.ArticleRef, error)
+	MergeArticles(ctx context.Context, keepID uuid.UUID, duplicateIDs []uuid.UUID, url string) error
+	ListArticleFingerprints(ctx context.Context) ([]*models.ArticleFingerprint, error)
+	ListActiveArticleRefs(ctx context.Context, configID uuid.UUID) ([]*models.ArticleRef, error)
+	TombstoneArticles(ctx context.Context, ids []uuid.UUID, reason string) ([]*models.ArticleChange, error)
+	PurgeDeletedArticles(ctx context.Context, before time.Time) (int64, error)
+
+	// Change log and webhook operations
+	ListChanges(ctx context.Context, since int64, limit int) ([]*models.ArticleChange, error)
+	CreateWebhook(ctx context.Context, webhook *models.Webhook) error
+	ListWebhooks(ctx context.Context) ([]*models.Webhook, error)
+	DeleteWebhook(ctx context.Context, id uuid.UUID) error
+	CreateWebhookDelivery(ctx context.Context, delivery *models.WebhookDelivery) error
+	ClaimDueWebhookDeliveries(ctx context.Context, limit int, lease time.Duration) ([]*models.WebhookDelivery, error)
+	UpdateWebhookDelivery(ctx context.Context, delivery *models.WebhookDelivery) error
+	ListWebhookDeliveries(ctx context.Context, webhookID uuid.UUID, status string, limit, offset int) ([]*models.WebhookDelivery, error)
+	RetryWebhookDelivery(ctx context.Context, id uuid.UUID) error
+
+	// Article version operations
+	ListArticleVersions(ctx context.Context, articleID uuid.UUID) ([]*models.ArticleVersion, error)
+	GetArticleVersion(ctx context.Context, articleID uuid.UUID, version int) (*models.ArticleVersion, error)
+
+	// Article section operations
+	ReplaceArticleSections(ctx context.Context, articleID uuid.UUID, sections []*models.ArticleSection) error
+	GetArticleSections(ctx context.Context, articleID uuid.UUID) ([]*models.ArticleSection, error)
+
+	// Asset operations
+	UpsertAsset(ctx context.Context, asset *models.Asset) error
+	UpdateAssetBlob(ctx context.Context, asset *models.Asset) error
+	ReplaceArticleAssets(ctx context.Context, articleID uuid.UUID, assets []*models.Asset) error
+	ListArticleAssets(ctx context.Context, articleID uuid.UUID) ([]*models.Asset, error)
+	GetAsset(ctx context.Context, id uuid.UUID) (*models.Asset, error)
+
+	// Link operations
+	ReplaceArticleLinks(ctx context.Context, articleID uuid.UUID, links []*models.ArticleLink) error
+	ListArticleLinks(ctx context.Context, articleID uuid.UUID) ([]*models.ArticleLink, error)
+	ListBacklinks(ctx context.Context, articleID uuid.UUID) ([]*models.ArticleLink, error)
+	ListBrokenLinks(ctx context.Context, configID uuid.UUID) ([]*models.ArticleLink, error)
+	ListInternalLinkTargets(ctx context.Context, configID uuid.UUID) ([]string, error)
+	UpdateLinkStatuses(ctx context.Context, configID uuid.UUID, statuses []models.LinkStatus) error
+	ResolveLinkTargets(ctx context.Context, configID uuid.UUID) error
+
+	// Distributed run operations
+	SetRunCategories(ctx context.Context, runID uuid.UUID, categories map[string]uuid.UUID) error
+	GetRunCategories(ctx context.Context, runID uuid.UUID) (map[string]uuid.UUID, error)
+	SetRunConfig(ctx context.Context, runID uuid.UUID, config *models.CrawlerConfig) error
+	GetRunConfig(ctx context.Context, runID uuid.UUID) (*models.CrawlerConfig, error)
+	AddRunURLs(ctx context.Context, runID uuid.UUID, urls []*models.RunURL) (int, error)
+	ClaimRunURLs(ctx context.Context, worker string, limit, maxAttempts int, lease time.Duration) ([]*models.RunURL, error)
+	RenewRunURLLeases(ctx context.Context, worker string, lease time.Duration) (int64, error)
+	CompleteRunURLs(ctx context.Context, worker string, urls []*models.RunURL) error
+	CancelRunURLs(ctx context.Context, runID uuid.UUID, reason string) (int64, error)
+	GetRunURLProgress(ctx context.Context, runID uuid.UUID, maxAttempts int) (*models.RunURLProgress, error)
+	ListRunURLs(ctx context.Context, runID uuid.UUID) ([]*models.RunURL, error)
+
+	// Leader election operations
+	TryLeadership(ctx context.Context, name, holder string) (*models.Leader, LeaderLock, error)
+	GetLeader(ctx context.Context, name string) (*models.Leader, error)
+
+	// Crawl job queue operations
+	EnqueueCrawlJob(ctx context.Context, job *models.CrawlJob) (*models.CrawlJob, error)
+	ClaimCrawlJob(ctx context.Context, maxRunning int, lease time.Duration) (*models.CrawlJob, error)
+	StartCrawlJob(ctx context.Context, job *models.CrawlJob, maxRunning int, lease time.Duration) (*models.CrawlJob, error)
+	RenewCrawlJobLease(ctx context.Context, id uuid.UUID, lease time.Duration) error
+	FinishCrawlJob(ctx context.Context, job *models.CrawlJob) error
+	CancelCrawlJob(ctx context.Context, id uuid.UUID) error
+	GetCrawlJob(ctx context.Context, id uuid.UUID) (*models.CrawlJob, error)
+	ListCrawlJobs(ctx context.Context, status string, limit, offset int) ([]*models.CrawlJob, error)
 
 	// Crawler Config operations
+	CreateCrawlRun(ctx context.Context, run *models.CrawlRun) error
+	FinishCrawlRun(ctx context.Context, run *models.CrawlRun) error
+	GetCrawlRun(ctx context.Context, id uuid.UUID) (*models.CrawlRun, error)
+	ListCrawlRuns(ctx context.Context, configID *uuid.UUID, limit, offset int) ([]*models.CrawlRun, error)
+	SetRunReport(ctx context.Context, runID uuid.UUID, report *models.DryRunReport) error
+	GetRunReport(ctx context.Context, runID uuid.UUID) (*models.DryRunReport, error)
 	ListCrawlerConfigs(ctx context.Context) ([]*models.CrawlerConfig, error)
 	GetCrawlerConfig(ctx context.Context, id uuid.UUID) (*models.CrawlerConfig, error)
 	CreateCrawlerConfig(ctx context.Context, config *models.CrawlerConfig) error
 	UpdateCrawlerConfig(ctx context.Context, config *models.CrawlerConfig) error
+	UpdateCrawlerConfigRun(ctx context.Context, config *models.CrawlerConfig) error
+	MarkCrawlerConfigQueued(ctx context.Context, id, jobID uuid.UUID) error
+	AdvanceCrawlerConfigNextRun(ctx context.Context, id uuid.UUID, due, next *time.Time) (bool, error)
+	SetCrawlerConfigEnabled(ctx context.Context, id uuid.UUID, enabled bool, status string, nextRun *time.Time) error
 	DeleteCrawlerConfig(ctx context.Context, id uuid.UUID) error
 }
+
+// LeaderLock is a leadership role held by this process. The role is lost when the process or
+// its database connection dies, so another instance can take over.
+type LeaderLock interface {
+	// Renew confirms the role is still held and records when; an error means it may be lost
+	Renew(ctx context.Context) error
+	// Release gives the role up
+	Release(ctx context.Context) error
+}
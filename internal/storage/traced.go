@@ -0,0 +1,644 @@
+package storage
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/romangod6/kb-crawler/internal/models"
+	"github.com/romangod6/kb-crawler/internal/tracing"
+	"go.opentelemetry.io/otel"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("github.com/romangod6/kb-crawler/internal/storage")
+
+// tracedStore records a span for every Store call. It wraps each method explicitly, so a
+// method added to Store fails to compile here until it is traced too.
+type tracedStore struct {
+	store Store
+}
+
+// NewTracedStore wraps a store so each call is traced as a child of the span in its context.
+func NewTracedStore(store Store) Store {
+	return &tracedStore{store: store}
+}
+
+func startStoreSpan(ctx context.Context, method string) (context.Context, trace.Span) {
+	return tracer.Start(ctx, "store."+method, trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.DBSystemPostgreSQL,
+			semconv.DBOperationName(method)))
+}
+
+func (s *tracedStore) Initialize() error { return s.store.Initialize() }
+func (s *tracedStore) Close() error      { return s.store.Close() }
+
+func (s *tracedStore) CreateCategory(ctx context.Context, category *models.Category) error {
+	ctx, span := startStoreSpan(ctx, "CreateCategory")
+	defer span.End()
+	err := s.store.CreateCategory(ctx, category)
+	tracing.RecordError(span, err)
+	return err
+}
+
+func (s *tracedStore) GetCategory(ctx context.Context, id uuid.UUID) (*models.Category, error) {
+	ctx, span := startStoreSpan(ctx, "GetCategory")
+	defer span.End()
+	result, err := s.store.GetCategory(ctx, id)
+	tracing.RecordError(span, err)
+	return result, err
+}
+
+func (s *tracedStore) ListCategories(ctx context.Context) ([]*models.Category, error) {
+	ctx, span := startStoreSpan(ctx, "ListCategories")
+	defer span.End()
+	result, err := s.store.ListCategories(ctx)
+	tracing.RecordError(span, err)
+	return result, err
+}
+
+func (s *tracedStore) CreateArticle(ctx context.Context, article *models.Article) (*models.ArticleChange, error) {
+	ctx, span := startStoreSpan(ctx, "CreateArticle")
+	defer span.End()
+	result, err := s.store.CreateArticle(ctx, article)
+	tracing.RecordError(span, err)
+	return result, err
+}
+
+func (s *tracedStore) GetArticle(ctx context.Context, id uuid.UUID) (*models.Article, error) {
+	ctx, span := startStoreSpan(ctx, "GetArticle")
+	defer span.End()
+	result, err := s.store.GetArticle(ctx, id)
+	tracing.RecordError(span, err)
+	return result, err
+}
+
+func (s *tracedStore) ListArticles(ctx context.Context, limit, offset int, includeDeleted bool) ([]*models.Article, error) {
+	ctx, span := startStoreSpan(ctx, "ListArticles")
+	defer span.End()
+	result, err := s.store.ListArticles(ctx, limit, offset, includeDeleted)
+	tracing.RecordError(span, err)
+	return result, err
+}
+
+func (s *tracedStore) SearchArticles(ctx context.Context, query string, limit, offset int, includeDeleted bool) ([]*models.Article, error) {
+	ctx, span := startStoreSpan(ctx, "SearchArticles")
+	defer span.End()
+	result, err := s.store.SearchArticles(ctx, query, limit, offset, includeDeleted)
+	tracing.RecordError(span, err)
+	return result, err
+}
+
+func (s *tracedStore) GetArticlesByCategory(ctx context.Context, categoryID uuid.UUID, limit, offset int, includeDeleted bool) ([]*models.Article, error) {
+	ctx, span := startStoreSpan(ctx, "GetArticlesByCategory")
+	defer span.End()
+	result, err := s.store.GetArticlesByCategory(ctx, categoryID, limit, offset, includeDeleted)
+	tracing.RecordError(span, err)
+	return result, err
+}
+
+func (s *tracedStore) ListLowConfidenceArticles(ctx context.Context, threshold float64, limit, offset int, includeDeleted bool) ([]*models.Article, error) {
+	ctx, span := startStoreSpan(ctx, "ListLowConfidenceArticles")
+	defer span.End()
+	result, err := s.store.ListLowConfidenceArticles(ctx, threshold, limit, offset, includeDeleted)
+	tracing.RecordError(span, err)
+	return result, err
+}
+
+func (s *tracedStore) ListArticleRefs(ctx context.Context) ([]*models.ArticleRef, error) {
+	ctx, span := startStoreSpan(ctx, "ListArticleRefs")
+	defer span.End()
+	result, err := s.store.ListArticleRefs(ctx)
+	tracing.RecordError(span, err)
+	return result, err
+}
+
+func (s *tracedStore) MergeArticles(ctx context.Context, keepID uuid.UUID, duplicateIDs []uuid.UUID, url string) error {
+	ctx, span := startStoreSpan(ctx, "MergeArticles")
+	defer span.End()
+	err := s.store.MergeArticles(ctx, keepID, duplicateIDs, url)
+	tracing.RecordError(span, err)
+	return err
+}
+
+func (s *tracedStore) ListArticleFingerprints(ctx context.Context) ([]*models.ArticleFingerprint, error) {
+	ctx, span := startStoreSpan(ctx, "ListArticleFingerprints")
+	defer span.End()
+	result, err := s.store.ListArticleFingerprints(ctx)
+	tracing.RecordError(span, err)
+	return result, err
+}
+
+func (s *tracedStore) ListActiveArticleRefs(ctx context.Context, configID uuid.UUID) ([]*models.ArticleRef, error) {
+	ctx, span := startStoreSpan(ctx, "ListActiveArticleRefs")
+	defer span.End()
+	result, err := s.store.ListActiveArticleRefs(ctx, configID)
+	tracing.RecordError(span, err)
+	return result, err
+}
+
+func (s *tracedStore) TombstoneArticles(ctx context.Context, ids []uuid.UUID, reason string) ([]*models.ArticleChange, error) {
+	ctx, span := startStoreSpan(ctx, "TombstoneArticles")
+	defer span.End()
+	result, err := s.store.TombstoneArticles(ctx, ids, reason)
+	tracing.RecordError(span, err)
+	return result, err
+}
+
+func (s *tracedStore) PurgeDeletedArticles(ctx context.Context, before time.Time) (int64, error) {
+	ctx, span := startStoreSpan(ctx, "PurgeDeletedArticles")
+	defer span.End()
+	result, err := s.store.PurgeDeletedArticles(ctx, before)
+	tracing.RecordError(span, err)
+	return result, err
+}
+
+func (s *tracedStore) ListChanges(ctx context.Context, since int64, limit int) ([]*models.ArticleChange, error) {
+	ctx, span := startStoreSpan(ctx, "ListChanges")
+	defer span.End()
+	result, err := s.store.ListChanges(ctx, since, limit)
+	tracing.RecordError(span, err)
+	return result, err
+}
+
+func (s *tracedStore) CreateWebhook(ctx context.Context, webhook *models.Webhook) error {
+	ctx, span := startStoreSpan(ctx, "CreateWebhook")
+	defer span.End()
+	err := s.store.CreateWebhook(ctx, webhook)
+	tracing.RecordError(span, err)
+	return err
+}
+
+func (s *tracedStore) ListWebhooks(ctx context.Context) ([]*models.Webhook, error) {
+	ctx, span := startStoreSpan(ctx, "ListWebhooks")
+	defer span.End()
+	result, err := s.store.ListWebhooks(ctx)
+	tracing.RecordError(span, err)
+	return result, err
+}
+
+func (s *tracedStore) DeleteWebhook(ctx context.Context, id uuid.UUID) error {
+	ctx, span := startStoreSpan(ctx, "DeleteWebhook")
+	defer span.End()
+	err := s.store.DeleteWebhook(ctx, id)
+	tracing.RecordError(span, err)
+	return err
+}
+
+func (s *tracedStore) CreateWebhookDelivery(ctx context.Context, delivery *models.WebhookDelivery) error {
+	ctx, span := startStoreSpan(ctx, "CreateWebhookDelivery")
+	defer span.End()
+	err := s.store.CreateWebhookDelivery(ctx, delivery)
+	tracing.RecordError(span, err)
+	return err
+}
+
+func (s *tracedStore) ClaimDueWebhookDeliveries(ctx context.Context, limit int, lease time.Duration) ([]*models.WebhookDelivery, error) {
+	ctx, span := startStoreSpan(ctx, "ClaimDueWebhookDeliveries")
+	defer span.End()
+	result, err := s.store.ClaimDueWebhookDeliveries(ctx, limit, lease)
+	tracing.RecordError(span, err)
+	return result, err
+}
+
+func (s *tracedStore) UpdateWebhookDelivery(ctx context.Context, delivery *models.WebhookDelivery) error {
+	ctx, span := startStoreSpan(ctx, "UpdateWebhookDelivery")
+	defer span.End()
+	err := s.store.UpdateWebhookDelivery(ctx, delivery)
+	tracing.RecordError(span, err)
+	return err
+}
+
+func (s *tracedStore) ListWebhookDeliveries(ctx context.Context, webhookID uuid.UUID, status string, limit, offset int) ([]*models.WebhookDelivery, error) {
+	ctx, span := startStoreSpan(ctx, "ListWebhookDeliveries")
+	defer span.End()
+	result, err := s.store.ListWebhookDeliveries(ctx, webhookID, status, limit, offset)
+	tracing.RecordError(span, err)
+	return result, err
+}
+
+func (s *tracedStore) RetryWebhookDelivery(ctx context.Context, id uuid.UUID) error {
+	ctx, span := startStoreSpan(ctx, "RetryWebhookDelivery")
+	defer span.End()
+	err := s.store.RetryWebhookDelivery(ctx, id)
+	tracing.RecordError(span, err)
+	return err
+}
+
+func (s *tracedStore) ListArticleVersions(ctx context.Context, articleID uuid.UUID) ([]*models.ArticleVersion, error) {
+	ctx, span := startStoreSpan(ctx, "ListArticleVersions")
+	defer span.End()
+	result, err := s.store.ListArticleVersions(ctx, articleID)
+	tracing.RecordError(span, err)
+	return result, err
+}
+
+func (s *tracedStore) GetArticleVersion(ctx context.Context, articleID uuid.UUID, version int) (*models.ArticleVersion, error) {
+	ctx, span := startStoreSpan(ctx, "GetArticleVersion")
+	defer span.End()
+	result, err := s.store.GetArticleVersion(ctx, articleID, version)
+	tracing.RecordError(span, err)
+	return result, err
+}
+
+func (s *tracedStore) ReplaceArticleSections(ctx context.Context, articleID uuid.UUID, sections []*models.ArticleSection) error {
+	ctx, span := startStoreSpan(ctx, "ReplaceArticleSections")
+	defer span.End()
+	err := s.store.ReplaceArticleSections(ctx, articleID, sections)
+	tracing.RecordError(span, err)
+	return err
+}
+
+func (s *tracedStore) GetArticleSections(ctx context.Context, articleID uuid.UUID) ([]*models.ArticleSection, error) {
+	ctx, span := startStoreSpan(ctx, "GetArticleSections")
+	defer span.End()
+	result, err := s.store.GetArticleSections(ctx, articleID)
+	tracing.RecordError(span, err)
+	return result, err
+}
+
+func (s *tracedStore) UpsertAsset(ctx context.Context, asset *models.Asset) error {
+	ctx, span := startStoreSpan(ctx, "UpsertAsset")
+	defer span.End()
+	err := s.store.UpsertAsset(ctx, asset)
+	tracing.RecordError(span, err)
+	return err
+}
+
+func (s *tracedStore) UpdateAssetBlob(ctx context.Context, asset *models.Asset) error {
+	ctx, span := startStoreSpan(ctx, "UpdateAssetBlob")
+	defer span.End()
+	err := s.store.UpdateAssetBlob(ctx, asset)
+	tracing.RecordError(span, err)
+	return err
+}
+
+func (s *tracedStore) ReplaceArticleAssets(ctx context.Context, articleID uuid.UUID, assets []*models.Asset) error {
+	ctx, span := startStoreSpan(ctx, "ReplaceArticleAssets")
+	defer span.End()
+	err := s.store.ReplaceArticleAssets(ctx, articleID, assets)
+	tracing.RecordError(span, err)
+	return err
+}
+
+func (s *tracedStore) ListArticleAssets(ctx context.Context, articleID uuid.UUID) ([]*models.Asset, error) {
+	ctx, span := startStoreSpan(ctx, "ListArticleAssets")
+	defer span.End()
+	result, err := s.store.ListArticleAssets(ctx, articleID)
+	tracing.RecordError(span, err)
+	return result, err
+}
+
+func (s *tracedStore) GetAsset(ctx context.Context, id uuid.UUID) (*models.Asset, error) {
+	ctx, span := startStoreSpan(ctx, "GetAsset")
+	defer span.End()
+	result, err := s.store.GetAsset(ctx, id)
+	tracing.RecordError(span, err)
+	return result, err
+}
+
+func (s *tracedStore) ReplaceArticleLinks(ctx context.Context, articleID uuid.UUID, links []*models.ArticleLink) error {
+	ctx, span := startStoreSpan(ctx, "ReplaceArticleLinks")
+	defer span.End()
+	err := s.store.ReplaceArticleLinks(ctx, articleID, links)
+	tracing.RecordError(span, err)
+	return err
+}
+
+func (s *tracedStore) ListArticleLinks(ctx context.Context, articleID uuid.UUID) ([]*models.ArticleLink, error) {
+	ctx, span := startStoreSpan(ctx, "ListArticleLinks")
+	defer span.End()
+	result, err := s.store.ListArticleLinks(ctx, articleID)
+	tracing.RecordError(span, err)
+	return result, err
+}
+
+func (s *tracedStore) ListBacklinks(ctx context.Context, articleID uuid.UUID) ([]*models.ArticleLink, error) {
+	ctx, span := startStoreSpan(ctx, "ListBacklinks")
+	defer span.End()
+	result, err := s.store.ListBacklinks(ctx, articleID)
+	tracing.RecordError(span, err)
+	return result, err
+}
+
+func (s *tracedStore) ListBrokenLinks(ctx context.Context, configID uuid.UUID) ([]*models.ArticleLink, error) {
+	ctx, span := startStoreSpan(ctx, "ListBrokenLinks")
+	defer span.End()
+	result, err := s.store.ListBrokenLinks(ctx, configID)
+	tracing.RecordError(span, err)
+	return result, err
+}
+
+func (s *tracedStore) ListInternalLinkTargets(ctx context.Context, configID uuid.UUID) ([]string, error) {
+	ctx, span := startStoreSpan(ctx, "ListInternalLinkTargets")
+	defer span.End()
+	result, err := s.store.ListInternalLinkTargets(ctx, configID)
+	tracing.RecordError(span, err)
+	return result, err
+}
+
+func (s *tracedStore) UpdateLinkStatuses(ctx context.Context, configID uuid.UUID, statuses []models.LinkStatus) error {
+	ctx, span := startStoreSpan(ctx, "UpdateLinkStatuses")
+	defer span.End()
+	err := s.store.UpdateLinkStatuses(ctx, configID, statuses)
+	tracing.RecordError(span, err)
+	return err
+}
+
+func (s *tracedStore) ResolveLinkTargets(ctx context.Context, configID uuid.UUID) error {
+	ctx, span := startStoreSpan(ctx, "ResolveLinkTargets")
+	defer span.End()
+	err := s.store.ResolveLinkTargets(ctx, configID)
+	tracing.RecordError(span, err)
+	return err
+}
+
+func (s *tracedStore) SetRunCategories(ctx context.Context, runID uuid.UUID, categories map[string]uuid.UUID) error {
+	ctx, span := startStoreSpan(ctx, "SetRunCategories")
+	defer span.End()
+	err := s.store.SetRunCategories(ctx, runID, categories)
+	tracing.RecordError(span, err)
+	return err
+}
+
+func (s *tracedStore) GetRunCategories(ctx context.Context, runID uuid.UUID) (map[string]uuid.UUID, error) {
+	ctx, span := startStoreSpan(ctx, "GetRunCategories")
+	defer span.End()
+	result, err := s.store.GetRunCategories(ctx, runID)
+	tracing.RecordError(span, err)
+	return result, err
+}
+
+func (s *tracedStore) SetRunConfig(ctx context.Context, runID uuid.UUID, config *models.CrawlerConfig) error {
+	ctx, span := startStoreSpan(ctx, "SetRunConfig")
+	defer span.End()
+	err := s.store.SetRunConfig(ctx, runID, config)
+	tracing.RecordError(span, err)
+	return err
+}
+
+func (s *tracedStore) GetRunConfig(ctx context.Context, runID uuid.UUID) (*models.CrawlerConfig, error) {
+	ctx, span := startStoreSpan(ctx, "GetRunConfig")
+	defer span.End()
+	result, err := s.store.GetRunConfig(ctx, runID)
+	tracing.RecordError(span, err)
+	return result, err
+}
+
+func (s *tracedStore) AddRunURLs(ctx context.Context, runID uuid.UUID, urls []*models.RunURL) (int, error) {
+	ctx, span := startStoreSpan(ctx, "AddRunURLs")
+	defer span.End()
+	result, err := s.store.AddRunURLs(ctx, runID, urls)
+	tracing.RecordError(span, err)
+	return result, err
+}
+
+func (s *tracedStore) ClaimRunURLs(ctx context.Context, worker string, limit, maxAttempts int, lease time.Duration) ([]*models.RunURL, error) {
+	ctx, span := startStoreSpan(ctx, "ClaimRunURLs")
+	defer span.End()
+	result, err := s.store.ClaimRunURLs(ctx, worker, limit, maxAttempts, lease)
+	tracing.RecordError(span, err)
+	return result, err
+}
+
+func (s *tracedStore) RenewRunURLLeases(ctx context.Context, worker string, lease time.Duration) (int64, error) {
+	ctx, span := startStoreSpan(ctx, "RenewRunURLLeases")
+	defer span.End()
+	result, err := s.store.RenewRunURLLeases(ctx, worker, lease)
+	tracing.RecordError(span, err)
+	return result, err
+}
+
+func (s *tracedStore) CompleteRunURLs(ctx context.Context, worker string, urls []*models.RunURL) error {
+	ctx, span := startStoreSpan(ctx, "CompleteRunURLs")
+	defer span.End()
+	err := s.store.CompleteRunURLs(ctx, worker, urls)
+	tracing.RecordError(span, err)
+	return err
+}
+
+func (s *tracedStore) CancelRunURLs(ctx context.Context, runID uuid.UUID, reason string) (int64, error) {
+	ctx, span := startStoreSpan(ctx, "CancelRunURLs")
+	defer span.End()
+	result, err := s.store.CancelRunURLs(ctx, runID, reason)
+	tracing.RecordError(span, err)
+	return result, err
+}
+
+func (s *tracedStore) GetRunURLProgress(ctx context.Context, runID uuid.UUID, maxAttempts int) (*models.RunURLProgress, error) {
+	ctx, span := startStoreSpan(ctx, "GetRunURLProgress")
+	defer span.End()
+	result, err := s.store.GetRunURLProgress(ctx, runID, maxAttempts)
+	tracing.RecordError(span, err)
+	return result, err
+}
+
+func (s *tracedStore) ListRunURLs(ctx context.Context, runID uuid.UUID) ([]*models.RunURL, error) {
+	ctx, span := startStoreSpan(ctx, "ListRunURLs")
+	defer span.End()
+	result, err := s.store.ListRunURLs(ctx, runID)
+	tracing.RecordError(span, err)
+	return result, err
+}
+
+func (s *tracedStore) TryLeadership(ctx context.Context, name, holder string) (*models.Leader, LeaderLock, error) {
+	ctx, span := startStoreSpan(ctx, "TryLeadership")
+	defer span.End()
+	leader, lock, err := s.store.TryLeadership(ctx, name, holder)
+	tracing.RecordError(span, err)
+	return leader, lock, err
+}
+
+func (s *tracedStore) GetLeader(ctx context.Context, name string) (*models.Leader, error) {
+	ctx, span := startStoreSpan(ctx, "GetLeader")
+	defer span.End()
+	result, err := s.store.GetLeader(ctx, name)
+	tracing.RecordError(span, err)
+	return result, err
+}
+
+func (s *tracedStore) EnqueueCrawlJob(ctx context.Context, job *models.CrawlJob) (*models.CrawlJob, error) {
+	ctx, span := startStoreSpan(ctx, "EnqueueCrawlJob")
+	defer span.End()
+	result, err := s.store.EnqueueCrawlJob(ctx, job)
+	tracing.RecordError(span, err)
+	return result, err
+}
+
+func (s *tracedStore) ClaimCrawlJob(ctx context.Context, maxRunning int, lease time.Duration) (*models.CrawlJob, error) {
+	ctx, span := startStoreSpan(ctx, "ClaimCrawlJob")
+	defer span.End()
+	result, err := s.store.ClaimCrawlJob(ctx, maxRunning, lease)
+	tracing.RecordError(span, err)
+	return result, err
+}
+
+func (s *tracedStore) StartCrawlJob(ctx context.Context, job *models.CrawlJob, maxRunning int, lease time.Duration) (*models.CrawlJob, error) {
+	ctx, span := startStoreSpan(ctx, "StartCrawlJob")
+	defer span.End()
+	result, err := s.store.StartCrawlJob(ctx, job, maxRunning, lease)
+	tracing.RecordError(span, err)
+	return result, err
+}
+
+func (s *tracedStore) RenewCrawlJobLease(ctx context.Context, id uuid.UUID, lease time.Duration) error {
+	ctx, span := startStoreSpan(ctx, "RenewCrawlJobLease")
+	defer span.End()
+	err := s.store.RenewCrawlJobLease(ctx, id, lease)
+	tracing.RecordError(span, err)
+	return err
+}
+
+func (s *tracedStore) FinishCrawlJob(ctx context.Context, job *models.CrawlJob) error {
+	ctx, span := startStoreSpan(ctx, "FinishCrawlJob")
+	defer span.End()
+	err := s.store.FinishCrawlJob(ctx, job)
+	tracing.RecordError(span, err)
+	return err
+}
+
+func (s *tracedStore) CancelCrawlJob(ctx context.Context, id uuid.UUID) error {
+	ctx, span := startStoreSpan(ctx, "CancelCrawlJob")
+	defer span.End()
+	err := s.store.CancelCrawlJob(ctx, id)
+	tracing.RecordError(span, err)
+	return err
+}
+
+func (s *tracedStore) GetCrawlJob(ctx context.Context, id uuid.UUID) (*models.CrawlJob, error) {
+	ctx, span := startStoreSpan(ctx, "GetCrawlJob")
+	defer span.End()
+	result, err := s.store.GetCrawlJob(ctx, id)
+	tracing.RecordError(span, err)
+	return result, err
+}
+
+func (s *tracedStore) ListCrawlJobs(ctx context.Context, status string, limit, offset int) ([]*models.CrawlJob, error) {
+	ctx, span := startStoreSpan(ctx, "ListCrawlJobs")
+	defer span.End()
+	result, err := s.store.ListCrawlJobs(ctx, status, limit, offset)
+	tracing.RecordError(span, err)
+	return result, err
+}
+
+func (s *tracedStore) CreateCrawlRun(ctx context.Context, run *models.CrawlRun) error {
+	ctx, span := startStoreSpan(ctx, "CreateCrawlRun")
+	defer span.End()
+	err := s.store.CreateCrawlRun(ctx, run)
+	tracing.RecordError(span, err)
+	return err
+}
+
+func (s *tracedStore) FinishCrawlRun(ctx context.Context, run *models.CrawlRun) error {
+	ctx, span := startStoreSpan(ctx, "FinishCrawlRun")
+	defer span.End()
+	err := s.store.FinishCrawlRun(ctx, run)
+	tracing.RecordError(span, err)
+	return err
+}
+
+func (s *tracedStore) GetCrawlRun(ctx context.Context, id uuid.UUID) (*models.CrawlRun, error) {
+	ctx, span := startStoreSpan(ctx, "GetCrawlRun")
+	defer span.End()
+	result, err := s.store.GetCrawlRun(ctx, id)
+	tracing.RecordError(span, err)
+	return result, err
+}
+
+func (s *tracedStore) ListCrawlRuns(ctx context.Context, configID *uuid.UUID, limit, offset int) ([]*models.CrawlRun, error) {
+	ctx, span := startStoreSpan(ctx, "ListCrawlRuns")
+	defer span.End()
+	result, err := s.store.ListCrawlRuns(ctx, configID, limit, offset)
+	tracing.RecordError(span, err)
+	return result, err
+}
+
+func (s *tracedStore) SetRunReport(ctx context.Context, runID uuid.UUID, report *models.DryRunReport) error {
+	ctx, span := startStoreSpan(ctx, "SetRunReport")
+	defer span.End()
+	err := s.store.SetRunReport(ctx, runID, report)
+	tracing.RecordError(span, err)
+	return err
+}
+
+func (s *tracedStore) GetRunReport(ctx context.Context, runID uuid.UUID) (*models.DryRunReport, error) {
+	ctx, span := startStoreSpan(ctx, "GetRunReport")
+	defer span.End()
+	result, err := s.store.GetRunReport(ctx, runID)
+	tracing.RecordError(span, err)
+	return result, err
+}
+
+func (s *tracedStore) ListCrawlerConfigs(ctx context.Context) ([]*models.CrawlerConfig, error) {
+	ctx, span := startStoreSpan(ctx, "ListCrawlerConfigs")
+	defer span.End()
+	result, err := s.store.ListCrawlerConfigs(ctx)
+	tracing.RecordError(span, err)
+	return result, err
+}
+
+func (s *tracedStore) GetCrawlerConfig(ctx context.Context, id uuid.UUID) (*models.CrawlerConfig, error) {
+	ctx, span := startStoreSpan(ctx, "GetCrawlerConfig")
+	defer span.End()
+	result, err := s.store.GetCrawlerConfig(ctx, id)
+	tracing.RecordError(span, err)
+	return result, err
+}
+
+func (s *tracedStore) CreateCrawlerConfig(ctx context.Context, config *models.CrawlerConfig) error {
+	ctx, span := startStoreSpan(ctx, "CreateCrawlerConfig")
+	defer span.End()
+	err := s.store.CreateCrawlerConfig(ctx, config)
+	tracing.RecordError(span, err)
+	return err
+}
+
+func (s *tracedStore) UpdateCrawlerConfig(ctx context.Context, config *models.CrawlerConfig) error {
+	ctx, span := startStoreSpan(ctx, "UpdateCrawlerConfig")
+	defer span.End()
+	err := s.store.UpdateCrawlerConfig(ctx, config)
+	tracing.RecordError(span, err)
+	return err
+}
+
+func (s *tracedStore) UpdateCrawlerConfigRun(ctx context.Context, config *models.CrawlerConfig) error {
+	ctx, span := startStoreSpan(ctx, "UpdateCrawlerConfigRun")
+	defer span.End()
+	err := s.store.UpdateCrawlerConfigRun(ctx, config)
+	tracing.RecordError(span, err)
+	return err
+}
+
+func (s *tracedStore) MarkCrawlerConfigQueued(ctx context.Context, id, jobID uuid.UUID) error {
+	ctx, span := startStoreSpan(ctx, "MarkCrawlerConfigQueued")
+	defer span.End()
+	err := s.store.MarkCrawlerConfigQueued(ctx, id, jobID)
+	tracing.RecordError(span, err)
+	return err
+}
+
+func (s *tracedStore) AdvanceCrawlerConfigNextRun(ctx context.Context, id uuid.UUID, due, next *time.Time) (bool, error) {
+	ctx, span := startStoreSpan(ctx, "AdvanceCrawlerConfigNextRun")
+	defer span.End()
+	result, err := s.store.AdvanceCrawlerConfigNextRun(ctx, id, due, next)
+	tracing.RecordError(span, err)
+	return result, err
+}
+
+func (s *tracedStore) SetCrawlerConfigEnabled(ctx context.Context, id uuid.UUID, enabled bool, status string, nextRun *time.Time) error {
+	ctx, span := startStoreSpan(ctx, "SetCrawlerConfigEnabled")
+	defer span.End()
+	err := s.store.SetCrawlerConfigEnabled(ctx, id, enabled, status, nextRun)
+	tracing.RecordError(span, err)
+	return err
+}
+
+func (s *tracedStore) DeleteCrawlerConfig(ctx context.Context, id uuid.UUID) error {
+	ctx, span := startStoreSpan(ctx, "DeleteCrawlerConfig")
+	defer span.End()
+	err := s.store.DeleteCrawlerConfig(ctx, id)
+	tracing.RecordError(span, err)
+	return err
+}
@@ -0,0 +1,61 @@
+package textdiff
+
+import (
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// blockElements start a new line when an article body is flattened to text.
+var blockElements = map[string]bool{
+	"p": true, "div": true, "br": true, "li": true, "tr": true, "pre": true, "blockquote": true,
+	"h1": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true,
+	"table": true, "ul": true, "ol": true, "dl": true, "dt": true, "dd": true, "section": true,
+}
+
+// PlainText flattens an HTML article body to text with one line per block, so diffs follow
+// the article's paragraphs and list items rather than its markup.
+func PlainText(body string) string {
+	doc, err := html.Parse(strings.NewReader(body))
+	if err != nil {
+		return body
+	}
+
+	var lines []string
+	var line strings.Builder
+	flush := func() {
+		if text := strings.Join(strings.Fields(line.String()), " "); text != "" {
+			lines = append(lines, text)
+		}
+		line.Reset()
+	}
+
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		switch n.Type {
+		case html.TextNode:
+			line.WriteString(n.Data)
+			line.WriteString(" ")
+			return
+		case html.ElementNode:
+			if n.Data == "script" || n.Data == "style" {
+				return
+			}
+		}
+
+		block := n.Type == html.ElementNode && blockElements[n.Data]
+		if block {
+			flush()
+		}
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			walk(child)
+		}
+		if block {
+			flush()
+		}
+	}
+	walk(doc)
+	flush()
+
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,307 @@
+// Package textdiff compares article revisions line by line or word by word.
+package textdiff
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Op is the kind of an edit.
+type Op string
+
+const (
+	Equal  Op = "equal"
+	Insert Op = "insert"
+	Delete Op = "delete"
+)
+
+// Edit is one token, or a run of tokens for word diffs, that is kept, inserted or deleted.
+type Edit struct {
+	Op   Op     `json:"op"`
+	Text string `json:"text"`
+}
+
+// maxEditDistance bounds the work done on very different inputs, which is O((N+M)·D) for
+// inputs of N and M tokens that are D edits apart. Beyond it the diff falls back to deleting
+// the rest of the old text and inserting the rest of the new.
+const maxEditDistance = 1000
+
+// Diff returns a shortest edit script turning a into b, using Myers' algorithm in linear space.
+func Diff(a, b []string) []Edit {
+	// Common prefix and suffix don't need the full algorithm
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	var edits []Edit
+	for _, token := range a[:prefix] {
+		edits = append(edits, Edit{Op: Equal, Text: token})
+	}
+	edits = append(edits, myers(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix])...)
+	for _, token := range a[len(a)-suffix:] {
+		edits = append(edits, Edit{Op: Equal, Text: token})
+	}
+	return edits
+}
+
+// myers computes the edit script for inputs without a common prefix or suffix, or replaces a
+// with b when they are more than maxEditDistance edits apart.
+func myers(a, b []string) []Edit {
+	edits, ok := appendDiff(nil, a, b, maxEditDistance)
+	if !ok {
+		return replace(a, b)
+	}
+	groupChanges(edits)
+	return edits
+}
+
+// groupChanges moves the deletions of each run of changes before its insertions, which the
+// recursion may have interleaved, so runs read as the old text followed by the new.
+func groupChanges(edits []Edit) {
+	for start := 0; start < len(edits); {
+		if edits[start].Op == Equal {
+			start++
+			continue
+		}
+		end := start
+		for end < len(edits) && edits[end].Op != Equal {
+			end++
+		}
+		run := edits[start:end]
+		sort.SliceStable(run, func(i, j int) bool {
+			return run[i].Op == Delete && run[j].Op == Insert
+		})
+		start = end
+	}
+}
+
+// appendDiff appends the edit script turning a into b to edits. It finds the middle snake of a
+// shortest edit path and diffs the parts before and after it, so only the two frontiers are
+// kept in memory. It reports false, having appended nothing, when a and b are more than limit
+// edits apart; a negative limit means no limit.
+func appendDiff(edits []Edit, a, b []string, limit int) ([]Edit, bool) {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+	innerA, innerB := a[prefix:len(a)-suffix], b[prefix:len(b)-suffix]
+
+	var x, y, u, v int
+	if len(innerA) > 0 && len(innerB) > 0 {
+		var ok bool
+		if x, y, u, v, ok = middleSnake(innerA, innerB, limit); !ok {
+			return edits, false
+		}
+	}
+
+	for _, token := range a[:prefix] {
+		edits = append(edits, Edit{Op: Equal, Text: token})
+	}
+	if len(innerA) == 0 || len(innerB) == 0 {
+		edits = append(edits, replace(innerA, innerB)...)
+	} else {
+		// The halves are at most half as far apart as the whole, so they need no limit
+		edits, _ = appendDiff(edits, innerA[:x], innerB[:y], -1)
+		for _, token := range innerA[x:u] {
+			edits = append(edits, Edit{Op: Equal, Text: token})
+		}
+		edits, _ = appendDiff(edits, innerA[u:], innerB[v:], -1)
+	}
+	for _, token := range a[len(a)-suffix:] {
+		edits = append(edits, Edit{Op: Equal, Text: token})
+	}
+	return edits, true
+}
+
+// middleSnake finds the snake, a run of equal tokens from (x, y) to (u, v), in the middle of a
+// shortest edit path from a to b, by running the forward and reverse searches until they
+// overlap. a and b must be non-empty and differ in their first and last tokens, so the path
+// has edits on both sides of the snake. It reports false when the inputs are more than limit
+// edits apart.
+func middleSnake(a, b []string, limit int) (x, y, u, v int, ok bool) {
+	n, m := len(a), len(b)
+	delta := n - m
+	odd := delta%2 != 0
+	maxD := (n + m + 1) / 2
+	offset := maxD + 1
+	// forward[k] is the furthest x reached on diagonal k = x - y from the start. reverse[c] is
+	// the furthest x' reached on diagonal c = x' - y' from the end, counting back, so c is
+	// delta - k.
+	forward := make([]int, 2*offset+1)
+	reverse := make([]int, 2*offset+1)
+
+	for d := 0; d <= maxD; d++ {
+		if limit >= 0 && 2*d-1 > limit {
+			return 0, 0, 0, 0, false
+		}
+
+		for k := -d; k <= d; k += 2 {
+			var x0 int
+			if k == -d || (k != d && forward[offset+k-1] < forward[offset+k+1]) {
+				x0 = forward[offset+k+1]
+			} else {
+				x0 = forward[offset+k-1] + 1
+			}
+			x, y := x0, x0-k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			forward[offset+k] = x
+
+			if c := delta - k; odd && c >= -(d-1) && c <= d-1 && x+reverse[offset+c] >= n {
+				return x0, x0 - k, x, y, true
+			}
+		}
+
+		for c := -d; c <= d; c += 2 {
+			var x0 int
+			if c == -d || (c != d && reverse[offset+c-1] < reverse[offset+c+1]) {
+				x0 = reverse[offset+c+1]
+			} else {
+				x0 = reverse[offset+c-1] + 1
+			}
+			x, y := x0, x0-c
+			for x < n && y < m && a[n-1-x] == b[m-1-y] {
+				x++
+				y++
+			}
+			reverse[offset+c] = x
+
+			if k := delta - c; !odd && k >= -d && k <= d && x+forward[offset+k] >= n {
+				return n - x, m - y, n - x0, m - (x0 - c), true
+			}
+		}
+	}
+	return 0, 0, 0, 0, false
+}
+
+func replace(a, b []string) []Edit {
+	edits := make([]Edit, 0, len(a)+len(b))
+	for _, token := range a {
+		edits = append(edits, Edit{Op: Delete, Text: token})
+	}
+	for _, token := range b {
+		edits = append(edits, Edit{Op: Insert, Text: token})
+	}
+	return edits
+}
+
+// Words diffs two texts word by word. Consecutive words with the same operation are joined
+// into one edit.
+func Words(a, b string) []Edit {
+	var merged []Edit
+	for _, edit := range Diff(strings.Fields(a), strings.Fields(b)) {
+		if last := len(merged) - 1; last >= 0 && merged[last].Op == edit.Op {
+			merged[last].Text += " " + edit.Text
+			continue
+		}
+		merged = append(merged, edit)
+	}
+	return merged
+}
+
+// Unified diffs two texts line by line and formats the result as a unified diff with the given
+// number of context lines. It returns an empty string when the texts are the same.
+func Unified(fromLabel, toLabel, a, b string, context int) string {
+	edits := Diff(splitLines(a), splitLines(b))
+
+	var out strings.Builder
+	for start := 0; start < len(edits); {
+		// Find the next change
+		for start < len(edits) && edits[start].Op == Equal {
+			start++
+		}
+		if start == len(edits) {
+			break
+		}
+
+		// Extend the hunk until a run of unchanged lines is long enough to split on
+		end := start
+		for end < len(edits) {
+			if edits[end].Op != Equal {
+				end++
+				continue
+			}
+			run := end
+			for run < len(edits) && edits[run].Op == Equal {
+				run++
+			}
+			if run == len(edits) || run-end > 2*context {
+				break
+			}
+			end = run
+		}
+
+		hunkStart := max(0, start-context)
+		hunkEnd := min(len(edits), end+context)
+
+		if out.Len() == 0 {
+			fmt.Fprintf(&out, "--- %s\n+++ %s\n", fromLabel, toLabel)
+		}
+		writeHunk(&out, edits, hunkStart, hunkEnd)
+		start = hunkEnd
+	}
+	return out.String()
+}
+
+func writeHunk(out *strings.Builder, edits []Edit, start, end int) {
+	// Line numbers of the hunk's first line in each text
+	fromLine, toLine := 1, 1
+	for _, edit := range edits[:start] {
+		if edit.Op != Insert {
+			fromLine++
+		}
+		if edit.Op != Delete {
+			toLine++
+		}
+	}
+
+	var fromCount, toCount int
+	var body strings.Builder
+	for _, edit := range edits[start:end] {
+		switch edit.Op {
+		case Equal:
+			fromCount++
+			toCount++
+			body.WriteString(" " + edit.Text + "\n")
+		case Delete:
+			fromCount++
+			body.WriteString("-" + edit.Text + "\n")
+		case Insert:
+			toCount++
+			body.WriteString("+" + edit.Text + "\n")
+		}
+	}
+
+	fmt.Fprintf(out, "@@ -%s +%s @@\n", hunkRange(fromLine, fromCount), hunkRange(toLine, toCount))
+	out.WriteString(body.String())
+}
+
+// hunkRange formats a hunk range; an empty range points at the line before it.
+func hunkRange(line, count int) string {
+	if count == 0 {
+		return fmt.Sprintf("%d,0", line-1)
+	}
+	if count == 1 {
+		return fmt.Sprintf("%d", line)
+	}
+	return fmt.Sprintf("%d,%d", line, count)
+}
+
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+}
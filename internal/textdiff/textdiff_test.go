@@ -0,0 +1,154 @@
+package textdiff
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+		want string
+	}{
+		{name: "both empty", a: "", b: "", want: ""},
+		{name: "same", a: "a b c", b: "a b c", want: "=a =b =c"},
+		{name: "insert into empty", a: "", b: "a b", want: "+a +b"},
+		{name: "delete all", a: "a b", b: "", want: "-a -b"},
+		{name: "insert in middle", a: "a c", b: "a b c", want: "=a +b =c"},
+		{name: "delete in middle", a: "a b c", b: "a c", want: "=a -b =c"},
+		{name: "replace in middle", a: "a b c", b: "a x c", want: "=a -b +x =c"},
+		{name: "nothing in common", a: "a b", b: "x y", want: "-a -b +x +y"},
+		{name: "move", a: "a b c d", b: "b c d a", want: "-a =b =c =d +a"},
+		{name: "grouped changes", a: "a b c", b: "a x y c", want: "=a -b +x +y =c"},
+		{name: "interleaved", a: "x a y b z c", b: "a b c", want: "-x =a -y =b -z =c"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			edits := Diff(strings.Fields(tt.a), strings.Fields(tt.b))
+			if got := format(edits); got != tt.want {
+				t.Errorf("Diff(%q, %q) = %q, want %q", tt.a, tt.b, got, tt.want)
+			}
+			checkEdits(t, edits, strings.Fields(tt.a), strings.Fields(tt.b))
+		})
+	}
+}
+
+func TestDiffFallsBackBeyondMaxEditDistance(t *testing.T) {
+	tests := []struct {
+		name     string
+		changed  int
+		fallback bool
+	}{
+		{name: "within the cap", changed: maxEditDistance / 2, fallback: false},
+		{name: "beyond the cap", changed: maxEditDistance, fallback: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Every changed token is one deletion and one insertion
+			a, b := []string{"head"}, []string{"head"}
+			for i := 0; i < tt.changed; i++ {
+				a = append(a, fmt.Sprintf("a%d", i), "same")
+				b = append(b, fmt.Sprintf("b%d", i), "same")
+			}
+			a, b = append(a, "tail"), append(b, "tail")
+
+			edits := Diff(a, b)
+			checkEdits(t, edits, a, b)
+
+			var equal int
+			for _, edit := range edits {
+				if edit.Op == Equal {
+					equal++
+				}
+			}
+			// The fallback keeps only the common prefix and suffix
+			if got := equal < tt.changed+2; got != tt.fallback {
+				t.Errorf("got %d unchanged tokens of %d, fallback = %v, want %v", equal, tt.changed+2, got, tt.fallback)
+			}
+		})
+	}
+}
+
+func TestWords(t *testing.T) {
+	got := Words("the quick brown fox", "the slow brown dog jumps")
+	want := []Edit{
+		{Op: Equal, Text: "the"},
+		{Op: Delete, Text: "quick"},
+		{Op: Insert, Text: "slow"},
+		{Op: Equal, Text: "brown"},
+		{Op: Delete, Text: "fox"},
+		{Op: Insert, Text: "dog jumps"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Words() = %v, want %v", got, want)
+	}
+}
+
+func TestUnified(t *testing.T) {
+	tests := []struct {
+		name    string
+		a, b    string
+		context int
+		want    string
+	}{
+		{name: "same", a: "a\nb\n", b: "a\nb\n", context: 3, want: ""},
+		{
+			name:    "one change",
+			a:       "a\nb\nc\n",
+			b:       "a\nx\nc\n",
+			context: 1,
+			want:    "--- old\n+++ new\n@@ -1,3 +1,3 @@\n a\n-b\n+x\n c\n",
+		},
+		{
+			name:    "split hunks",
+			a:       "1\n2\n3\n4\n5\n6\n7\n",
+			b:       "x\n2\n3\n4\n5\n6\ny\n",
+			context: 1,
+			want:    "--- old\n+++ new\n@@ -1,2 +1,2 @@\n-1\n+x\n 2\n@@ -6,2 +6,2 @@\n 6\n-7\n+y\n",
+		},
+		{
+			name:    "insert into empty",
+			a:       "",
+			b:       "a\n",
+			context: 3,
+			want:    "--- old\n+++ new\n@@ -0,0 +1 @@\n+a\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Unified("old", "new", tt.a, tt.b, tt.context); got != tt.want {
+				t.Errorf("Unified() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// format writes edits as space-separated tokens prefixed with =, - or +.
+func format(edits []Edit) string {
+	prefixes := map[Op]string{Equal: "=", Delete: "-", Insert: "+"}
+	tokens := make([]string, len(edits))
+	for i, edit := range edits {
+		tokens[i] = prefixes[edit.Op] + edit.Text
+	}
+	return strings.Join(tokens, " ")
+}
+
+// checkEdits fails the test unless edits turn a into b.
+func checkEdits(t *testing.T, edits []Edit, a, b []string) {
+	t.Helper()
+	var from, to []string
+	for _, edit := range edits {
+		if edit.Op != Insert {
+			from = append(from, edit.Text)
+		}
+		if edit.Op != Delete {
+			to = append(to, edit.Text)
+		}
+	}
+	if strings.Join(from, " ") != strings.Join(a, " ") || strings.Join(to, " ") != strings.Join(b, " ") {
+		t.Errorf("edits %v don't turn %v into %v", edits, a, b)
+	}
+}
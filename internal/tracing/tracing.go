@@ -0,0 +1,130 @@
+// Package tracing sets up OpenTelemetry tracing for crawl runs, page fetches, parsing, storage
+// calls and API requests, and exports the spans over OTLP/HTTP or as JSON to stdout or a file.
+// Spans are created through the global tracer provider, and trace context is propagated with
+// W3C traceparent and tracestate headers, so traces join up with other OpenTelemetry services.
+package tracing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Exporter names for Options.Exporter.
+const (
+	ExporterNone   = "none"
+	ExporterStdout = "stdout"
+	ExporterFile   = "file"
+	ExporterOTLP   = "otlp"
+)
+
+// Options selects and configures the exporter.
+type Options struct {
+	// Exporter is "none", "stdout", "file" or "otlp"; empty means none
+	Exporter string
+	// Endpoint is the OTLP/HTTP collector base URL; spans are posted to <Endpoint>/v1/traces
+	Endpoint string
+	// Headers are added to OTLP requests, for collector authentication
+	Headers map[string]string
+	// File receives spans as JSON for the file exporter
+	File string
+	// ServiceName is reported as the service.name resource attribute
+	ServiceName string
+	// SampleRatio is the share of new traces that are recorded, from 0 to 1. Spans with a
+	// parent, such as requests carrying a traceparent header, follow the parent's decision.
+	SampleRatio float64
+}
+
+// Setup installs the global tracer provider and propagator as configured and returns a
+// function that flushes the pending spans and stops. With no exporter, spans are not recorded,
+// but incoming trace context is still passed on.
+func Setup(opts Options) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{}, propagation.Baggage{}))
+
+	var (
+		exporter sdktrace.SpanExporter
+		file     *os.File
+		err      error
+	)
+	switch strings.ToLower(opts.Exporter) {
+	case "", ExporterNone:
+		return func(context.Context) error { return nil }, nil
+	case ExporterStdout:
+		exporter, err = stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+	case ExporterFile:
+		file, err = os.OpenFile(opts.File, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open trace file: %w", err)
+		}
+		exporter, err = stdouttrace.New(stdouttrace.WithWriter(file))
+	case ExporterOTLP:
+		exporter, err = newOTLPExporter(opts)
+	default:
+		return nil, fmt.Errorf("unknown trace exporter %q", opts.Exporter)
+	}
+	if err != nil {
+		if file != nil {
+			file.Close()
+		}
+		return nil, fmt.Errorf("failed to create trace exporter: %w", err)
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(semconv.SchemaURL,
+		semconv.ServiceName(opts.ServiceName)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe trace resource: %w", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(opts.SampleRatio))))
+	otel.SetTracerProvider(provider)
+
+	return func(ctx context.Context) error {
+		err := provider.Shutdown(ctx)
+		if file != nil {
+			err = errors.Join(err, file.Close())
+		}
+		return err
+	}, nil
+}
+
+// newOTLPExporter creates an exporter posting spans to the collector at opts.Endpoint.
+func newOTLPExporter(opts Options) (sdktrace.SpanExporter, error) {
+	endpoint, err := url.Parse(opts.Endpoint)
+	if err != nil || endpoint.Host == "" {
+		return nil, fmt.Errorf("invalid OTLP endpoint %q", opts.Endpoint)
+	}
+	endpoint.Path = path.Join("/", endpoint.Path, "v1/traces")
+
+	options := []otlptracehttp.Option{otlptracehttp.WithEndpointURL(endpoint.String())}
+	if len(opts.Headers) > 0 {
+		options = append(options, otlptracehttp.WithHeaders(opts.Headers))
+	}
+	return otlptracehttp.New(context.Background(), options...)
+}
+
+// RecordError records err on the span and marks the span as failed. A nil error is ignored.
+func RecordError(span trace.Span, err error) {
+	if err == nil {
+		return
+	}
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
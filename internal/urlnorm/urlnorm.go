@@ -0,0 +1,157 @@
+// internal/urlnorm/urlnorm.go
+package urlnorm
+
+import (
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// Rules controls how URLs are normalized before they are queued or used as storage keys.
+type Rules struct {
+	DropFragments bool
+	LowercaseHost bool
+	// StripParams lists query parameters to remove. A trailing "*" matches by prefix, e.g. "utm_*".
+	StripParams []string
+	// SortParams orders the remaining query parameters so equivalent URLs compare equal.
+	SortParams bool
+	// UseCanonical makes ResolveCanonical prefer a page's <link rel=canonical> over its fetched URL.
+	UseCanonical bool
+}
+
+// DefaultRules drops fragments, tracking parameters and MadCap's tocpath navigation parameter.
+var DefaultRules = Rules{
+	DropFragments: true,
+	LowercaseHost: true,
+	StripParams:   []string{"tocpath", "utm_*", "gclid", "fbclid", "mc_cid", "mc_eid", "_ga", "_gl"},
+	SortParams:    true,
+	UseCanonical:  true,
+}
+
+var (
+	mu      sync.RWMutex
+	current = DefaultRules
+)
+
+// Configure replaces the rules used by the package-level Normalize and ResolveCanonical.
+func Configure(rules Rules) {
+	mu.Lock()
+	defer mu.Unlock()
+	current = rules
+}
+
+// Current returns the rules used by the package-level functions.
+func Current() Rules {
+	mu.RLock()
+	defer mu.RUnlock()
+	return current
+}
+
+// Normalize applies the configured rules to raw.
+func Normalize(raw string) string {
+	return Current().Normalize(raw)
+}
+
+// ResolveCanonical returns the normalized storage key for a page using the configured rules.
+func ResolveCanonical(pageURL, canonicalURL string) string {
+	return Current().ResolveCanonical(pageURL, canonicalURL)
+}
+
+// Normalize returns raw with the rules applied. Values that don't parse as absolute URLs are
+// returned trimmed but otherwise unchanged.
+func (r Rules) Normalize(raw string) string {
+	raw = strings.TrimSpace(raw)
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return raw
+	}
+
+	u.Scheme = strings.ToLower(u.Scheme)
+	if r.LowercaseHost {
+		u.Host = strings.ToLower(u.Host)
+	}
+
+	// Drop the port when it is the default for the scheme
+	if port := u.Port(); (u.Scheme == "http" && port == "80") || (u.Scheme == "https" && port == "443") {
+		u.Host = strings.TrimSuffix(u.Host, ":"+port)
+	}
+
+	if u.Path == "" {
+		u.Path = "/"
+	}
+
+	if r.DropFragments {
+		u.Fragment = ""
+		u.RawFragment = ""
+	}
+
+	if u.RawQuery != "" {
+		query := u.Query()
+		for name := range query {
+			if r.stripped(name) {
+				query.Del(name)
+			}
+		}
+		if r.SortParams {
+			// Encode sorts by key
+			u.RawQuery = query.Encode()
+		} else {
+			u.RawQuery = r.filterRawQuery(u.RawQuery)
+		}
+	}
+	u.ForceQuery = false
+
+	return u.String()
+}
+
+// ResolveCanonical picks the storage key for a page. When canonical resolution is enabled and
+// the page declares a canonical URL on the same host, that URL wins over the fetched one.
+func (r Rules) ResolveCanonical(pageURL, canonicalURL string) string {
+	page := r.Normalize(pageURL)
+	if !r.UseCanonical || canonicalURL == "" {
+		return page
+	}
+
+	canonical := r.Normalize(canonicalURL)
+	pageParsed, err1 := url.Parse(page)
+	canonicalParsed, err2 := url.Parse(canonical)
+	if err1 != nil || err2 != nil || !strings.EqualFold(pageParsed.Host, canonicalParsed.Host) {
+		return page
+	}
+
+	return canonical
+}
+
+// stripped reports whether the query parameter name matches one of the strip rules.
+func (r Rules) stripped(name string) bool {
+	name = strings.ToLower(name)
+	for _, param := range r.StripParams {
+		param = strings.ToLower(param)
+		if prefix, ok := strings.CutSuffix(param, "*"); ok {
+			if strings.HasPrefix(name, prefix) {
+				return true
+			}
+		} else if name == param {
+			return true
+		}
+	}
+	return false
+}
+
+// filterRawQuery removes stripped parameters while keeping the original order and encoding.
+func (r Rules) filterRawQuery(rawQuery string) string {
+	var kept []string
+	for _, pair := range strings.Split(rawQuery, "&") {
+		if pair == "" {
+			continue
+		}
+		name, _, _ := strings.Cut(pair, "=")
+		if unescaped, err := url.QueryUnescape(name); err == nil {
+			name = unescaped
+		}
+		if !r.stripped(name) {
+			kept = append(kept, pair)
+		}
+	}
+	return strings.Join(kept, "&")
+}
@@ -0,0 +1,128 @@
+package urlnorm
+
+import "testing"
+
+func TestNormalize(t *testing.T) {
+	unsorted := DefaultRules
+	unsorted.SortParams = false
+	keepCase := DefaultRules
+	keepCase.LowercaseHost = false
+	keepFragment := DefaultRules
+	keepFragment.DropFragments = false
+
+	tests := []struct {
+		name  string
+		rules Rules
+		raw   string
+		want  string
+	}{
+		{name: "unchanged", rules: DefaultRules, raw: "https://kb.example.com/a/b?x=1", want: "https://kb.example.com/a/b?x=1"},
+		{name: "scheme and host case", rules: DefaultRules, raw: "HTTPS://KB.Example.com/Docs/Page.htm", want: "https://kb.example.com/Docs/Page.htm"},
+		{name: "host case kept", rules: keepCase, raw: "https://KB.Example.com/a", want: "https://KB.Example.com/a"},
+		{name: "default http port", rules: DefaultRules, raw: "http://kb.example.com:80/a", want: "http://kb.example.com/a"},
+		{name: "default https port", rules: DefaultRules, raw: "https://kb.example.com:443/a", want: "https://kb.example.com/a"},
+		{name: "other port", rules: DefaultRules, raw: "https://kb.example.com:8443/a", want: "https://kb.example.com:8443/a"},
+		{name: "empty path", rules: DefaultRules, raw: "https://kb.example.com", want: "https://kb.example.com/"},
+		{name: "surrounding space", rules: DefaultRules, raw: "  https://kb.example.com/a\n", want: "https://kb.example.com/a"},
+		{name: "fragment dropped", rules: DefaultRules, raw: "https://kb.example.com/a#install", want: "https://kb.example.com/a"},
+		{name: "fragment kept", rules: keepFragment, raw: "https://kb.example.com/a#install", want: "https://kb.example.com/a#install"},
+		{name: "params sorted", rules: DefaultRules, raw: "https://kb.example.com/a?b=2&a=1&c=3", want: "https://kb.example.com/a?a=1&b=2&c=3"},
+		{
+			name:  "tracking params stripped",
+			rules: DefaultRules,
+			raw:   "https://kb.example.com/a?utm_source=mail&id=7&UTM_Medium=x&gclid=abc&tocpath=Admin%7CUsers",
+			want:  "https://kb.example.com/a?id=7",
+		},
+		{name: "only stripped params", rules: DefaultRules, raw: "https://kb.example.com/a?tocpath=Admin", want: "https://kb.example.com/a"},
+		{name: "empty query", rules: DefaultRules, raw: "https://kb.example.com/a?", want: "https://kb.example.com/a"},
+		{name: "prefix rule needs the prefix", rules: DefaultRules, raw: "https://kb.example.com/a?utm=1", want: "https://kb.example.com/a?utm=1"},
+		{
+			name:  "unsorted keeps order and encoding",
+			rules: unsorted,
+			raw:   "https://kb.example.com/a?q=two%20words&utm_campaign=x&b=2&a=1",
+			want:  "https://kb.example.com/a?q=two%20words&b=2&a=1",
+		},
+		{name: "unsorted with escaped stripped name", rules: unsorted, raw: "https://kb.example.com/a?%75tm_source=x&a=1", want: "https://kb.example.com/a?a=1"},
+		{name: "relative URL", rules: DefaultRules, raw: "/a/b#c", want: "/a/b#c"},
+		{name: "not a URL", rules: DefaultRules, raw: "%zz", want: "%zz"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.rules.Normalize(tt.raw); got != tt.want {
+				t.Errorf("Normalize(%q) = %q, want %q", tt.raw, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolveCanonical(t *testing.T) {
+	noCanonical := DefaultRules
+	noCanonical.UseCanonical = false
+
+	tests := []struct {
+		name      string
+		rules     Rules
+		page      string
+		canonical string
+		want      string
+	}{
+		{
+			name:      "canonical on the same host",
+			rules:     DefaultRules,
+			page:      "https://kb.example.com/a?tocpath=Admin",
+			canonical: "https://KB.example.com/Admin/a#top",
+			want:      "https://kb.example.com/Admin/a",
+		},
+		{
+			name:  "no canonical",
+			rules: DefaultRules,
+			page:  "https://kb.example.com/a?utm_source=x",
+			want:  "https://kb.example.com/a",
+		},
+		{
+			name:      "canonical on another host",
+			rules:     DefaultRules,
+			page:      "https://kb.example.com/a",
+			canonical: "https://www.example.com/a",
+			want:      "https://kb.example.com/a",
+		},
+		{
+			name:      "relative canonical",
+			rules:     DefaultRules,
+			page:      "https://kb.example.com/a",
+			canonical: "/b",
+			want:      "https://kb.example.com/a",
+		},
+		{
+			name:      "canonical resolution off",
+			rules:     noCanonical,
+			page:      "https://kb.example.com/a",
+			canonical: "https://kb.example.com/b",
+			want:      "https://kb.example.com/a",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.rules.ResolveCanonical(tt.page, tt.canonical); got != tt.want {
+				t.Errorf("ResolveCanonical(%q, %q) = %q, want %q", tt.page, tt.canonical, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConfigure(t *testing.T) {
+	t.Cleanup(func() { Configure(DefaultRules) })
+
+	raw := "https://kb.example.com/a?b=2&a=1&ref=mail"
+	if got, want := Normalize(raw), "https://kb.example.com/a?a=1&b=2&ref=mail"; got != want {
+		t.Errorf("Normalize(%q) with the default rules = %q, want %q", raw, got, want)
+	}
+
+	Configure(Rules{StripParams: []string{"ref"}})
+	if got, want := Normalize(raw), "https://kb.example.com/a?b=2&a=1"; got != want {
+		t.Errorf("Normalize(%q) with configured rules = %q, want %q", raw, got, want)
+	}
+	if got, want := ResolveCanonical(raw, "https://kb.example.com/c"), "https://kb.example.com/a?b=2&a=1"; got != want {
+		t.Errorf("ResolveCanonical() with canonical resolution off = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,16 @@
+package utils
+
+import (
+	"fmt"
+	"os"
+)
+
+// InstanceID identifies this process among the instances sharing a database, by host name and
+// process ID.
+func InstanceID() string {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "kb-crawler"
+	}
+	return fmt.Sprintf("%s-%d", host, os.Getpid())
+}
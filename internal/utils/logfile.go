@@ -0,0 +1,205 @@
+package utils
+
+import (
+	"bufio"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// rotatingFile is a log file that moves its content to a numbered part once it reaches
+// maxSize bytes and starts over.
+type rotatingFile struct {
+	mu      sync.Mutex
+	path    string
+	maxSize int64
+	file    *os.File
+	size    int64
+	parts   int
+}
+
+func openRotatingFile(path string, maxSize int64) (*rotatingFile, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	return &rotatingFile{
+		path:    path,
+		maxSize: maxSize,
+		file:    file,
+		size:    info.Size(),
+		parts:   len(rotatedParts(path)),
+	}, nil
+}
+
+func (f *rotatingFile) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.maxSize > 0 && f.size > 0 && f.size+int64(len(p)) > f.maxSize {
+		if err := f.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := f.file.Write(p)
+	f.size += int64(n)
+	return n, err
+}
+
+func (f *rotatingFile) rotate() error {
+	if err := f.file.Close(); err != nil {
+		return err
+	}
+	f.parts++
+	if err := os.Rename(f.path, f.path+"."+strconv.Itoa(f.parts)); err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(f.path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	f.file, f.size = file, 0
+	return nil
+}
+
+func (f *rotatingFile) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.file.Close()
+}
+
+// rotatedParts returns the rotated parts of a log file, oldest first.
+func rotatedParts(path string) []string {
+	matches, _ := filepath.Glob(path + ".*")
+	type part struct {
+		path string
+		n    int
+	}
+	var parts []part
+	for _, match := range matches {
+		if n, err := strconv.Atoi(strings.TrimPrefix(match, path+".")); err == nil {
+			parts = append(parts, part{match, n})
+		}
+	}
+	sort.Slice(parts, func(i, j int) bool { return parts[i].n < parts[j].n })
+
+	paths := make([]string, len(parts))
+	for i, p := range parts {
+		paths[i] = p.path
+	}
+	return paths
+}
+
+// levelPattern finds the level of a record written by the text or JSON handler.
+var levelPattern = regexp.MustCompile(`(?:\blevel=|"level":")([A-Z]+(?:[+-]\d+)?)`)
+
+// ReadLog returns the records of a run's log, including its rotated parts, at or above
+// minLevel. With tail above zero only the last tail records are returned. Lines without a
+// level, such as output from other writers, are kept.
+func ReadLog(path string, minLevel slog.Level, tail int) ([]string, error) {
+	files := append(rotatedParts(path), path)
+
+	var lines []string
+	for _, name := range files {
+		file, err := os.Open(name)
+		if err != nil {
+			if os.IsNotExist(err) && name != path {
+				continue // pruned since the glob
+			}
+			return nil, err
+		}
+
+		scanner := bufio.NewScanner(file)
+		scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+		for scanner.Scan() {
+			line := scanner.Text()
+			if m := levelPattern.FindStringSubmatch(line); m != nil && ParseLevel(m[1]) < minLevel {
+				continue
+			}
+			lines = append(lines, line)
+			if tail > 0 && len(lines) > 2*tail {
+				lines = append(lines[:0], lines[len(lines)-tail:]...)
+			}
+		}
+		file.Close()
+		if err := scanner.Err(); err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", name, err)
+		}
+	}
+
+	if tail > 0 && len(lines) > tail {
+		lines = lines[len(lines)-tail:]
+	}
+	return lines, nil
+}
+
+// PruneLogs deletes log files below dir that are older than maxAge, then the oldest files until
+// the rest fit in maxTotalSize bytes. Zero disables either limit. It returns the number of
+// files deleted.
+func PruneLogs(dir string, maxAge time.Duration, maxTotalSize int64) (int, error) {
+	type logFile struct {
+		path    string
+		size    int64
+		modTime time.Time
+	}
+
+	var files []logFile
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) && path == dir {
+				return filepath.SkipDir
+			}
+			return err
+		}
+		if d.IsDir() || !strings.Contains(d.Name(), ".log") {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		files = append(files, logFile{path, info.Size(), info.ModTime()})
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	sort.Slice(files, func(i, j int) bool { return files[i].modTime.Before(files[j].modTime) })
+
+	var total int64
+	for _, f := range files {
+		total += f.size
+	}
+
+	removed := 0
+	cutoff := time.Now().Add(-maxAge)
+	for _, f := range files {
+		expired := maxAge > 0 && f.modTime.Before(cutoff)
+		oversize := maxTotalSize > 0 && total > maxTotalSize
+		if !expired && !oversize {
+			continue
+		}
+		if err := os.Remove(f.path); err != nil && !os.IsNotExist(err) {
+			return removed, err
+		}
+		total -= f.size
+		removed++
+	}
+	return removed, nil
+}
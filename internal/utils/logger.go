@@ -3,72 +3,86 @@ package utils
 import (
 	"fmt"
 	"io"
-	"log"
+	"log/slog"
 	"os"
 	"path/filepath"
 	"strings"
 	"time"
 )
 
-type CrawlerLogger struct {
-	file       *os.File
-	logger     *log.Logger
-	multiWrite io.Writer
+// LogOptions configures the format, level, location, rotation and retention of crawl logs.
+type LogOptions struct {
+	Dir    string // each product logs to its own directory below Dir
+	Format string // "json" or "text"
+	Level  slog.Level
+	// MaxSize rotates a run's log file once it reaches this many bytes; zero disables rotation
+	MaxSize int64
+	// MaxAge and MaxTotalSize bound what PruneLogs keeps; zero disables either limit
+	MaxAge       time.Duration
+	MaxTotalSize int64
 }
 
-func NewCrawlerLogger(productName string) (*CrawlerLogger, error) {
-	// Sanitize product name for file system
-	sanitizedProduct := strings.ReplaceAll(strings.ToLower(productName), " ", "_")
+// ParseLevel converts a level name (debug, info, warn or error) to a slog level, defaulting to
+// info for unknown names.
+func ParseLevel(name string) slog.Level {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(name)); err != nil {
+		return slog.LevelInfo
+	}
+	return level
+}
 
-	// Create logs directory if it doesn't exist
-	logsDir := "logs"
-	if err := os.MkdirAll(logsDir, 0755); err != nil {
-		return nil, fmt.Errorf("failed to create logs directory: %w", err)
+// NewHandler creates a slog handler writing to w in the configured format.
+func NewHandler(w io.Writer, opts LogOptions) slog.Handler {
+	handlerOpts := &slog.HandlerOptions{Level: opts.Level}
+	if strings.EqualFold(opts.Format, "json") {
+		return slog.NewJSONHandler(w, handlerOpts)
 	}
+	return slog.NewTextHandler(w, handlerOpts)
+}
 
-	// Create product directory inside logs
-	productDir := filepath.Join(logsDir, sanitizedProduct)
+// RunLogger is the logger of one crawl run. It writes to stdout and to a log file of its own,
+// and every record carries the run ID and the attributes it was created with.
+type RunLogger struct {
+	*slog.Logger
+	file *rotatingFile
+}
+
+// NewRunLogger creates the logger for a crawl run of a product, with the given attributes (such
+// as the config ID) on every record. Close it when the run ends.
+func NewRunLogger(opts LogOptions, productName, runID string, attrs ...any) (*RunLogger, error) {
+	// Sanitize product name for file system
+	sanitizedProduct := strings.ReplaceAll(strings.ToLower(productName), " ", "_")
+
+	// Create product directory inside the logs directory
+	productDir := filepath.Join(opts.Dir, sanitizedProduct)
 	if err := os.MkdirAll(productDir, 0755); err != nil {
-		return nil, fmt.Errorf("failed to create product directory: %w", err)
+		return nil, fmt.Errorf("failed to create log directory: %w", err)
 	}
 
-	// Create log file with timestamp
+	// Create log file named by timestamp and run
 	timestamp := time.Now().Format("2006-01-02_15-04-05")
-	logPath := filepath.Join(productDir, fmt.Sprintf("crawl_%s_%s.log", sanitizedProduct, timestamp))
+	logPath := filepath.Join(productDir, fmt.Sprintf("crawl_%s_%s_%s.log", sanitizedProduct, timestamp, runID))
 
-	file, err := os.Create(logPath)
+	file, err := openRotatingFile(logPath, opts.MaxSize)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create log file: %w", err)
 	}
 
-	// Create multi-writer for both file and stdout
-	multiWrite := io.MultiWriter(os.Stdout, file)
-	logger := log.New(multiWrite, "", log.Ldate|log.Ltime|log.Lmicroseconds)
-
-	return &CrawlerLogger{
-		file:       file,
-		logger:     logger,
-		multiWrite: multiWrite,
+	handler := NewHandler(io.MultiWriter(os.Stdout, file), opts)
+	return &RunLogger{
+		Logger: slog.New(handler).With(append([]any{"run_id", runID}, attrs...)...),
+		file:   file,
 	}, nil
 }
 
-func (cl *CrawlerLogger) LogInfo(format string, v ...interface{}) {
-	cl.log("INFO", format, v...)
-}
-
-func (cl *CrawlerLogger) LogError(format string, v ...interface{}) {
-	cl.log("ERROR", format, v...)
-}
-
-func (cl *CrawlerLogger) LogDebug(format string, v ...interface{}) {
-	cl.log("DEBUG", format, v...)
-}
-
-func (cl *CrawlerLogger) log(level string, format string, v ...interface{}) {
-	message := fmt.Sprintf(format, v...)
-	cl.logger.Printf("[%s] %s", level, message)
+// Path returns the path of the run's log file. Rotated parts are kept next to it as
+// <path>.1, <path>.2 and so on, oldest first.
+func (l *RunLogger) Path() string {
+	return l.file.path
 }
 
-func (cl *CrawlerLogger) Close() error {
-	return cl.file.Close()
+// Close closes the run's log file.
+func (l *RunLogger) Close() error {
+	return l.file.Close()
 }
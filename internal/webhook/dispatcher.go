@@ -0,0 +1,231 @@
+// Package webhook delivers article change notifications to registered webhooks.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/romangod6/kb-crawler/internal/models"
+	"github.com/romangod6/kb-crawler/internal/storage"
+)
+
+const (
+	// SignatureHeader carries "sha256=" and the hex HMAC-SHA256 of the request body, keyed with
+	// the webhook's secret.
+	SignatureHeader = "X-KB-Signature"
+	EventHeader     = "X-KB-Event"
+	DeliveryHeader  = "X-KB-Delivery"
+
+	claimBatch   = 20
+	claimLease   = 2 * time.Minute
+	pollInterval = 15 * time.Second
+	baseBackoff  = 30 * time.Second
+	maxBackoff   = 6 * time.Hour
+)
+
+// Dispatcher records a delivery per webhook for each change and sends them, retrying failed
+// deliveries with exponential backoff until they are dead-lettered.
+type Dispatcher struct {
+	store       storage.Store
+	client      *http.Client
+	maxAttempts int
+	wake        chan struct{}
+}
+
+// NewDispatcher creates a dispatcher that gives up on a delivery after maxAttempts tries.
+func NewDispatcher(store storage.Store, maxAttempts int) *Dispatcher {
+	if maxAttempts < 1 {
+		maxAttempts = 1
+	}
+	return &Dispatcher{
+		store:       store,
+		client:      &http.Client{Timeout: 10 * time.Second},
+		maxAttempts: maxAttempts,
+		wake:        make(chan struct{}, 1),
+	}
+}
+
+// Payload is the JSON body POSTed to webhooks.
+type Payload struct {
+	Event  string                `json:"event"`
+	Change *models.ArticleChange `json:"change"`
+}
+
+// Notify queues a delivery of each change to every active webhook subscribed to its event and
+// wakes the delivery loop. Errors are logged; the changes stay in the change log regardless.
+func (d *Dispatcher) Notify(ctx context.Context, changes ...*models.ArticleChange) {
+	if len(changes) == 0 {
+		return
+	}
+
+	webhooks, err := d.store.ListWebhooks(ctx)
+	if err != nil {
+		log.Printf("Failed to list webhooks: %v", err)
+		return
+	}
+
+	queued := false
+	now := time.Now()
+	for _, change := range changes {
+		payload, err := json.Marshal(Payload{Event: change.Event(), Change: change})
+		if err != nil {
+			log.Printf("Failed to encode change %d: %v", change.Seq, err)
+			continue
+		}
+
+		for _, webhook := range webhooks {
+			if !webhook.Active || !subscribed(webhook, change.Event()) {
+				continue
+			}
+
+			delivery := &models.WebhookDelivery{
+				ID:            uuid.New(),
+				WebhookID:     webhook.ID,
+				ChangeSeq:     change.Seq,
+				Event:         change.Event(),
+				Payload:       payload,
+				Status:        models.DeliveryPending,
+				NextAttemptAt: now,
+				CreatedAt:     now,
+				UpdatedAt:     now,
+			}
+			if err := d.store.CreateWebhookDelivery(ctx, delivery); err != nil {
+				log.Printf("Failed to queue delivery of change %d to %s: %v", change.Seq, webhook.URL, err)
+				continue
+			}
+			queued = true
+		}
+	}
+
+	if queued {
+		select {
+		case d.wake <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// Run sends due deliveries until the context is cancelled, immediately after Notify queues new
+// ones and periodically for retries.
+func (d *Dispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		d.deliverDue(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-d.wake:
+		}
+	}
+}
+
+func (d *Dispatcher) deliverDue(ctx context.Context) {
+	for ctx.Err() == nil {
+		deliveries, err := d.store.ClaimDueWebhookDeliveries(ctx, claimBatch, claimLease)
+		if err != nil {
+			log.Printf("Failed to claim webhook deliveries: %v", err)
+			return
+		}
+
+		for _, delivery := range deliveries {
+			d.attempt(ctx, delivery)
+		}
+
+		if len(deliveries) < claimBatch {
+			return
+		}
+	}
+}
+
+// attempt sends one delivery and records the outcome.
+func (d *Dispatcher) attempt(ctx context.Context, delivery *models.WebhookDelivery) {
+	status, err := d.send(ctx, delivery)
+	now := time.Now()
+
+	delivery.Attempts++
+	delivery.ResponseStatus = status
+	switch {
+	case err == nil:
+		delivery.Status = models.DeliveryDelivered
+		delivery.DeliveredAt = &now
+		delivery.LastError = ""
+	case delivery.Attempts >= d.maxAttempts:
+		delivery.Status = models.DeliveryDead
+		delivery.LastError = err.Error()
+		log.Printf("Webhook delivery %s to %s dead-lettered after %d attempts: %v",
+			delivery.ID, delivery.URL, delivery.Attempts, err)
+	default:
+		delivery.LastError = err.Error()
+		delivery.NextAttemptAt = now.Add(backoff(delivery.Attempts))
+	}
+
+	if err := d.store.UpdateWebhookDelivery(ctx, delivery); err != nil {
+		log.Printf("Failed to record webhook delivery %s: %v", delivery.ID, err)
+	}
+}
+
+// send POSTs the signed payload and returns the response status. Non-2xx responses are errors.
+func (d *Dispatcher) send(ctx context.Context, delivery *models.WebhookDelivery) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventHeader, delivery.Event)
+	req.Header.Set(DeliveryHeader, delivery.ID.String())
+	req.Header.Set(SignatureHeader, "sha256="+Sign(delivery.Secret, delivery.Payload))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("webhook responded with status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// Sign returns the hex HMAC-SHA256 of a payload, as sent in the signature header.
+func Sign(secret string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// backoff doubles the wait after each failed attempt, up to maxBackoff.
+func backoff(attempts int) time.Duration {
+	wait := baseBackoff
+	for i := 1; i < attempts && wait < maxBackoff; i++ {
+		wait *= 2
+	}
+	return min(wait, maxBackoff)
+}
+
+func subscribed(webhook *models.Webhook, event string) bool {
+	if len(webhook.Events) == 0 {
+		return true
+	}
+	for _, e := range webhook.Events {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
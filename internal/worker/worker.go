@@ -0,0 +1,255 @@
+// Package worker fetches the pages of distributed crawl runs. The process running a run queues
+// its URLs in the database; any number of workers claim them in batches under a lease, fetch
+// and store the pages, and queue the links they discover for whichever worker claims them next.
+package worker
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/romangod6/kb-crawler/internal/blobstore"
+	"github.com/romangod6/kb-crawler/internal/crawler"
+	"github.com/romangod6/kb-crawler/internal/models"
+	"github.com/romangod6/kb-crawler/internal/storage"
+	"github.com/romangod6/kb-crawler/internal/utils"
+)
+
+// idleCrawler is how long a run's crawler is kept after the worker last fetched one of its pages.
+const idleCrawler = 10 * time.Minute
+
+// Options configures a worker.
+type Options struct {
+	// ID identifies the worker's leases; it must be unique among running workers
+	ID           string
+	BatchSize    int
+	Lease        time.Duration
+	MaxAttempts  int
+	PollInterval time.Duration
+	// AssetHosts are hosts assets may be downloaded from besides each config's allowed domains
+	AssetHosts []string
+	// PageCache keeps the pages the worker fetches for dry runs to replay; may be nil
+	PageCache *crawler.PageCache
+}
+
+// Worker claims and fetches pages of distributed crawl runs.
+type Worker struct {
+	store    storage.Store
+	assets   *blobstore.Store
+	notifier crawler.ChangeNotifier
+	opts     Options
+
+	// crawlers holds a crawler per run, so pages of a run share its state between batches
+	crawlers map[uuid.UUID]*runCrawler
+}
+
+type runCrawler struct {
+	crawler  *crawler.Crawler
+	lastUsed time.Time
+}
+
+// New creates a worker. assets stores the assets of the worker's pages and notifier receives
+// the article changes they make; either may be nil.
+func New(store storage.Store, assets *blobstore.Store, notifier crawler.ChangeNotifier, opts Options) *Worker {
+	if opts.ID == "" {
+		opts.ID = utils.InstanceID()
+	}
+	if opts.BatchSize < 1 {
+		opts.BatchSize = 10
+	}
+	if opts.Lease <= 0 {
+		opts.Lease = 2 * time.Minute
+	}
+	if opts.MaxAttempts < 1 {
+		opts.MaxAttempts = 3
+	}
+	if opts.PollInterval <= 0 {
+		opts.PollInterval = 5 * time.Second
+	}
+	return &Worker{
+		store:    store,
+		assets:   assets,
+		notifier: notifier,
+		opts:     opts,
+		crawlers: make(map[uuid.UUID]*runCrawler),
+	}
+}
+
+// Run claims and fetches batches until the context is cancelled, polling when there is no
+// work. Pages of a batch still in flight when it returns stay leased until their lease runs
+// out and are then claimed by another worker.
+func (w *Worker) Run(ctx context.Context) {
+	slog.Info("Worker started", "worker", w.opts.ID, "batch_size", w.opts.BatchSize, "lease", w.opts.Lease)
+
+	// Renew leases while batches are fetched, as the worker's heartbeat
+	heartbeatCtx, stopHeartbeat := context.WithCancel(ctx)
+	defer stopHeartbeat()
+	go w.heartbeat(heartbeatCtx)
+
+	for ctx.Err() == nil {
+		claimed, err := w.store.ClaimRunURLs(ctx, w.opts.ID, w.opts.BatchSize, w.opts.MaxAttempts, w.opts.Lease)
+		if err != nil && ctx.Err() == nil {
+			slog.Error("Failed to claim URLs", "worker", w.opts.ID, "error", err)
+		}
+		if len(claimed) > 0 {
+			w.fetch(ctx, claimed)
+			continue
+		}
+
+		w.evict()
+		select {
+		case <-ctx.Done():
+		case <-time.After(w.opts.PollInterval):
+		}
+	}
+
+	slog.Info("Worker stopped", "worker", w.opts.ID)
+}
+
+func (w *Worker) heartbeat(ctx context.Context) {
+	ticker := time.NewTicker(w.opts.Lease / 3)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if _, err := w.store.RenewRunURLLeases(ctx, w.opts.ID, w.opts.Lease); err != nil && ctx.Err() == nil {
+			slog.Warn("Failed to renew URL leases", "worker", w.opts.ID, "error", err)
+		}
+	}
+}
+
+// fetch fetches a claimed batch, grouped by run, and records the outcome of each page.
+func (w *Worker) fetch(ctx context.Context, claimed []*models.RunURL) {
+	byRun := make(map[uuid.UUID][]*models.RunURL)
+	for _, u := range claimed {
+		byRun[u.RunID] = append(byRun[u.RunID], u)
+	}
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var finished []*models.RunURL
+	for runID, urls := range byRun {
+		c, err := w.crawler(ctx, runID, urls[0].ConfigID)
+		if err != nil {
+			slog.Error("Failed to prepare crawler", "worker", w.opts.ID, "run_id", runID.String(), "error", err)
+			for _, u := range urls {
+				w.retry(u, err.Error())
+			}
+			mu.Lock()
+			finished = append(finished, urls...)
+			mu.Unlock()
+			continue
+		}
+
+		wg.Add(1)
+		go func(urls []*models.RunURL) {
+			defer wg.Done()
+			c.FetchRunURLs(ctx, urls)
+
+			mu.Lock()
+			defer mu.Unlock()
+			finished = append(finished, w.outcomes(urls)...)
+		}(urls)
+	}
+	wg.Wait()
+
+	if len(finished) == 0 {
+		return
+	}
+	if err := w.store.CompleteRunURLs(context.WithoutCancel(ctx), w.opts.ID, finished); err != nil {
+		slog.Error("Failed to record fetched URLs", "worker", w.opts.ID, "urls", len(finished), "error", err)
+	}
+}
+
+// outcomes returns the pages of a fetched batch that have an outcome, returning failed ones to
+// the queue while they have attempts left. Pages the fetch didn't get to have no status and
+// stay leased.
+func (w *Worker) outcomes(urls []*models.RunURL) []*models.RunURL {
+	var finished []*models.RunURL
+	for _, u := range urls {
+		if u.Status == "" {
+			continue
+		}
+		if u.Status == models.RunURLFailed {
+			w.retry(u, u.Error)
+		}
+		finished = append(finished, u)
+	}
+	return finished
+}
+
+// retry returns a failed page to the run's queue while it has attempts left.
+func (w *Worker) retry(u *models.RunURL, reason string) {
+	u.Error = reason
+	u.Status = models.RunURLFailed
+	if u.Attempts < w.opts.MaxAttempts {
+		u.Status = models.RunURLPending
+	}
+}
+
+// crawler returns the run's crawler, creating it from the config and overrides the run started
+// with and the category structure its coordinator stored. Runs started before configs were
+// stored with them use the config's current settings.
+func (w *Worker) crawler(ctx context.Context, runID, configID uuid.UUID) (*crawler.Crawler, error) {
+	if rc, ok := w.crawlers[runID]; ok {
+		rc.lastUsed = time.Now()
+		return rc.crawler, nil
+	}
+
+	config, err := w.store.GetRunConfig(ctx, runID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load the run's crawler config: %w", err)
+	}
+	if config == nil {
+		if config, err = w.store.GetCrawlerConfig(ctx, configID); err != nil {
+			return nil, fmt.Errorf("failed to load crawler config: %w", err)
+		}
+		if config == nil {
+			return nil, fmt.Errorf("crawler config %s not found", configID)
+		}
+	}
+
+	run, err := w.store.GetCrawlRun(ctx, runID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load run: %w", err)
+	}
+	var opts models.RunOptions
+	if run != nil && run.Options != nil {
+		opts = *run.Options
+	}
+
+	categories, err := w.store.GetRunCategories(ctx, runID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load category structure: %w", err)
+	}
+
+	crawlerConfig := crawler.RunConfig(*config, opts)
+	crawlerConfig.RunID = runID
+	crawlerConfig.AssetStore = w.assets
+	crawlerConfig.AssetHosts = w.opts.AssetHosts
+	crawlerConfig.Notifier = w.notifier
+	crawlerConfig.PageCache = w.opts.PageCache
+	crawlerConfig.Logger = slog.Default().With("worker", w.opts.ID, "run_id", runID.String(), "product", config.Product)
+
+	c := crawler.NewCrawler(w.store, crawlerConfig)
+	c.PrepareWorker(ctx, categories)
+	w.crawlers[runID] = &runCrawler{crawler: c, lastUsed: time.Now()}
+	return c, nil
+}
+
+// evict drops the crawlers of runs the worker has not fetched pages of for a while.
+func (w *Worker) evict() {
+	for runID, rc := range w.crawlers {
+		if time.Since(rc.lastUsed) > idleCrawler {
+			delete(w.crawlers, runID)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/romangod6/kb-crawler/internal/models"
+	"github.com/romangod6/kb-crawler/internal/storage"
+)
+
+// fakeStore records the URLs a worker completes. Methods the tests don't reach panic through
+// the nil embedded Store.
+type fakeStore struct {
+	storage.Store
+	runConfigErr error
+	completed    []*models.RunURL
+}
+
+func (s *fakeStore) GetRunConfig(ctx context.Context, runID uuid.UUID) (*models.CrawlerConfig, error) {
+	return nil, s.runConfigErr
+}
+
+func (s *fakeStore) CompleteRunURLs(ctx context.Context, worker string, urls []*models.RunURL) error {
+	s.completed = append(s.completed, urls...)
+	return nil
+}
+
+func TestRetry(t *testing.T) {
+	tests := []struct {
+		name     string
+		attempts int
+		want     string
+	}{
+		{name: "first attempt", attempts: 1, want: models.RunURLPending},
+		{name: "attempts left", attempts: 2, want: models.RunURLPending},
+		{name: "last attempt", attempts: 3, want: models.RunURLFailed},
+		{name: "beyond the last attempt", attempts: 4, want: models.RunURLFailed},
+	}
+	w := New(nil, nil, nil, Options{ID: "test", MaxAttempts: 3})
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u := &models.RunURL{URL: "https://kb.example.com/a", Status: models.RunURLLeased, Attempts: tt.attempts}
+			w.retry(u, "connection reset")
+			if u.Status != tt.want || u.Error != "connection reset" {
+				t.Errorf("status, error = %q, %q, want %q, %q", u.Status, u.Error, tt.want, "connection reset")
+			}
+		})
+	}
+}
+
+func TestOutcomes(t *testing.T) {
+	w := New(nil, nil, nil, Options{ID: "test", MaxAttempts: 2})
+	done := &models.RunURL{URL: "https://kb.example.com/done", Status: models.RunURLDone, Attempts: 1, StatusCode: 200}
+	retried := &models.RunURL{URL: "https://kb.example.com/retried", Status: models.RunURLFailed, Attempts: 1, Error: "404 Not Found"}
+	failed := &models.RunURL{URL: "https://kb.example.com/failed", Status: models.RunURLFailed, Attempts: 2, Error: "timeout"}
+	unfetched := &models.RunURL{URL: "https://kb.example.com/unfetched", Attempts: 1}
+
+	got := w.outcomes([]*models.RunURL{done, retried, failed, unfetched})
+	if len(got) != 3 || got[0] != done || got[1] != retried || got[2] != failed {
+		t.Fatalf("outcomes() = %v, want the done, retried and failed pages", got)
+	}
+	if done.Status != models.RunURLDone {
+		t.Errorf("done page status = %q, want %q", done.Status, models.RunURLDone)
+	}
+	if retried.Status != models.RunURLPending || retried.Error != "404 Not Found" {
+		t.Errorf("retried page status, error = %q, %q, want pending with its error", retried.Status, retried.Error)
+	}
+	if failed.Status != models.RunURLFailed {
+		t.Errorf("failed page status = %q, want %q", failed.Status, models.RunURLFailed)
+	}
+}
+
+func TestFetchRetriesWhenCrawlerCantBePrepared(t *testing.T) {
+	store := &fakeStore{runConfigErr: errors.New("connection refused")}
+	w := New(store, nil, nil, Options{ID: "test", MaxAttempts: 2})
+	runID := uuid.New()
+	first := &models.RunURL{RunID: runID, URL: "https://kb.example.com/a", Status: models.RunURLLeased, Attempts: 1}
+	last := &models.RunURL{RunID: runID, URL: "https://kb.example.com/b", Status: models.RunURLLeased, Attempts: 2}
+
+	w.fetch(context.Background(), []*models.RunURL{first, last})
+
+	if len(store.completed) != 2 {
+		t.Fatalf("completed %d URLs, want 2", len(store.completed))
+	}
+	if first.Status != models.RunURLPending {
+		t.Errorf("page with attempts left: status = %q, want %q", first.Status, models.RunURLPending)
+	}
+	if last.Status != models.RunURLFailed {
+		t.Errorf("page on its last attempt: status = %q, want %q", last.Status, models.RunURLFailed)
+	}
+	for _, u := range store.completed {
+		if u.Error == "" {
+			t.Errorf("%s: no error recorded", u.URL)
+		}
+	}
+	if _, ok := w.crawlers[runID]; ok {
+		t.Error("a crawler was kept for the run although it couldn't be prepared")
+	}
+}
@@ -0,0 +1,110 @@
+// tools/urlmerge/url_merger.go
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+
+	"github.com/google/uuid"
+	"github.com/romangod6/kb-crawler/config"
+	"github.com/romangod6/kb-crawler/internal/models"
+	"github.com/romangod6/kb-crawler/internal/storage"
+	"github.com/romangod6/kb-crawler/internal/urlnorm"
+)
+
+// url_merger collapses articles whose URLs normalize to the same key, such as Page.htm,
+// Page.htm?tocpath=... and Page.htm#section, into a single article stored under the
+// normalized URL.
+func main() {
+	dryRun := flag.Bool("dry-run", false, "report the merges without changing the database")
+	flag.Parse()
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		log.Fatalf("Failed to load config: %v", err)
+	}
+	urlnorm.Configure(cfg.URLRules())
+
+	store, err := storage.NewPostgresStore(cfg.Database.URL)
+	if err != nil {
+		log.Fatalf("Failed to initialize storage: %v", err)
+	}
+	defer store.Close()
+
+	if err := store.Initialize(); err != nil {
+		log.Fatalf("Failed to initialize database tables: %v", err)
+	}
+
+	ctx := context.Background()
+	refs, err := store.ListArticleRefs(ctx)
+	if err != nil {
+		log.Fatalf("Failed to list articles: %v", err)
+	}
+
+	// Group articles by their normalized storage key, keeping first-seen order
+	groups := make(map[string][]*models.ArticleRef)
+	var keys []string
+	for _, ref := range refs {
+		key := urlnorm.ResolveCanonical(ref.URL, ref.CanonicalURL)
+		if _, ok := groups[key]; !ok {
+			keys = append(keys, key)
+		}
+		groups[key] = append(groups[key], ref)
+	}
+
+	merged, renamed, failed := 0, 0, 0
+	for _, key := range keys {
+		group := groups[key]
+		if len(group) == 1 && group[0].URL == key {
+			continue
+		}
+
+		keep := pickKeeper(key, group)
+		var duplicates []uuid.UUID
+		for _, ref := range group {
+			if ref.ID != keep.ID {
+				duplicates = append(duplicates, ref.ID)
+				log.Printf("  merge %s (%s)", ref.URL, ref.ID)
+			}
+		}
+		log.Printf("%s <- keep %s (%s), %d duplicate(s)", key, keep.URL, keep.ID, len(duplicates))
+
+		if *dryRun {
+			continue
+		}
+
+		if err := store.MergeArticles(ctx, keep.ID, duplicates, key); err != nil {
+			log.Printf("Failed to merge articles for %s: %v", key, err)
+			failed++
+			continue
+		}
+
+		if len(duplicates) > 0 {
+			merged += len(duplicates)
+		} else {
+			renamed++
+		}
+	}
+
+	if *dryRun {
+		log.Println("Dry run, no changes were made")
+		return
+	}
+	log.Printf("Merged %d duplicate articles, renamed %d articles, %d failures", merged, renamed, failed)
+}
+
+// pickKeeper prefers the article already stored under the normalized key, otherwise the most
+// recently updated one.
+func pickKeeper(key string, group []*models.ArticleRef) *models.ArticleRef {
+	keep := group[0]
+	for _, ref := range group {
+		if ref.URL == key {
+			return ref
+		}
+		if ref.UpdatedAt.After(keep.UpdatedAt) {
+			keep = ref
+		}
+	}
+	return keep
+}